@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"WarehouseManager/internal/model"
+	"errors"
+)
+
+// ErrApprovalPending is returned by the consumptions and transfers exceeding an approval threshold: instead of being
+// executed they wait in the inbox of the manager of the user
+var ErrApprovalPending = errors.New("the movement exceeds the approval threshold and waits for your manager's approval")
+
+// ErrApprovalRequired is returned by the movements exceeding an approval threshold which can't be held back: batches,
+// shipments and write-offs, which have to be split into single consumptions and transfers, and every movement of the
+// users without a manager
+var ErrApprovalRequired = errors.New("the movement exceeds the approval threshold and can't be held for approval")
+
+// PendingApproval is an approval request waiting in the inbox of a manager, together with the member of the team who
+// made it
+type PendingApproval struct {
+	model.ApprovalEntry
+	RequesterID uint
+	Requester   string
+}
+
+func (manager *AuthenticationManager) ChooseManager(userID uint, username string) error {
+	_, err1 := manager.checkLogin(userID)
+	if err1 != nil {
+		return err1
+	}
+	index, err2 := manager.findUser(userID)
+	if err2 != nil {
+		return err2
+	}
+	managerIndex := -1
+	for i, v := range manager.Users {
+		if v.Username == username {
+			managerIndex = i
+		}
+	}
+	if managerIndex < 0 {
+		return errors.New("unknown user: " + username)
+	}
+	managerID := manager.Users[managerIndex].UserID
+	if managerID == userID {
+		return errors.New("you can't approve your own movements")
+	}
+	// only the current manager can release a member, who can't move to a more lenient team by themselves
+	if manager.Users[index].ManagerID != nil {
+		return errors.New("you already have a manager")
+	}
+	// nobody can end up approving the movements of their own manager
+	chained, err3 := manager.isInManagerChain(managerID, userID)
+	if err3 != nil {
+		return err3
+	}
+	if chained {
+		return errors.New("user " + username + " is in your team")
+	}
+	manager.Users[index].ManagerID = &managerID
+	return manager.Save()
+}
+
+func (manager *AuthenticationManager) SetMemberThreshold(userID uint, memberID uint, threshold int) error {
+	_, err1 := manager.checkLogin(userID)
+	if err1 != nil {
+		return err1
+	}
+	if threshold < 0 {
+		return errors.New("approval threshold can't be negative")
+	}
+	memberIndex, err2 := manager.findTeamMember(userID, memberID)
+	if err2 != nil {
+		return err2
+	}
+	manager.Users[memberIndex].ApprovalThreshold = threshold
+	return manager.Save()
+}
+
+func (manager *AuthenticationManager) ReleaseTeamMember(userID uint, memberID uint) error {
+	_, err1 := manager.checkLogin(userID)
+	if err1 != nil {
+		return err1
+	}
+	memberIndex, err2 := manager.findTeamMember(userID, memberID)
+	if err2 != nil {
+		return err2
+	}
+	manager.Users[memberIndex].ManagerID = nil
+	manager.Users[memberIndex].ApprovalThreshold = 0
+	return manager.Save()
+}
+
+func (manager *AuthenticationManager) ListTeamMembers(userID uint) ([]User, error) {
+	_, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]User, 0)
+	for _, v := range manager.Users {
+		if v.ManagerID != nil && *v.ManagerID == userID {
+			members = append(members, v)
+		}
+	}
+	return members, nil
+}
+
+func (manager *AuthenticationManager) ListApprovalInbox(userID uint) ([]PendingApproval, error) {
+	members, err1 := manager.ListTeamMembers(userID)
+	if err1 != nil {
+		return nil, err1
+	}
+	inbox := make([]PendingApproval, 0)
+	for _, member := range members {
+		db, closeDB, err2 := manager.openUserDatabase(member)
+		if err2 != nil {
+			return nil, err2
+		}
+		requests, err3 := db.ListApprovalRequests(model.PendingApproval)
+		closeDB()
+		if err3 != nil {
+			return nil, err3
+		}
+		for _, request := range requests {
+			inbox = append(inbox, PendingApproval{ApprovalEntry: request, RequesterID: member.UserID, Requester: member.Username})
+		}
+	}
+	return inbox, nil
+}
+
+func (manager *AuthenticationManager) DecideApproval(userID uint, memberID uint, requestID uint, approved bool, comment string) error {
+	index, err1 := manager.checkLogin(userID)
+	if err1 != nil {
+		return err1
+	}
+	memberIndex, err2 := manager.findTeamMember(userID, memberID)
+	if err2 != nil {
+		return err2
+	}
+	db, closeDB, err3 := manager.openUserDatabase(manager.Users[memberIndex])
+	if err3 != nil {
+		return err3
+	}
+	defer closeDB()
+	approver := manager.ActiveUsers[index].User.Username
+	if approved {
+		return db.ApproveRequest(requestID, approver)
+	}
+	return db.RejectRequest(requestID, approver, comment)
+}
+
+// needsApproval tells whether the quantity exceeds the threshold of the logged-in user or of the item
+func (manager *AuthenticationManager) needsApproval(index int, itemID uint, quantity int) (bool, error) {
+	userIndex, err1 := manager.findUser(manager.ActiveUsers[index].User.UserID)
+	if err1 != nil {
+		return false, err1
+	}
+	user := manager.Users[userIndex]
+	item, err2 := manager.ActiveUsers[index].DB.FindItemByID(itemID)
+	if err2 != nil {
+		return false, err2
+	}
+	return (user.ApprovalThreshold > 0 && quantity > user.ApprovalThreshold) ||
+		(item.ApprovalThreshold > 0 && quantity > item.ApprovalThreshold), nil
+}
+
+// holdForApproval stores the request and returns ErrApprovalPending when it needs an approval. The movements of the
+// users without a manager are rejected since nobody could approve them.
+func (manager *AuthenticationManager) holdForApproval(index int, request model.ApprovalRequest) error {
+	needed, err1 := manager.needsApproval(index, request.ItemID, request.Quantity)
+	if err1 != nil || !needed {
+		return err1
+	}
+	userIndex, err2 := manager.findUser(manager.ActiveUsers[index].User.UserID)
+	if err2 != nil {
+		return err2
+	}
+	if manager.Users[userIndex].ManagerID == nil {
+		return ErrApprovalRequired
+	}
+	request.RequestedBy = manager.ActiveUsers[index].User.Username
+	_, err3 := manager.ActiveUsers[index].DB.RequestApproval(request)
+	if err3 != nil {
+		return err3
+	}
+	return ErrApprovalPending
+}
+
+// rejectAboveThreshold returns ErrApprovalRequired when the movement needs an approval
+func (manager *AuthenticationManager) rejectAboveThreshold(index int, itemID uint, quantity int) error {
+	needed, err := manager.needsApproval(index, itemID, quantity)
+	if err != nil {
+		return err
+	}
+	if needed {
+		return ErrApprovalRequired
+	}
+	return nil
+}
+
+// isInManagerChain tells whether userID is managerID or one of the managers above it
+func (manager *AuthenticationManager) isInManagerChain(managerID uint, userID uint) (bool, error) {
+	for current := &managerID; current != nil; {
+		if *current == userID {
+			return true, nil
+		}
+		index, err := manager.findUser(*current)
+		if err != nil {
+			return false, err
+		}
+		current = manager.Users[index].ManagerID
+	}
+	return false, nil
+}
+
+// findUser returns the index in Users of the user identified by userID
+func (manager *AuthenticationManager) findUser(userID uint) (int, error) {
+	for i, v := range manager.Users {
+		if v.UserID == userID {
+			return i, nil
+		}
+	}
+	return 0, errors.New("unknown user")
+}
+
+// findTeamMember returns the index in Users of the user identified by memberID if it is managed by userID
+func (manager *AuthenticationManager) findTeamMember(userID uint, memberID uint) (int, error) {
+	index, err := manager.findUser(memberID)
+	if err != nil {
+		return 0, err
+	}
+	if manager.Users[index].ManagerID == nil || *manager.Users[index].ManagerID != userID {
+		return 0, errors.New("user " + manager.Users[index].Username + " isn't in your team")
+	}
+	return index, nil
+}
+
+// openUserDatabase returns the repository of a user, opening it when the user isn't logged in. The returned function
+// closes the repository if it was opened.
+func (manager *AuthenticationManager) openUserDatabase(user User) (model.WarehouseRepository, func(), error) {
+	for _, v := range manager.ActiveUsers {
+		if v.User.UserID == user.UserID {
+			return v.DB, func() {}, nil
+		}
+	}
+	db, err := manager.injector(user.AssignedDatabase)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, func() { _ = db.Close() }, nil
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"time"
 )
 
 // User represents an app user with unique ID, username, encrypted password, and assigned database.
@@ -16,6 +17,11 @@ type User struct {
 	Username          string `json:"username"`
 	EncryptedPassword string `json:"password"`
 	AssignedDatabase  string `json:"assignedDatabase"`
+	// ManagerID is the user approving the large movements of this user, if any
+	ManagerID *uint `json:"managerID,omitempty"`
+	// ApprovalThreshold is the quantity above which the consumptions and transfers of this user need the approval of
+	// the manager, 0 when only the thresholds of the items apply
+	ApprovalThreshold int `json:"approvalThreshold,omitempty"`
 }
 
 // GeneralAuthenticationManager is an interface managing user sessions and resource operations for items and warehouses.
@@ -54,8 +60,8 @@ type GeneralAuthenticationManager interface {
 	FindWarehousesForItem(userID uint, itemID uint) ([]model.LoadedItemPack, error)
 	CreateItem(userID uint, name string, category string, description string) error
 	CreateWarehouse(userID uint, name string, position string, capacity int) error
-	UpdateItem(userID uint, itemID uint, name string, category string, description string) error
-	UpdateWarehouse(userID uint, warehouseID uint, name string, position string, capacity int) error
+	UpdateItem(userID uint, itemID uint, name string, category string, description string, version uint) error
+	UpdateWarehouse(userID uint, warehouseID uint, name string, position string, capacity int, version uint) error
 	DeleteItem(userID uint, itemID uint) error
 	DeleteWarehouse(userID uint, warehouseID uint) error
 	SupplyItems(userID uint, itemID uint, warehouseID uint, quantity int) error
@@ -63,6 +69,99 @@ type GeneralAuthenticationManager interface {
 	TransferItems(userID uint, itemID uint, sourceWarehouseID uint, quantity int, destinationWarehouseID uint) error
 	ListAllItems(userID uint) ([]model.Item, error)
 	ListAllWarehouses(userID uint) ([]model.Warehouse, error)
+	ApplyStockBatch(userID uint, lines []model.StockLine) error
+	PreviewStockBatch(userID uint, lines []model.StockLine) ([]model.StockLineResult, error)
+	CheckConsistency(userID uint) ([]model.Inconsistency, error)
+	RepairConsistency(userID uint) ([]model.Inconsistency, error)
+	UpdateWarehouseLocation(userID uint, warehouseID uint, location model.WarehouseLocation) error
+	FindNearestStock(userID uint, itemID uint, minQuantity int, latitude float64, longitude float64) ([]model.NearbyStock, error)
+	ListCategories(userID uint) ([]model.CategoryNode, error)
+	CreateCategory(userID uint, name string, parentID *uint) error
+	RenameCategory(userID uint, categoryID uint, name string) error
+	MoveCategory(userID uint, categoryID uint, parentID *uint) error
+	MergeCategories(userID uint, sourceID uint, targetID uint) error
+	CreateAttributeDefinition(userID uint, categoryID uint, name string, attributeType model.AttributeType, options []string) error
+	DeleteAttributeDefinition(userID uint, definitionID uint) error
+	ListAttributeDefinitions(userID uint, categoryID uint) ([]model.AttributeDefinition, error)
+	FindItemAttributes(userID uint, itemID uint) ([]model.ItemAttribute, error)
+	SetItemAttributes(userID uint, itemID uint, values map[uint]string) error
+	FindItemsByAttributes(userID uint, filters []model.AttributeFilter) ([]model.Item, error)
+	AddAttachment(userID uint, ownerType model.AttachmentOwner, ownerID uint, fileName string, content []byte) (model.Attachment, error)
+	ListAttachments(userID uint, ownerType model.AttachmentOwner, ownerID uint) ([]model.Attachment, error)
+	OpenAttachment(userID uint, attachmentID uint, thumbnail bool) (model.Attachment, []byte, error)
+	DeleteAttachment(userID uint, attachmentID uint) error
+	CreateProduct(userID uint, name string, description string, dimensions []string) error
+	FindProductByID(userID uint, productID uint) (model.Product, error)
+	ListProducts(userID uint) ([]model.ProductSummary, error)
+	AddVariant(userID uint, productID uint, itemID uint, values map[string]string) error
+	RemoveVariant(userID uint, itemID uint) error
+	FindVariantMatrix(userID uint, productID uint) (model.VariantMatrix, error)
+	SetBOMLine(userID uint, kitID uint, componentID uint, quantity int) error
+	FindBOM(userID uint, kitID uint) ([]model.BOMComponent, error)
+	FindBuildableQuantity(userID uint, kitID uint) (model.BuildableStock, error)
+	AssembleKits(userID uint, kitID uint, warehouseID uint, count int) error
+	DisassembleKits(userID uint, kitID uint, warehouseID uint, count int) error
+	FindReplenishment(userID uint, parameters model.ReplenishmentParameters) ([]model.ReplenishmentSuggestion, error)
+	UpdateItemUnitCost(userID uint, itemID uint, unitCost float64) error
+	FindClassification(userID uint, parameters model.ClassificationParameters) (model.ClassificationReport, error)
+	ClassifyItems(userID uint, parameters model.ClassificationParameters) (model.ClassificationReport, error)
+	FindRecentMovements(userID uint, limit int) ([]model.MovementEntry, error)
+	FindDashboard(userID uint, days int, limit int) (model.Dashboard, error)
+	TakeInventorySnapshot(userID uint) (model.InventorySnapshot, error)
+	ListInventorySnapshots(userID uint) ([]model.InventorySnapshot, error)
+	FindInventoryAsOf(userID uint, at time.Time, warehouseID uint) ([]model.InventoryLine, error)
+	CompareInventory(userID uint, from time.Time, to time.Time, warehouseID uint) ([]model.InventoryDifference, error)
+	DispatchShipment(userID uint, itemID uint, sourceWarehouseID uint, quantity int, destinationWarehouseID uint) (model.Shipment, error)
+	ReceiveShipment(userID uint, shipmentID uint, receivedQuantity int) error
+	ListShipments(userID uint, status model.ShipmentStatus) ([]model.ShipmentEntry, error)
+	FindIncomingQuantity(userID uint, warehouseID uint) (int, error)
+	SetStockLevel(userID uint, itemID uint, warehouseID uint, min int, max int) error
+	ListStockLevels(userID uint) ([]model.StockLevelEntry, error)
+	PlanRebalancing(userID uint) ([]model.RebalancingMove, error)
+	UpdateWarehousePutawayPriority(userID uint, warehouseID uint, priority int) error
+	PlanPutaway(userID uint, itemID uint, quantity int, strategy model.PutawayStrategy) ([]model.PutawayAllocation, error)
+	ApplyPutaway(userID uint, itemID uint, quantity int, strategy model.PutawayStrategy) ([]model.PutawayAllocation, error)
+	SupplyItemsWithStatus(userID uint, itemID uint, warehouseID uint, quantity int, status model.StockStatus, note model.MovementNote) error
+	ConsumeItemsFromStatus(userID uint, itemID uint, warehouseID uint, quantity int, status model.StockStatus, note model.MovementNote) error
+	ChangeStockStatus(userID uint, itemID uint, warehouseID uint, quantity int, from model.StockStatus, to model.StockStatus) error
+	FindStockStatuses(userID uint, itemID uint, warehouseID uint) ([]model.StockStatusLine, error)
+	AuthorizeReturn(userID uint, kind model.ReturnKind, itemID uint, quantity int, reason string, orderReference string, movementID *uint) (model.ReturnAuthorization, error)
+	ReceiveReturn(userID uint, returnID uint, warehouseID uint, quantity int, disposition model.ReturnDisposition) error
+	ShipSupplierReturn(userID uint, returnID uint, warehouseID uint, status model.StockStatus) error
+	ListReturns(userID uint, status model.ReturnStatus) ([]model.ReturnEntry, error)
+	TransferItemsWithNote(userID uint, itemID uint, sourceWarehouseID uint, quantity int, destinationWarehouseID uint, note model.MovementNote) error
+	ListReasonCodes(userID uint) ([]model.ReasonCode, error)
+	CreateReasonCode(userID uint, code string, description string, writeOff bool) error
+	DeleteReasonCode(userID uint, reasonID uint) error
+	WriteOffItems(userID uint, itemID uint, warehouseID uint, quantity int, status model.StockStatus, note model.MovementNote) error
+	FindShrinkage(userID uint, from time.Time, to time.Time) (model.ShrinkageReport, error)
+	SetItemArchived(userID uint, itemID uint, archived bool) error
+	ListArchivedItems(userID uint) ([]model.Item, error)
+	MergeItems(userID uint, duplicateID uint, canonicalID uint) error
+	CreateSupplier(userID uint, name string, contact string) error
+	ListSuppliers(userID uint) ([]model.Supplier, error)
+	DeleteSupplier(userID uint, supplierID uint) error
+	SetSupplierItem(userID uint, entry model.SupplierItem) error
+	RemoveSupplierItem(userID uint, supplierID uint, itemID uint) error
+	ListSupplierItems(userID uint, itemID uint, supplierID uint) ([]model.SupplierItemEntry, error)
+	FindItemsBySupplierPartNumber(userID uint, partNumber string) ([]model.Item, error)
+	CheckOutItems(userID uint, itemID uint, warehouseID uint, quantity int, borrower string, serialNumber string, dueAt time.Time) (model.Loan, error)
+	CheckInLoan(userID uint, loanID uint, warehouseID uint) error
+	ListLoans(userID uint, open bool) ([]model.LoanEntry, error)
+	FindOverdueLoans(userID uint, now time.Time) ([]model.LoanEntry, error)
+	CreateProject(userID uint, code string, name string) error
+	ListProjects(userID uint) ([]model.Project, error)
+	SetProjectClosed(userID uint, projectID uint, closed bool) error
+	FindProjectConsumption(userID uint, from time.Time, to time.Time) (model.ProjectReport, error)
+	SetItemApprovalThreshold(userID uint, itemID uint, threshold int) error
+	ListApprovalRequests(userID uint, status model.ApprovalStatus) ([]model.ApprovalEntry, error)
+	FindApprovalEvents(userID uint, requestID uint) ([]model.ApprovalEvent, error)
+	ChooseManager(userID uint, username string) error
+	SetMemberThreshold(userID uint, memberID uint, threshold int) error
+	ReleaseTeamMember(userID uint, memberID uint) error
+	ListTeamMembers(userID uint) ([]User, error)
+	ListApprovalInbox(userID uint) ([]PendingApproval, error)
+	DecideApproval(userID uint, memberID uint, requestID uint, approved bool, comment string) error
 }
 
 // AuthenticationManager implements GeneralAuthenticationManager by using an underlying WarehouseRepository.
@@ -228,7 +327,8 @@ func (manager *AuthenticationManager) ChangePassword(username string, oldPasswor
 		return errors.New("new password must be at least 8 characters long")
 	}
 	manager.ActiveUsers[index].User.EncryptedPassword = ShaHashing(newPassword)
-	manager.Users[manager.ActiveUsers[index].User.UserID] = *manager.ActiveUsers[index].User
+	// the other fields of the user may have changed since the login
+	manager.Users[manager.ActiveUsers[index].User.UserID].EncryptedPassword = ShaHashing(newPassword)
 	return manager.Save()
 }
 
@@ -320,20 +420,20 @@ func (manager *AuthenticationManager) CreateWarehouse(userID uint, name string,
 	return manager.ActiveUsers[index].DB.CreateWarehouse(name, position, capacity)
 }
 
-func (manager *AuthenticationManager) UpdateItem(userID uint, itemID uint, name string, category string, description string) error {
+func (manager *AuthenticationManager) UpdateItem(userID uint, itemID uint, name string, category string, description string, version uint) error {
 	index, err := manager.checkLogin(userID)
 	if err != nil {
 		return err
 	}
-	return manager.ActiveUsers[index].DB.UpdateItem(itemID, name, category, description)
+	return manager.ActiveUsers[index].DB.UpdateItem(itemID, name, category, description, version)
 }
 
-func (manager *AuthenticationManager) UpdateWarehouse(userID uint, warehouseID uint, name string, position string, capacity int) error {
+func (manager *AuthenticationManager) UpdateWarehouse(userID uint, warehouseID uint, name string, position string, capacity int, version uint) error {
 	index, err := manager.checkLogin(userID)
 	if err != nil {
 		return err
 	}
-	return manager.ActiveUsers[index].DB.UpdateWarehouse(warehouseID, name, position, capacity)
+	return manager.ActiveUsers[index].DB.UpdateWarehouse(warehouseID, name, position, capacity, version)
 }
 
 func (manager *AuthenticationManager) DeleteItem(userID uint, itemID uint) error {
@@ -365,6 +465,11 @@ func (manager *AuthenticationManager) ConsumeItems(userID uint, itemID uint, war
 	if err != nil {
 		return err
 	}
+	err1 := manager.holdForApproval(index, model.ApprovalRequest{Kind: model.ConsumeMovement, ItemID: itemID,
+		WarehouseID: warehouseID, Quantity: quantity, StockStatus: model.AvailableStatus})
+	if err1 != nil {
+		return err1
+	}
 	return manager.ActiveUsers[index].DB.ConsumeItems(itemID, warehouseID, quantity)
 }
 
@@ -373,6 +478,11 @@ func (manager *AuthenticationManager) TransferItems(userID uint, itemID uint, so
 	if err != nil {
 		return err
 	}
+	err1 := manager.holdForApproval(index, model.ApprovalRequest{Kind: model.TransferMovement, ItemID: itemID,
+		WarehouseID: sourceWarehouseID, DestinationWarehouseID: destinationWarehouseID, Quantity: quantity})
+	if err1 != nil {
+		return err1
+	}
 	return manager.ActiveUsers[index].DB.TransferItems(itemID, sourceWarehouseID, quantity, destinationWarehouseID)
 }
 
@@ -406,3 +516,789 @@ func (manager *AuthenticationManager) ListAllWarehouses(userID uint) ([]model.Wa
 	}
 	return manager.ActiveUsers[index].DB.ListAllWarehouses()
 }
+
+func (manager *AuthenticationManager) ApplyStockBatch(userID uint, lines []model.StockLine) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	// the lines are summed so that a large movement split into many lines is checked as a whole
+	type batchTotal struct {
+		operation model.StockOperation
+		itemID    uint
+	}
+	totals := make(map[batchTotal]int)
+	order := make([]batchTotal, 0)
+	for _, line := range lines {
+		if line.Operation != model.ConsumeOperation && line.Operation != model.TransferOperation {
+			continue
+		}
+		key := batchTotal{operation: line.Operation, itemID: line.ItemID}
+		if _, ok := totals[key]; !ok {
+			order = append(order, key)
+		}
+		totals[key] += line.Quantity
+	}
+	for _, key := range order {
+		err1 := manager.rejectAboveThreshold(index, key.itemID, totals[key])
+		if err1 != nil {
+			return errors.New(string(key.operation) + " of item " + strconv.Itoa(int(key.itemID)) + " in the batch: " +
+				err1.Error())
+		}
+	}
+	return manager.ActiveUsers[index].DB.ApplyStockBatch(lines)
+}
+
+func (manager *AuthenticationManager) PreviewStockBatch(userID uint, lines []model.StockLine) ([]model.StockLineResult, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.PreviewStockBatch(lines)
+}
+
+func (manager *AuthenticationManager) CheckConsistency(userID uint) ([]model.Inconsistency, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.CheckConsistency()
+}
+
+func (manager *AuthenticationManager) RepairConsistency(userID uint) ([]model.Inconsistency, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.RepairConsistency()
+}
+
+func (manager *AuthenticationManager) UpdateWarehouseLocation(userID uint, warehouseID uint, location model.WarehouseLocation) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.UpdateWarehouseLocation(warehouseID, location)
+}
+
+func (manager *AuthenticationManager) FindNearestStock(userID uint, itemID uint, minQuantity int, latitude float64, longitude float64) ([]model.NearbyStock, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.FindNearestStock(itemID, minQuantity, latitude, longitude)
+}
+
+func (manager *AuthenticationManager) ListCategories(userID uint) ([]model.CategoryNode, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListCategories()
+}
+
+func (manager *AuthenticationManager) CreateCategory(userID uint, name string, parentID *uint) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.CreateCategory(name, parentID)
+}
+
+func (manager *AuthenticationManager) RenameCategory(userID uint, categoryID uint, name string) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.RenameCategory(categoryID, name)
+}
+
+func (manager *AuthenticationManager) MoveCategory(userID uint, categoryID uint, parentID *uint) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.MoveCategory(categoryID, parentID)
+}
+
+func (manager *AuthenticationManager) MergeCategories(userID uint, sourceID uint, targetID uint) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.MergeCategories(sourceID, targetID)
+}
+
+func (manager *AuthenticationManager) CreateAttributeDefinition(userID uint, categoryID uint, name string, attributeType model.AttributeType, options []string) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.CreateAttributeDefinition(categoryID, name, attributeType, options)
+}
+
+func (manager *AuthenticationManager) DeleteAttributeDefinition(userID uint, definitionID uint) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.DeleteAttributeDefinition(definitionID)
+}
+
+func (manager *AuthenticationManager) ListAttributeDefinitions(userID uint, categoryID uint) ([]model.AttributeDefinition, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListAttributeDefinitions(categoryID)
+}
+
+func (manager *AuthenticationManager) FindItemAttributes(userID uint, itemID uint) ([]model.ItemAttribute, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.FindItemAttributes(itemID)
+}
+
+func (manager *AuthenticationManager) SetItemAttributes(userID uint, itemID uint, values map[uint]string) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.SetItemAttributes(itemID, values)
+}
+
+func (manager *AuthenticationManager) FindItemsByAttributes(userID uint, filters []model.AttributeFilter) ([]model.Item, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.FindItemsByAttributes(filters)
+}
+
+func (manager *AuthenticationManager) AddAttachment(userID uint, ownerType model.AttachmentOwner, ownerID uint, fileName string, content []byte) (model.Attachment, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	return manager.ActiveUsers[index].DB.AddAttachment(ownerType, ownerID, fileName, content)
+}
+
+func (manager *AuthenticationManager) ListAttachments(userID uint, ownerType model.AttachmentOwner, ownerID uint) ([]model.Attachment, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListAttachments(ownerType, ownerID)
+}
+
+func (manager *AuthenticationManager) OpenAttachment(userID uint, attachmentID uint, thumbnail bool) (model.Attachment, []byte, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return model.Attachment{}, nil, err
+	}
+	return manager.ActiveUsers[index].DB.OpenAttachment(attachmentID, thumbnail)
+}
+
+func (manager *AuthenticationManager) DeleteAttachment(userID uint, attachmentID uint) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.DeleteAttachment(attachmentID)
+}
+
+func (manager *AuthenticationManager) CreateProduct(userID uint, name string, description string, dimensions []string) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.CreateProduct(name, description, dimensions)
+}
+
+func (manager *AuthenticationManager) FindProductByID(userID uint, productID uint) (model.Product, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return model.Product{}, err
+	}
+	return manager.ActiveUsers[index].DB.FindProductByID(productID)
+}
+
+func (manager *AuthenticationManager) ListProducts(userID uint) ([]model.ProductSummary, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListProducts()
+}
+
+func (manager *AuthenticationManager) AddVariant(userID uint, productID uint, itemID uint, values map[string]string) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.AddVariant(productID, itemID, values)
+}
+
+func (manager *AuthenticationManager) RemoveVariant(userID uint, itemID uint) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.RemoveVariant(itemID)
+}
+
+func (manager *AuthenticationManager) FindVariantMatrix(userID uint, productID uint) (model.VariantMatrix, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return model.VariantMatrix{}, err
+	}
+	return manager.ActiveUsers[index].DB.FindVariantMatrix(productID)
+}
+
+func (manager *AuthenticationManager) SetBOMLine(userID uint, kitID uint, componentID uint, quantity int) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.SetBOMLine(kitID, componentID, quantity)
+}
+
+func (manager *AuthenticationManager) FindBOM(userID uint, kitID uint) ([]model.BOMComponent, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.FindBOM(kitID)
+}
+
+func (manager *AuthenticationManager) FindBuildableQuantity(userID uint, kitID uint) (model.BuildableStock, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return model.BuildableStock{}, err
+	}
+	return manager.ActiveUsers[index].DB.FindBuildableQuantity(kitID)
+}
+
+func (manager *AuthenticationManager) AssembleKits(userID uint, kitID uint, warehouseID uint, count int) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	components, err1 := manager.ActiveUsers[index].DB.FindBOM(kitID)
+	if err1 != nil {
+		return err1
+	}
+	for _, component := range components {
+		err2 := manager.rejectAboveThreshold(index, component.ComponentID, count*component.Quantity)
+		if err2 != nil {
+			return err2
+		}
+	}
+	return manager.ActiveUsers[index].DB.AssembleKits(kitID, warehouseID, count)
+}
+
+func (manager *AuthenticationManager) DisassembleKits(userID uint, kitID uint, warehouseID uint, count int) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	err1 := manager.rejectAboveThreshold(index, kitID, count)
+	if err1 != nil {
+		return err1
+	}
+	return manager.ActiveUsers[index].DB.DisassembleKits(kitID, warehouseID, count)
+}
+
+func (manager *AuthenticationManager) FindReplenishment(userID uint, parameters model.ReplenishmentParameters) ([]model.ReplenishmentSuggestion, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.FindReplenishment(parameters)
+}
+
+func (manager *AuthenticationManager) UpdateItemUnitCost(userID uint, itemID uint, unitCost float64) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.UpdateItemUnitCost(itemID, unitCost)
+}
+
+func (manager *AuthenticationManager) FindClassification(userID uint, parameters model.ClassificationParameters) (model.ClassificationReport, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return model.ClassificationReport{}, err
+	}
+	return manager.ActiveUsers[index].DB.FindClassification(parameters)
+}
+
+func (manager *AuthenticationManager) ClassifyItems(userID uint, parameters model.ClassificationParameters) (model.ClassificationReport, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return model.ClassificationReport{}, err
+	}
+	return manager.ActiveUsers[index].DB.ClassifyItems(parameters)
+}
+
+func (manager *AuthenticationManager) FindRecentMovements(userID uint, limit int) ([]model.MovementEntry, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.FindRecentMovements(limit)
+}
+
+func (manager *AuthenticationManager) FindDashboard(userID uint, days int, limit int) (model.Dashboard, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return model.Dashboard{}, err
+	}
+	return manager.ActiveUsers[index].DB.FindDashboard(days, limit)
+}
+
+func (manager *AuthenticationManager) TakeInventorySnapshot(userID uint) (model.InventorySnapshot, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return model.InventorySnapshot{}, err
+	}
+	return manager.ActiveUsers[index].DB.TakeInventorySnapshot()
+}
+
+func (manager *AuthenticationManager) ListInventorySnapshots(userID uint) ([]model.InventorySnapshot, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListInventorySnapshots()
+}
+
+func (manager *AuthenticationManager) FindInventoryAsOf(userID uint, at time.Time, warehouseID uint) ([]model.InventoryLine, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.FindInventoryAsOf(at, warehouseID)
+}
+
+func (manager *AuthenticationManager) CompareInventory(userID uint, from time.Time, to time.Time, warehouseID uint) ([]model.InventoryDifference, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.CompareInventory(from, to, warehouseID)
+}
+
+func (manager *AuthenticationManager) DispatchShipment(userID uint, itemID uint, sourceWarehouseID uint, quantity int, destinationWarehouseID uint) (model.Shipment, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return model.Shipment{}, err
+	}
+	err1 := manager.rejectAboveThreshold(index, itemID, quantity)
+	if err1 != nil {
+		return model.Shipment{}, err1
+	}
+	return manager.ActiveUsers[index].DB.DispatchShipment(itemID, sourceWarehouseID, quantity, destinationWarehouseID)
+}
+
+func (manager *AuthenticationManager) ReceiveShipment(userID uint, shipmentID uint, receivedQuantity int) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.ReceiveShipment(shipmentID, receivedQuantity)
+}
+
+func (manager *AuthenticationManager) ListShipments(userID uint, status model.ShipmentStatus) ([]model.ShipmentEntry, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListShipments(status)
+}
+
+func (manager *AuthenticationManager) FindIncomingQuantity(userID uint, warehouseID uint) (int, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return 0, err
+	}
+	return manager.ActiveUsers[index].DB.FindIncomingQuantity(warehouseID)
+}
+
+func (manager *AuthenticationManager) SetStockLevel(userID uint, itemID uint, warehouseID uint, min int, max int) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.SetStockLevel(itemID, warehouseID, min, max)
+}
+
+func (manager *AuthenticationManager) ListStockLevels(userID uint) ([]model.StockLevelEntry, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListStockLevels()
+}
+
+func (manager *AuthenticationManager) PlanRebalancing(userID uint) ([]model.RebalancingMove, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.PlanRebalancing()
+}
+
+func (manager *AuthenticationManager) UpdateWarehousePutawayPriority(userID uint, warehouseID uint, priority int) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.UpdateWarehousePutawayPriority(warehouseID, priority)
+}
+
+func (manager *AuthenticationManager) PlanPutaway(userID uint, itemID uint, quantity int, strategy model.PutawayStrategy) ([]model.PutawayAllocation, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.PlanPutaway(itemID, quantity, strategy)
+}
+
+func (manager *AuthenticationManager) ApplyPutaway(userID uint, itemID uint, quantity int, strategy model.PutawayStrategy) ([]model.PutawayAllocation, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ApplyPutaway(itemID, quantity, strategy)
+}
+
+func (manager *AuthenticationManager) SupplyItemsWithStatus(userID uint, itemID uint, warehouseID uint, quantity int, status model.StockStatus, note model.MovementNote) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.SupplyItemsWithStatus(itemID, warehouseID, quantity, status, note)
+}
+
+func (manager *AuthenticationManager) ConsumeItemsFromStatus(userID uint, itemID uint, warehouseID uint, quantity int, status model.StockStatus, note model.MovementNote) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	err1 := manager.holdForApproval(index, model.ApprovalRequest{Kind: model.ConsumeMovement, ItemID: itemID,
+		WarehouseID: warehouseID, Quantity: quantity, StockStatus: status, Reason: note.Reason, Note: note.Note,
+		ProjectID: note.ProjectID})
+	if err1 != nil {
+		return err1
+	}
+	return manager.ActiveUsers[index].DB.ConsumeItemsFromStatus(itemID, warehouseID, quantity, status, note)
+}
+
+func (manager *AuthenticationManager) ChangeStockStatus(userID uint, itemID uint, warehouseID uint, quantity int, from model.StockStatus, to model.StockStatus) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	err1 := manager.rejectAboveThreshold(index, itemID, quantity)
+	if err1 != nil {
+		return err1
+	}
+	return manager.ActiveUsers[index].DB.ChangeStockStatus(itemID, warehouseID, quantity, from, to)
+}
+
+func (manager *AuthenticationManager) FindStockStatuses(userID uint, itemID uint, warehouseID uint) ([]model.StockStatusLine, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.FindStockStatuses(itemID, warehouseID)
+}
+
+func (manager *AuthenticationManager) AuthorizeReturn(userID uint, kind model.ReturnKind, itemID uint, quantity int, reason string, orderReference string, movementID *uint) (model.ReturnAuthorization, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return model.ReturnAuthorization{}, err
+	}
+	return manager.ActiveUsers[index].DB.AuthorizeReturn(kind, itemID, quantity, reason, orderReference, movementID)
+}
+
+func (manager *AuthenticationManager) ReceiveReturn(userID uint, returnID uint, warehouseID uint, quantity int, disposition model.ReturnDisposition) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.ReceiveReturn(returnID, warehouseID, quantity, disposition)
+}
+
+func (manager *AuthenticationManager) ShipSupplierReturn(userID uint, returnID uint, warehouseID uint, status model.StockStatus) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	returns, err1 := manager.ActiveUsers[index].DB.ListReturns(model.AuthorizedReturn)
+	if err1 != nil {
+		return err1
+	}
+	for _, authorization := range returns {
+		if authorization.ID != returnID {
+			continue
+		}
+		err2 := manager.rejectAboveThreshold(index, authorization.ItemID, authorization.Quantity)
+		if err2 != nil {
+			return err2
+		}
+	}
+	return manager.ActiveUsers[index].DB.ShipSupplierReturn(returnID, warehouseID, status)
+}
+
+func (manager *AuthenticationManager) ListReturns(userID uint, status model.ReturnStatus) ([]model.ReturnEntry, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListReturns(status)
+}
+
+func (manager *AuthenticationManager) TransferItemsWithNote(userID uint, itemID uint, sourceWarehouseID uint, quantity int, destinationWarehouseID uint, note model.MovementNote) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	err1 := manager.holdForApproval(index, model.ApprovalRequest{Kind: model.TransferMovement, ItemID: itemID,
+		WarehouseID: sourceWarehouseID, DestinationWarehouseID: destinationWarehouseID, Quantity: quantity,
+		Reason: note.Reason, Note: note.Note})
+	if err1 != nil {
+		return err1
+	}
+	return manager.ActiveUsers[index].DB.TransferItemsWithNote(itemID, sourceWarehouseID, quantity, destinationWarehouseID, note)
+}
+
+func (manager *AuthenticationManager) ListReasonCodes(userID uint) ([]model.ReasonCode, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListReasonCodes()
+}
+
+func (manager *AuthenticationManager) CreateReasonCode(userID uint, code string, description string, writeOff bool) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.CreateReasonCode(code, description, writeOff)
+}
+
+func (manager *AuthenticationManager) DeleteReasonCode(userID uint, reasonID uint) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.DeleteReasonCode(reasonID)
+}
+
+func (manager *AuthenticationManager) WriteOffItems(userID uint, itemID uint, warehouseID uint, quantity int, status model.StockStatus, note model.MovementNote) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	err1 := manager.rejectAboveThreshold(index, itemID, quantity)
+	if err1 != nil {
+		return err1
+	}
+	return manager.ActiveUsers[index].DB.WriteOffItems(itemID, warehouseID, quantity, status, note)
+}
+
+func (manager *AuthenticationManager) FindShrinkage(userID uint, from time.Time, to time.Time) (model.ShrinkageReport, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return model.ShrinkageReport{}, err
+	}
+	return manager.ActiveUsers[index].DB.FindShrinkage(from, to)
+}
+
+func (manager *AuthenticationManager) SetItemArchived(userID uint, itemID uint, archived bool) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.SetItemArchived(itemID, archived)
+}
+
+func (manager *AuthenticationManager) ListArchivedItems(userID uint) ([]model.Item, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListArchivedItems()
+}
+
+func (manager *AuthenticationManager) MergeItems(userID uint, duplicateID uint, canonicalID uint) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	// the whole stock of the duplicate moves to the canonical item
+	duplicate, err1 := manager.ActiveUsers[index].DB.FindItemByID(duplicateID)
+	if err1 != nil {
+		return err1
+	}
+	for _, itemID := range []uint{duplicateID, canonicalID} {
+		err2 := manager.rejectAboveThreshold(index, itemID, duplicate.Quantity)
+		if err2 != nil {
+			return err2
+		}
+	}
+	return manager.ActiveUsers[index].DB.MergeItems(duplicateID, canonicalID)
+}
+
+func (manager *AuthenticationManager) CreateSupplier(userID uint, name string, contact string) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.CreateSupplier(name, contact)
+}
+
+func (manager *AuthenticationManager) ListSuppliers(userID uint) ([]model.Supplier, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListSuppliers()
+}
+
+func (manager *AuthenticationManager) DeleteSupplier(userID uint, supplierID uint) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.DeleteSupplier(supplierID)
+}
+
+func (manager *AuthenticationManager) SetSupplierItem(userID uint, entry model.SupplierItem) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.SetSupplierItem(entry)
+}
+
+func (manager *AuthenticationManager) RemoveSupplierItem(userID uint, supplierID uint, itemID uint) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.RemoveSupplierItem(supplierID, itemID)
+}
+
+func (manager *AuthenticationManager) ListSupplierItems(userID uint, itemID uint, supplierID uint) ([]model.SupplierItemEntry, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListSupplierItems(itemID, supplierID)
+}
+
+func (manager *AuthenticationManager) FindItemsBySupplierPartNumber(userID uint, partNumber string) ([]model.Item, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.FindItemsBySupplierPartNumber(partNumber)
+}
+
+func (manager *AuthenticationManager) CheckOutItems(userID uint, itemID uint, warehouseID uint, quantity int, borrower string, serialNumber string, dueAt time.Time) (model.Loan, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return model.Loan{}, err
+	}
+	err1 := manager.rejectAboveThreshold(index, itemID, quantity)
+	if err1 != nil {
+		return model.Loan{}, err1
+	}
+	return manager.ActiveUsers[index].DB.CheckOutItems(itemID, warehouseID, quantity, borrower, serialNumber, dueAt)
+}
+
+func (manager *AuthenticationManager) CheckInLoan(userID uint, loanID uint, warehouseID uint) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.CheckInLoan(loanID, warehouseID)
+}
+
+func (manager *AuthenticationManager) ListLoans(userID uint, open bool) ([]model.LoanEntry, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListLoans(open)
+}
+
+func (manager *AuthenticationManager) FindOverdueLoans(userID uint, now time.Time) ([]model.LoanEntry, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.FindOverdueLoans(now)
+}
+
+func (manager *AuthenticationManager) CreateProject(userID uint, code string, name string) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.CreateProject(code, name)
+}
+
+func (manager *AuthenticationManager) ListProjects(userID uint) ([]model.Project, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListProjects()
+}
+
+func (manager *AuthenticationManager) SetProjectClosed(userID uint, projectID uint, closed bool) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.SetProjectClosed(projectID, closed)
+}
+
+func (manager *AuthenticationManager) FindProjectConsumption(userID uint, from time.Time, to time.Time) (model.ProjectReport, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return model.ProjectReport{}, err
+	}
+	return manager.ActiveUsers[index].DB.FindProjectConsumption(from, to)
+}
+
+func (manager *AuthenticationManager) SetItemApprovalThreshold(userID uint, itemID uint, threshold int) error {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return err
+	}
+	return manager.ActiveUsers[index].DB.SetItemApprovalThreshold(itemID, threshold)
+}
+
+func (manager *AuthenticationManager) ListApprovalRequests(userID uint, status model.ApprovalStatus) ([]model.ApprovalEntry, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.ListApprovalRequests(status)
+}
+
+func (manager *AuthenticationManager) FindApprovalEvents(userID uint, requestID uint) ([]model.ApprovalEvent, error) {
+	index, err := manager.checkLogin(userID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveUsers[index].DB.FindApprovalEvents(requestID)
+}
@@ -3,10 +3,12 @@ package auth
 import (
 	"WarehouseManager/internal/model"
 	"encoding/json"
+	"errors"
 	"os"
 	"regexp"
 	"strconv"
 	"testing"
+	"time"
 )
 
 type testUser struct {
@@ -216,4 +218,141 @@ func TestUserSessionManager(t *testing.T) {
 				item2[0].Name, item2[0].Category, item2[0].Description)
 		}
 	})
+	t.Run("Approvals", func(t *testing.T) {
+		err1 := testManager.ChooseManager(0, "user1")
+		if err1 == nil || err1.Error() != "you can't approve your own movements" {
+			t.Errorf("unexpected error: %v", err1)
+		}
+		_, err2 := testManager.Login("user2", "<PASSWORD>")
+		if err2 != nil {
+			t.Fatalf("Failed to login user, error: %v", err2)
+		}
+		err3 := testManager.ChooseManager(0, "user2")
+		if err3 != nil {
+			t.Fatalf("Failed to choose the manager, error: %v", err3)
+		}
+		err3 = testManager.SetMemberThreshold(1, 0, 5)
+		if err3 != nil {
+			t.Fatalf("Failed to set the threshold, error: %v", err3)
+		}
+		members, _ := testManager.ListTeamMembers(1)
+		if len(members) != 1 || members[0].Username != "user1" || members[0].ApprovalThreshold != 5 {
+			t.Errorf("Wrong team: %+v", members)
+		}
+		err4 := testManager.ChooseManager(1, "user1")
+		if err4 == nil || err4.Error() != "user user1 is in your team" {
+			t.Errorf("unexpected error: %v", err4)
+		}
+		_ = testManager.CreateWarehouse(0, "North", "Milan", 1000)
+		_ = testManager.SupplyItems(0, 1, 1, 20)
+		err5 := testManager.ConsumeItems(0, 1, 1, 10)
+		if !errors.Is(err5, ErrApprovalPending) {
+			t.Fatalf("Movement above the threshold wasn't held back: %v", err5)
+		}
+		err6 := testManager.ConsumeItems(0, 1, 1, 3)
+		if err6 != nil {
+			t.Fatalf("Failed to consume items, error: %v", err6)
+		}
+		err7 := testManager.ApplyStockBatch(0, []model.StockLine{
+			{Operation: model.SupplyOperation, ItemID: 1, WarehouseID: 1, Quantity: 50},
+			{Operation: model.ConsumeOperation, ItemID: 1, WarehouseID: 1, Quantity: 10},
+		})
+		if err7 == nil || err7.Error() != "consume of item 1 in the batch: "+ErrApprovalRequired.Error() {
+			t.Errorf("unexpected error: %v", err7)
+		}
+		// splitting the consumption into lines within the threshold doesn't get past it
+		err7 = testManager.ApplyStockBatch(0, []model.StockLine{
+			{Operation: model.ConsumeOperation, ItemID: 1, WarehouseID: 1, Quantity: 4},
+			{Operation: model.ConsumeOperation, ItemID: 1, WarehouseID: 1, Quantity: 4},
+		})
+		if err7 == nil || err7.Error() != "consume of item 1 in the batch: "+ErrApprovalRequired.Error() {
+			t.Errorf("unexpected error: %v", err7)
+		}
+		item, _ := testManager.FindItemByID(0, 1)
+		if item.Quantity != 17 {
+			t.Errorf("Batch above the threshold was applied, %d items left", item.Quantity)
+		}
+		_, err7 = testManager.DispatchShipment(0, 1, 1, 10, 2)
+		if !errors.Is(err7, ErrApprovalRequired) {
+			t.Errorf("unexpected error: %v", err7)
+		}
+		inbox, err7 := testManager.ListApprovalInbox(1)
+		if err7 != nil {
+			t.Fatalf("Failed to list the inbox, error: %v", err7)
+		}
+		if len(inbox) != 1 || inbox[0].Requester != "user1" || inbox[0].Quantity != 10 {
+			t.Fatalf("Wrong inbox: %+v", inbox)
+		}
+		err8 := testManager.DecideApproval(0, 1, inbox[0].ID, true, "")
+		if err8 == nil || err8.Error() != "user user2 isn't in your team" {
+			t.Errorf("unexpected error: %v", err8)
+		}
+		_, err8 = testManager.Login("user3", "pass1hello")
+		if err8 != nil {
+			t.Fatalf("Failed to login user, error: %v", err8)
+		}
+		err8 = testManager.SetMemberThreshold(2, 0, 100)
+		if err8 == nil || err8.Error() != "user user1 isn't in your team" {
+			t.Errorf("unexpected error: %v", err8)
+		}
+		err8 = testManager.ChooseManager(0, "user3")
+		if err8 == nil || err8.Error() != "you already have a manager" {
+			t.Errorf("unexpected error: %v", err8)
+		}
+		err8 = testManager.DecideApproval(2, 0, inbox[0].ID, true, "")
+		if err8 == nil || err8.Error() != "user user1 isn't in your team" {
+			t.Errorf("unexpected error: %v", err8)
+		}
+		foreignInbox, _ := testManager.ListApprovalInbox(2)
+		if len(foreignInbox) != 0 {
+			t.Errorf("Requests of another team were listed: %+v", foreignInbox)
+		}
+		// the threshold of an item applies to the users without a manager too, who can't be approved
+		_ = testManager.CreateWarehouse(2, "South", "Naples", 1000)
+		_ = testManager.CreateItem(2, "Cement", "building", "cement bags")
+		_ = testManager.SupplyItems(2, 1, 1, 50)
+		_ = testManager.SetItemApprovalThreshold(2, 1, 20)
+		err8 = testManager.ConsumeItems(2, 1, 1, 30)
+		if !errors.Is(err8, ErrApprovalRequired) {
+			t.Errorf("Movement above the item threshold wasn't rejected: %v", err8)
+		}
+		err8 = testManager.ConsumeItems(2, 1, 1, 20)
+		if err8 != nil {
+			t.Errorf("Movement within the item threshold was rejected: %v", err8)
+		}
+		err8 = testManager.ChangeStockStatus(2, 1, 1, 25, model.AvailableStatus, model.QuarantinedStatus)
+		if !errors.Is(err8, ErrApprovalRequired) {
+			t.Errorf("Status change above the item threshold wasn't rejected: %v", err8)
+		}
+		_, err8 = testManager.CheckOutItems(2, 1, 1, 25, "site crew", "", time.Now().AddDate(0, 0, 7))
+		if !errors.Is(err8, ErrApprovalRequired) {
+			t.Errorf("Loan above the item threshold wasn't rejected: %v", err8)
+		}
+		_ = testManager.Logout("user3")
+		err9 := testManager.DecideApproval(1, 0, inbox[0].ID, true, "")
+		if err9 != nil {
+			t.Fatalf("Failed to approve the request, error: %v", err9)
+		}
+		item, _ = testManager.FindItemByID(0, 1)
+		if item.Quantity != 7 {
+			t.Errorf("Approved consumption wasn't executed, %d items left", item.Quantity)
+		}
+		events, _ := testManager.FindApprovalEvents(0, inbox[0].ID)
+		if len(events) != 2 || events[0].Actor != "user2" {
+			t.Errorf("Wrong audit trail: %+v", events)
+		}
+		err10 := testManager.ReleaseTeamMember(0, 0)
+		if err10 == nil || err10.Error() != "user user1 isn't in your team" {
+			t.Errorf("Member released themselves from the team: %v", err10)
+		}
+		err10 = testManager.ReleaseTeamMember(1, 0)
+		if err10 != nil {
+			t.Fatalf("Failed to release team member, error: %v", err10)
+		}
+		err11 := testManager.ConsumeItems(0, 1, 1, 6)
+		if err11 != nil {
+			t.Errorf("Movement of a user without manager was held back: %v", err11)
+		}
+		_ = testManager.Logout("user2")
+	})
 }
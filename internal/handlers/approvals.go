@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/auth"
+	"WarehouseManager/internal/model"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ApprovalsPage represents the page obtained by calling /approvals
+type ApprovalsPage struct {
+	Page
+	// Inbox holds the requests of the team waiting for a decision, Pending the requests of the user
+	Inbox   []auth.PendingApproval
+	Pending []model.ApprovalEntry
+	// Events is the audit trail of the requests of the user
+	Events []model.ApprovalEvent
+	Team   []auth.User
+}
+
+// ApprovalsHandler shows the approval inbox, the requests of the user and the team on GET and joins the team of the
+// chosen manager on POST
+func ApprovalsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		{
+			getApprovals(&w, r)
+			return
+		}
+	case http.MethodPost:
+		{
+			postApprovals(&w, r)
+			return
+		}
+	default:
+		{
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+}
+
+func getApprovals(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := ApprovalsPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	inbox, err1 := authManager.ListApprovalInbox(session.id)
+	if err1 != nil {
+		http.Error(*w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	pending, err2 := authManager.ListApprovalRequests(session.id, model.PendingApproval)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	events, err3 := authManager.FindApprovalEvents(session.id, 0)
+	if err3 != nil {
+		http.Error(*w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	team, err4 := authManager.ListTeamMembers(session.id)
+	if err4 != nil {
+		http.Error(*w, err4.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Inbox = inbox
+	page.Pending = pending
+	page.Events = events
+	page.Team = team
+	err5 := templates.ExecuteTemplate(*w, "approvals.html", page)
+	if err5 != nil {
+		http.Error(*w, err5.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+func postApprovals(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	err := authManager.ChooseManager(session.id, strings.TrimSpace(r.FormValue("manager")))
+	if err != nil {
+		setFlashMessage(w, "error", err.Error(), "/approvals")
+	}
+	http.Redirect(*w, r, "/approvals", http.StatusFound)
+	return
+}
+
+// ReleaseTeamMemberHandler removes a member from the team, whose movements won't need an approval anymore
+func ReleaseTeamMemberHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	memberID, err1 := strconv.Atoi(mux.Vars(r)["memberID"])
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	err2 := authManager.ReleaseTeamMember(session.id, uint(memberID))
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), "/approvals")
+	}
+	http.Redirect(w, r, "/approvals", http.StatusFound)
+	return
+}
+
+// SetMemberThresholdHandler sets the quantity above which the movements of a member of the team need an approval
+func SetMemberThresholdHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	memberID, err1 := strconv.Atoi(mux.Vars(r)["memberID"])
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	threshold, err2 := strconv.Atoi(r.FormValue("threshold"))
+	if err2 == nil {
+		err2 = authManager.SetMemberThreshold(session.id, uint(memberID), threshold)
+	}
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), "/approvals")
+	}
+	http.Redirect(w, r, "/approvals", http.StatusFound)
+	return
+}
+
+// DecideApprovalHandler approves and executes a request of a member of the team, or rejects it when approved is false
+func DecideApprovalHandler(approved bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := getSession(&w, r)
+		if !ok {
+			http.Error(w, "no session found", http.StatusInternalServerError)
+			return
+		}
+		memberID, err1 := strconv.Atoi(mux.Vars(r)["memberID"])
+		requestID, err2 := strconv.Atoi(mux.Vars(r)["requestID"])
+		if err1 != nil || err2 != nil {
+			http.Error(w, "invalid approval request", http.StatusInternalServerError)
+			return
+		}
+		err3 := authManager.DecideApproval(session.id, uint(memberID), uint(requestID), approved,
+			strings.TrimSpace(r.FormValue("comment")))
+		if err3 != nil {
+			setFlashMessage(&w, "error", err3.Error(), "/approvals")
+		}
+		http.Redirect(w, r, "/approvals", http.StatusFound)
+		return
+	}
+}
+
+// SetItemApprovalThresholdHandler sets the quantity above which moving the item of the page needs an approval
+func SetItemApprovalThresholdHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	itemIDStr := mux.Vars(r)["itemID"]
+	itemID, err1 := strconv.Atoi(itemIDStr)
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	threshold, err2 := strconv.Atoi(r.FormValue("threshold"))
+	if err2 == nil {
+		err2 = authManager.SetItemApprovalThreshold(session.id, uint(itemID), threshold)
+	}
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), "/item/"+itemIDStr)
+	}
+	http.Redirect(w, r, "/item/"+itemIDStr, http.StatusFound)
+	return
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// ArchiveItemHandler archives an item, or restores it when archived is false
+func ArchiveItemHandler(archived bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := getSession(&w, r)
+		if !ok {
+			http.Error(w, "no session found", http.StatusInternalServerError)
+			return
+		}
+		itemIDStr := mux.Vars(r)["itemID"]
+		itemID, err1 := strconv.Atoi(itemIDStr)
+		if err1 != nil {
+			http.Error(w, err1.Error(), http.StatusInternalServerError)
+			return
+		}
+		err2 := authManager.SetItemArchived(session.id, uint(itemID), archived)
+		if err2 != nil {
+			setFlashMessage(&w, "error", err2.Error(), "/item/"+itemIDStr)
+		}
+		http.Redirect(w, r, "/item/"+itemIDStr, http.StatusFound)
+		return
+	}
+}
+
+// MergeItemsHandler folds the item of the page into the canonical item chosen in the form
+func MergeItemsHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	itemIDStr := mux.Vars(r)["itemID"]
+	itemID, err1 := strconv.Atoi(itemIDStr)
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	canonicalID, err2 := strconv.Atoi(r.FormValue("canonicalID"))
+	if err2 != nil {
+		setFlashMessage(&w, "error", "choose the item to merge into", "/item/"+itemIDStr)
+		http.Redirect(w, r, "/item/"+itemIDStr, http.StatusFound)
+		return
+	}
+	err3 := authManager.MergeItems(session.id, uint(itemID), uint(canonicalID))
+	if err3 != nil {
+		setFlashMessage(&w, "error", err3.Error(), "/item/"+itemIDStr)
+		http.Redirect(w, r, "/item/"+itemIDStr, http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/item/"+strconv.Itoa(canonicalID), http.StatusFound)
+	return
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"errors"
+	"github.com/gorilla/mux"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// AttachmentsPage represents the page obtained by calling /item/{id}/attachments or /warehouse/{id}/attachments
+type AttachmentsPage struct {
+	Page
+	OwnerType   model.AttachmentOwner
+	OwnerID     uint
+	OwnerName   string
+	Attachments []model.Attachment
+	MaxSizeMB   int
+}
+
+// AttachmentsHandler lists the attachments of an item or a warehouse on GET and uploads a new one on POST
+func AttachmentsHandler(owner model.AttachmentOwner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := getSession(&w, r)
+		if !ok {
+			http.Error(w, "no session found", http.StatusInternalServerError)
+			return
+		}
+		ownerIDStr := mux.Vars(r)[string(owner)+"ID"]
+		ownerID, err1 := strconv.Atoi(ownerIDStr)
+		if err1 != nil {
+			http.Error(w, err1.Error(), http.StatusInternalServerError)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			{
+				getAttachments(&w, r, session, owner, uint(ownerID))
+				return
+			}
+		case http.MethodPost:
+			{
+				uploadAttachment(&w, r, session, owner, uint(ownerID))
+				return
+			}
+		default:
+			{
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+		}
+	}
+}
+
+// shows the attachment page of a record
+func getAttachments(w *http.ResponseWriter, r *http.Request, session userSession, owner model.AttachmentOwner, ownerID uint) {
+	page := AttachmentsPage{OwnerType: owner, OwnerID: ownerID, MaxSizeMB: model.MaxAttachmentSize >> 20}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	var err1 error
+	if owner == model.ItemAttachment {
+		var item model.Item
+		item, err1 = authManager.FindItemByID(session.id, ownerID)
+		page.OwnerName = item.Name
+	} else {
+		var warehouse model.Warehouse
+		warehouse, err1 = authManager.FindWarehouseByID(session.id, ownerID)
+		page.OwnerName = warehouse.Name
+	}
+	if err1 != nil {
+		NotFoundHandler(*w, r)
+		return
+	}
+	attachments, err2 := authManager.ListAttachments(session.id, owner, ownerID)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Attachments = attachments
+	err3 := templates.ExecuteTemplate(*w, "attachments.html", page)
+	if err3 != nil {
+		http.Error(*w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+// stores the file sent through the multipart form of the attachment page
+func uploadAttachment(w *http.ResponseWriter, r *http.Request, session userSession, owner model.AttachmentOwner, ownerID uint) {
+	path := attachmentsPath(owner, ownerID)
+	// the limit leaves room for the other parts of the form
+	r.Body = http.MaxBytesReader(*w, r.Body, model.MaxAttachmentSize+1<<20)
+	content, fileName, err1 := readUploadedFile(r)
+	if err1 == nil {
+		_, err1 = authManager.AddAttachment(session.id, owner, ownerID, fileName, content)
+	}
+	if err1 != nil {
+		setFlashMessage(w, "error", err1.Error(), path)
+	}
+	http.Redirect(*w, r, path, http.StatusFound)
+	return
+}
+
+// readUploadedFile returns the content and the name of the file sent in the "file" field
+func readUploadedFile(r *http.Request) ([]byte, string, error) {
+	err1 := r.ParseMultipartForm(model.MaxAttachmentSize)
+	if err1 != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err1, &tooBig) {
+			return nil, "", errors.New("attachment is too big, the limit is " + strconv.Itoa(model.MaxAttachmentSize>>20) + " MB")
+		}
+		return nil, "", err1
+	}
+	file, header, err2 := r.FormFile("file")
+	if err2 != nil {
+		return nil, "", errors.New("no file was uploaded")
+	}
+	defer file.Close()
+	content, err3 := io.ReadAll(file)
+	if err3 != nil {
+		return nil, "", err3
+	}
+	return content, header.Filename, nil
+}
+
+// AttachmentFileHandler sends the content, or the thumbnail, of an attachment of the record in the URL
+func AttachmentFileHandler(owner model.AttachmentOwner, thumbnail bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := getSession(&w, r)
+		if !ok {
+			http.Error(w, "no session found", http.StatusInternalServerError)
+			return
+		}
+		ownerID, attachmentID, err1 := attachmentVars(r, owner)
+		if err1 != nil {
+			http.Error(w, err1.Error(), http.StatusInternalServerError)
+			return
+		}
+		attachment, content, err2 := authManager.OpenAttachment(session.id, attachmentID, thumbnail)
+		if err2 != nil || attachment.OwnerType != owner || attachment.OwnerID != ownerID {
+			NotFoundHandler(w, r)
+			return
+		}
+		contentType := attachment.MIMEType
+		disposition := "attachment"
+		if thumbnail {
+			contentType = "image/png"
+		}
+		if attachment.IsImage() {
+			disposition = "inline"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		_, _ = w.Write(content)
+		return
+	}
+}
+
+// DeleteAttachmentHandler removes an attachment of the record in the URL
+func DeleteAttachmentHandler(owner model.AttachmentOwner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := getSession(&w, r)
+		if !ok {
+			http.Error(w, "no session found", http.StatusInternalServerError)
+			return
+		}
+		ownerID, attachmentID, err1 := attachmentVars(r, owner)
+		if err1 != nil {
+			http.Error(w, err1.Error(), http.StatusInternalServerError)
+			return
+		}
+		path := attachmentsPath(owner, ownerID)
+		attachment, _, err2 := authManager.OpenAttachment(session.id, attachmentID, false)
+		if err2 == nil && (attachment.OwnerType != owner || attachment.OwnerID != ownerID) {
+			err2 = errors.New("attachment not found")
+		}
+		if err2 == nil {
+			err2 = authManager.DeleteAttachment(session.id, attachmentID)
+		}
+		if err2 != nil {
+			setFlashMessage(&w, "error", err2.Error(), path)
+		}
+		http.Redirect(w, r, path, http.StatusFound)
+		return
+	}
+}
+
+// attachmentVars extracts the IDs of the owner and of the attachment from the URL
+func attachmentVars(r *http.Request, owner model.AttachmentOwner) (uint, uint, error) {
+	vars := mux.Vars(r)
+	ownerID, err1 := strconv.Atoi(vars[string(owner)+"ID"])
+	if err1 != nil {
+		return 0, 0, err1
+	}
+	attachmentID, err2 := strconv.Atoi(vars["attachmentID"])
+	if err2 != nil {
+		return 0, 0, err2
+	}
+	return uint(ownerID), uint(attachmentID), nil
+}
+
+// attachmentsPath returns the path of the attachment page of a record
+func attachmentsPath(owner model.AttachmentOwner, ownerID uint) string {
+	return "/" + string(owner) + "/" + strconv.Itoa(int(ownerID)) + "/attachments"
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CategoryAttributesPage represents the page obtained by calling /category/{id}/attributes
+type CategoryAttributesPage struct {
+	Page
+	Category    model.CategoryNode
+	Definitions []model.AttributeDefinition
+	Types       []model.AttributeType
+}
+
+// CategoryAttributesHandler shows the attributes of a category on GET and defines a new one on POST
+func CategoryAttributesHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	categoryIDStr := mux.Vars(r)["categoryID"]
+	categoryID, err1 := strconv.Atoi(categoryIDStr)
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		{
+			getCategoryAttributes(&w, r, session, uint(categoryID))
+			return
+		}
+	case http.MethodPost:
+		{
+			path := "/category/" + categoryIDStr + "/attributes"
+			options := strings.Split(r.FormValue("attributeOptions"), ",")
+			for i := range options {
+				options[i] = strings.TrimSpace(options[i])
+			}
+			if r.FormValue("attributeOptions") == "" {
+				options = nil
+			}
+			err2 := authManager.CreateAttributeDefinition(session.id, uint(categoryID), r.FormValue("attributeName"),
+				model.AttributeType(r.FormValue("attributeType")), options)
+			if err2 != nil {
+				setFlashMessage(&w, "error", err2.Error(), path)
+			}
+			http.Redirect(w, r, path, http.StatusFound)
+			return
+		}
+	default:
+		{
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+}
+
+// shows the /category/{id}/attributes page
+func getCategoryAttributes(w *http.ResponseWriter, r *http.Request, session userSession, categoryID uint) {
+	page := CategoryAttributesPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	page.Types = []model.AttributeType{model.TextAttribute, model.NumberAttribute, model.DateAttribute, model.EnumAttribute}
+	categories, err1 := authManager.ListCategories(session.id)
+	if err1 != nil {
+		http.Error(*w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	found := false
+	for _, category := range categories {
+		if category.ID == categoryID {
+			page.Category = category
+			found = true
+		}
+	}
+	if !found {
+		NotFoundHandler(*w, r)
+		return
+	}
+	definitions, err2 := authManager.ListAttributeDefinitions(session.id, categoryID)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Definitions = definitions
+	err3 := templates.ExecuteTemplate(*w, "category_attributes.html", page)
+	if err3 != nil {
+		http.Error(*w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+// DeleteAttributeHandler removes an attribute definition and goes back to the page of the category it was shown in
+func DeleteAttributeHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	definitionID, err1 := strconv.Atoi(mux.Vars(r)["definitionID"])
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	path := "/categories"
+	if _, err2 := strconv.Atoi(r.FormValue("categoryID")); err2 == nil {
+		path = "/category/" + r.FormValue("categoryID") + "/attributes"
+	}
+	err3 := authManager.DeleteAttributeDefinition(session.id, uint(definitionID))
+	if err3 != nil {
+		setFlashMessage(&w, "error", err3.Error(), path)
+	}
+	http.Redirect(w, r, path, http.StatusFound)
+	return
+}
+
+// EditItemAttributesHandler stores the attribute values submitted from the item page
+func EditItemAttributesHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	itemIDStr := mux.Vars(r)["itemID"]
+	itemID, err1 := strconv.Atoi(itemIDStr)
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	// FormValue parses the form, filling r.Form with the repeated fields
+	_ = r.FormValue("attributeID")
+	values := make(map[uint]string)
+	for i, definitionIDStr := range r.Form["attributeID"] {
+		definitionID, err2 := strconv.Atoi(definitionIDStr)
+		if err2 != nil {
+			http.Error(w, err2.Error(), http.StatusBadRequest)
+			return
+		}
+		values[uint(definitionID)] = formValueAt(r, "attributeValue", i)
+	}
+	err3 := authManager.SetItemAttributes(session.id, uint(itemID), values)
+	if err3 != nil {
+		setFlashMessage(&w, "error", err3.Error(), "/item/"+itemIDStr)
+	}
+	http.Redirect(w, r, "/item/"+itemIDStr, http.StatusFound)
+	return
+}
+
+// parseAttributeFilters collects the attribute filters of the search form, skipping the rows left empty
+func parseAttributeFilters(r *http.Request) ([]model.AttributeFilter, error) {
+	_ = r.FormValue("attributeName")
+	filters := make([]model.AttributeFilter, 0)
+	for i := range r.Form["attributeName"] {
+		filter := model.AttributeFilter{
+			Name:     formValueAt(r, "attributeName", i),
+			Operator: formValueAt(r, "attributeOperator", i),
+			Value:    formValueAt(r, "attributeValue", i),
+		}
+		if filter.Name == "" && filter.Value == "" {
+			continue
+		}
+		if filter.Name == "" || filter.Value == "" {
+			return nil, errors.New("attribute filters need both a name and a value")
+		}
+		if filter.Operator == "" {
+			filter.Operator = "="
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// keepItems returns the items which are also present in allowed
+func keepItems(items []model.Item, allowed []model.Item) []model.Item {
+	present := make(map[uint]bool)
+	for _, item := range allowed {
+		present[item.ID] = true
+	}
+	res := make([]model.Item, 0)
+	for _, item := range items {
+		if present[item.ID] {
+			res = append(res, item)
+		}
+	}
+	return res
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// CategoriesPage represents the page obtained by calling /categories
+type CategoriesPage struct {
+	Page
+	Categories []model.CategoryNode
+}
+
+// CategoriesHandler shows the category tree on GET and creates a new category on POST
+func CategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		{
+			getCategories(&w, r)
+			return
+		}
+	case http.MethodPost:
+		{
+			createCategory(&w, r)
+			return
+		}
+	default:
+		{
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+}
+
+// shows the /categories page
+func getCategories(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := CategoriesPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	categories, err1 := authManager.ListCategories(session.id)
+	if err1 != nil {
+		http.Error(*w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Categories = categories
+	err2 := templates.ExecuteTemplate(*w, "categories.html", page)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+// creates a category under the chosen parent
+func createCategory(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	parentID, err1 := parseOptionalID(r.FormValue("parentID"))
+	if err1 == nil {
+		err1 = authManager.CreateCategory(session.id, r.FormValue("categoryName"), parentID)
+	}
+	if err1 != nil {
+		setFlashMessage(w, "error", err1.Error(), "/categories")
+	}
+	http.Redirect(*w, r, "/categories", http.StatusFound)
+	return
+}
+
+// RenameCategoryHandler renames a category and the items belonging to it
+func RenameCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	categoryID, err1 := strconv.Atoi(mux.Vars(r)["categoryID"])
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	err2 := authManager.RenameCategory(session.id, uint(categoryID), r.FormValue("categoryName"))
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), "/categories")
+	}
+	http.Redirect(w, r, "/categories", http.StatusFound)
+	return
+}
+
+// MoveCategoryHandler moves a category under another one or to the top level
+func MoveCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	categoryID, err1 := strconv.Atoi(mux.Vars(r)["categoryID"])
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	parentID, err2 := parseOptionalID(r.FormValue("parentID"))
+	if err2 == nil {
+		err2 = authManager.MoveCategory(session.id, uint(categoryID), parentID)
+	}
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), "/categories")
+	}
+	http.Redirect(w, r, "/categories", http.StatusFound)
+	return
+}
+
+// MergeCategoryHandler merges a category into the chosen target
+func MergeCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	categoryID, err1 := strconv.Atoi(mux.Vars(r)["categoryID"])
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	targetID, err2 := strconv.Atoi(r.FormValue("targetID"))
+	if err2 != nil {
+		setFlashMessage(&w, "error", "invalid target category", "/categories")
+		http.Redirect(w, r, "/categories", http.StatusFound)
+		return
+	}
+	err3 := authManager.MergeCategories(session.id, uint(categoryID), uint(targetID))
+	if err3 != nil {
+		setFlashMessage(&w, "error", err3.Error(), "/categories")
+	}
+	http.Redirect(w, r, "/categories", http.StatusFound)
+	return
+}
+
+// parseOptionalID converts a form value to an ID, returning nil for empty values
+func parseOptionalID(value string) (*uint, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return nil, errors.New("invalid ID: " + value)
+	}
+	res := uint(id)
+	return &res, nil
+}
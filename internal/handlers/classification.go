@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// ClassificationPage represents the page obtained by calling /reports/abc
+type ClassificationPage struct {
+	Page
+	Report model.ClassificationReport
+}
+
+// ClassificationHandler shows the ABC/XYZ classification of the items and, on POST, stores the classes on the items
+func ClassificationHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	parameters, err1 := parseClassificationParameters(r)
+	if err1 != nil {
+		setFlashMessage(&w, "error", err1.Error(), "/reports/abc")
+		http.Redirect(w, r, "/reports/abc", http.StatusFound)
+		return
+	}
+	if r.Method == http.MethodPost {
+		_, err2 := authManager.ClassifyItems(session.id, parameters)
+		if err2 != nil {
+			setFlashMessage(&w, "error", err2.Error(), "/reports/abc")
+		}
+		http.Redirect(w, r, "/reports/abc?"+r.Form.Encode(), http.StatusFound)
+		return
+	}
+	page := ClassificationPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(&w, r, "error", r.URL.Path)
+	report, err3 := authManager.FindClassification(session.id, parameters)
+	if err3 != nil {
+		setFlashMessage(&w, "error", err3.Error(), "/items")
+		http.Redirect(w, r, "/items", http.StatusFound)
+		return
+	}
+	page.Report = report
+	err4 := templates.ExecuteTemplate(w, "classification.html", page)
+	if err4 != nil {
+		http.Error(w, err4.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+// parseClassificationParameters reads the parameters from the form, using the defaults for the missing ones
+func parseClassificationParameters(r *http.Request) (model.ClassificationParameters, error) {
+	parameters := model.DefaultClassificationParameters()
+	// FormValue parses the form, filling r.Form with both the query string and the body
+	_ = r.FormValue("days")
+	query := r.Form
+	if query.Get("days") != "" {
+		days, err := strconv.Atoi(query.Get("days"))
+		if err != nil {
+			return parameters, errors.New("invalid value for days: " + query.Get("days"))
+		}
+		parameters.Days = days
+	}
+	if query.Get("basis") != "" {
+		parameters.Basis = model.ClassificationBasis(query.Get("basis"))
+	}
+	floats := map[string]*float64{
+		"aShare": &parameters.AShare,
+		"bShare": &parameters.BShare,
+		"xLimit": &parameters.XLimit,
+		"yLimit": &parameters.YLimit,
+	}
+	for name, field := range floats {
+		if query.Get(name) == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(query.Get(name), 64)
+		if err != nil {
+			return parameters, errors.New("invalid value for " + name + ": " + query.Get(name))
+		}
+		*field = value
+	}
+	return parameters, nil
+}
+
+// EditItemUnitCostHandler changes the unit cost of an item
+func EditItemUnitCostHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	itemIDStr := mux.Vars(r)["itemID"]
+	itemID, err1 := strconv.Atoi(itemIDStr)
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	unitCost, err2 := strconv.ParseFloat(r.FormValue("unitCost"), 64)
+	if err2 != nil {
+		err2 = errors.New("invalid unit cost: " + r.FormValue("unitCost"))
+	} else {
+		err2 = authManager.UpdateItemUnitCost(session.id, uint(itemID), unitCost)
+	}
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), "/item/"+itemIDStr)
+	}
+	http.Redirect(w, r, "/item/"+itemIDStr, http.StatusFound)
+	return
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"net/http"
+)
+
+// ConsistencyPage represents the page obtained by calling /admin/consistency
+type ConsistencyPage struct {
+	Page
+	Inconsistencies []model.Inconsistency
+	// true when the listed problems were found right before being repaired
+	Repaired bool
+}
+
+// ConsistencyHandler shows the problems found in the user's database on GET and repairs them on POST
+func ConsistencyHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := ConsistencyPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(&w, r, "error", r.URL.Path)
+	var inconsistencies []model.Inconsistency
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		{
+			inconsistencies, err = authManager.CheckConsistency(session.id)
+		}
+	case http.MethodPost:
+		{
+			inconsistencies, err = authManager.RepairConsistency(session.id)
+			page.Repaired = true
+		}
+	default:
+		{
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Inconsistencies = inconsistencies
+	err2 := templates.ExecuteTemplate(w, "consistency.html", page)
+	if err2 != nil {
+		http.Error(w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
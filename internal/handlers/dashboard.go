@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// quantity below which an item is reported as low on supply
+const lowSupplyThreshold = 100
+
+// utilization above which a warehouse is reported as almost full
+const fullWarehouseRatio = 0.9
+
+// days of consumption and number of rows shown by the dashboard
+const (
+	dashboardDays  = 30
+	dashboardLimit = 10
+)
+
+// dimensions of the bar charts, in pixels
+const (
+	chartLabelWidth = 180
+	chartBarWidth   = 300
+	chartTextWidth  = 140
+	chartRowHeight  = 28
+)
+
+// DashboardPage represents the home page of a logged-in user
+type DashboardPage struct {
+	Page
+	Utilization     template.HTML
+	TopConsumed     template.HTML
+	CategoryValues  template.HTML
+	RecentMovements []model.MovementEntry
+	Alerts          []string
+	OverdueLoans    []model.LoanEntry
+}
+
+// chartBar is a bar of a horizontal bar chart, filled in proportion of Value to Max
+type chartBar struct {
+	Label   string
+	Value   float64
+	Max     float64
+	Text    string
+	Warning bool
+}
+
+// fillDashboard collects the figures of the dashboard and renders its charts
+func fillDashboard(page *DashboardPage, session userSession) error {
+	dashboard, err1 := authManager.FindDashboard(session.id, dashboardDays, dashboardLimit)
+	if err1 != nil {
+		return err1
+	}
+	items, err2 := authManager.ListAllItems(session.id)
+	if err2 != nil {
+		return err2
+	}
+	page.Alerts = make([]string, 0)
+	utilization := make([]chartBar, 0, len(dashboard.Utilization))
+	for _, v := range dashboard.Utilization {
+		ratio := 0.0
+		if v.Capacity > 0 {
+			ratio = float64(v.Stored) / float64(v.Capacity)
+		}
+		utilization = append(utilization, chartBar{
+			Label:   v.Name,
+			Value:   float64(v.Stored),
+			Max:     float64(v.Capacity),
+			Text:    strconv.Itoa(v.Stored) + " / " + strconv.Itoa(v.Capacity) + " (" + strconv.Itoa(int(ratio*100)) + "%)",
+			Warning: ratio >= fullWarehouseRatio,
+		})
+		if v.Stored > v.Capacity {
+			page.Alerts = append(page.Alerts, "warehouse \""+v.Name+"\" is over capacity")
+		} else if ratio >= fullWarehouseRatio {
+			page.Alerts = append(page.Alerts, "warehouse \""+v.Name+"\" is almost full")
+		}
+	}
+	for _, v := range items {
+		if v.Quantity < lowSupplyThreshold {
+			page.Alerts = append(page.Alerts, "item \""+v.Name+"\" is low on supply: "+strconv.Itoa(v.Quantity)+" left")
+		}
+	}
+	loans, err3 := authManager.FindOverdueLoans(session.id, time.Now())
+	if err3 != nil {
+		return err3
+	}
+	for _, v := range loans {
+		page.Alerts = append(page.Alerts, "loan "+strconv.Itoa(int(v.ID))+" of \""+v.ItemName+"\" to "+v.Borrower+
+			" was due on "+v.DueAt.Format(time.DateOnly))
+	}
+	page.OverdueLoans = loans
+	consumed := make([]chartBar, 0, len(dashboard.TopConsumed))
+	for _, v := range dashboard.TopConsumed {
+		consumed = append(consumed, chartBar{Label: v.Name, Value: float64(v.Consumed), Text: strconv.Itoa(v.Consumed)})
+	}
+	values := make([]chartBar, 0, len(dashboard.CategoryValues))
+	for _, v := range dashboard.CategoryValues {
+		values = append(values, chartBar{Label: v.Category, Value: v.Value,
+			Text: strconv.FormatFloat(v.Value, 'f', 2, 64) + " (" + strconv.Itoa(v.Quantity) + " units)"})
+	}
+	page.Utilization = horizontalBarChart(utilization)
+	page.TopConsumed = horizontalBarChart(scaleToLargest(consumed))
+	page.CategoryValues = horizontalBarChart(scaleToLargest(values))
+	page.RecentMovements = dashboard.RecentMovements
+	return nil
+}
+
+// scaleToLargest sets the Max of every bar to the largest value so that the longest bar fills the chart
+func scaleToLargest(bars []chartBar) []chartBar {
+	largest := 0.0
+	for _, bar := range bars {
+		largest = max(largest, bar.Value)
+	}
+	for i := range bars {
+		bars[i].Max = largest
+	}
+	return bars
+}
+
+// horizontalBarChart renders the bars as an inline SVG image, one row per bar with its label on the left
+// and its text on the right
+func horizontalBarChart(bars []chartBar) template.HTML {
+	if len(bars) == 0 {
+		return template.HTML("<p>No data to show yet</p>")
+	}
+	width := chartLabelWidth + chartBarWidth + chartTextWidth
+	height := len(bars) * chartRowHeight
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img">`,
+		width, height, width, height)
+	for i, bar := range bars {
+		y := i * chartRowHeight
+		filled := 0.0
+		if bar.Max > 0 {
+			filled = min(max(bar.Value/bar.Max, 0), 1) * chartBarWidth
+		}
+		colour := "cornflowerblue"
+		if bar.Warning {
+			colour = "crimson"
+		}
+		label := []rune(bar.Label)
+		if len(label) > 24 {
+			label = append(label[:23], '…')
+		}
+		fmt.Fprintf(&builder, `<g><title>%s: %s</title>`, template.HTMLEscapeString(bar.Label), template.HTMLEscapeString(bar.Text))
+		fmt.Fprintf(&builder, `<text x="0" y="%d" font-size="14" fill="currentColor">%s</text>`,
+			y+chartRowHeight/2+5, template.HTMLEscapeString(string(label)))
+		fmt.Fprintf(&builder, `<rect x="%d" y="%d" width="%d" height="%d" fill="#8884"/>`,
+			chartLabelWidth, y+4, chartBarWidth, chartRowHeight-8)
+		fmt.Fprintf(&builder, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s"/>`,
+			chartLabelWidth, y+4, filled, chartRowHeight-8, colour)
+		fmt.Fprintf(&builder, `<text x="%d" y="%d" font-size="13" fill="currentColor">%s</text></g>`,
+			chartLabelWidth+chartBarWidth+8, y+chartRowHeight/2+5, template.HTMLEscapeString(bar.Text))
+	}
+	builder.WriteString("</svg>")
+	return template.HTML(builder.String())
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// NearestStockSearch holds the parameters and the results of a "find nearest stock" search on the item page
+type NearestStockSearch struct {
+	// destination warehouse of the transfer, nil when the search started from coordinates
+	Destination *model.Warehouse
+	MinQuantity int
+	Results     []model.NearbyStock
+}
+
+// EditWarehouseLocationHandler updates the coordinates and the address of a warehouse
+func EditWarehouseLocationHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	warehouseIDStr := mux.Vars(r)["warehouseID"]
+	warehouseID, err1 := strconv.Atoi(warehouseIDStr)
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	location := model.WarehouseLocation{
+		Street:     r.FormValue("street"),
+		City:       r.FormValue("city"),
+		PostalCode: r.FormValue("postalCode"),
+		Country:    r.FormValue("country"),
+	}
+	var err2, err3 error
+	location.Latitude, err2 = parseOptionalFloat(r.FormValue("latitude"))
+	location.Longitude, err3 = parseOptionalFloat(r.FormValue("longitude"))
+	err4 := errors.Join(err2, err3)
+	if err4 == nil {
+		err4 = authManager.UpdateWarehouseLocation(session.id, uint(warehouseID), location)
+	}
+	if err4 != nil {
+		setFlashMessage(&w, "error", err4.Error(), "/warehouse/"+warehouseIDStr)
+		http.Redirect(w, r, "/warehouse/"+warehouseIDStr, http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/warehouse/"+warehouseIDStr, http.StatusFound)
+	return
+}
+
+// searchNearestStock runs the search requested through the query string of the item page, if any.
+// The point is either the one of the destination warehouse "nearTo" or the given latitude and longitude.
+func searchNearestStock(r *http.Request, session userSession, itemID uint) (*NearestStockSearch, error) {
+	query := r.URL.Query()
+	if query.Get("nearTo") == "" && query.Get("latitude") == "" {
+		return nil, nil
+	}
+	search := &NearestStockSearch{MinQuantity: 1}
+	if query.Get("minQuantity") != "" {
+		minQuantity, err1 := strconv.Atoi(query.Get("minQuantity"))
+		if err1 != nil {
+			return nil, errors.New("invalid minimum quantity: " + query.Get("minQuantity"))
+		}
+		search.MinQuantity = minQuantity
+	}
+	var latitude, longitude float64
+	if query.Get("nearTo") != "" {
+		destinationID, err2 := strconv.Atoi(query.Get("nearTo"))
+		if err2 != nil {
+			return nil, err2
+		}
+		destination, err3 := authManager.FindWarehouseByID(session.id, uint(destinationID))
+		if err3 != nil {
+			return nil, err3
+		}
+		if !destination.Location.HasCoordinates() {
+			return nil, errors.New("warehouse \"" + destination.Name + "\" has no coordinates")
+		}
+		search.Destination = &destination
+		latitude, longitude = *destination.Location.Latitude, *destination.Location.Longitude
+	} else {
+		givenLatitude, err4 := parseOptionalFloat(query.Get("latitude"))
+		givenLongitude, err5 := parseOptionalFloat(query.Get("longitude"))
+		if err4 != nil || err5 != nil || givenLatitude == nil || givenLongitude == nil {
+			return nil, errors.New("latitude and longitude are both required")
+		}
+		latitude, longitude = *givenLatitude, *givenLongitude
+	}
+	results, err6 := authManager.FindNearestStock(session.id, itemID, search.MinQuantity, latitude, longitude)
+	if err6 != nil {
+		return nil, err6
+	}
+	for _, result := range results {
+		// the destination itself isn't a source for the transfer
+		if search.Destination == nil || result.WarehouseID != search.Destination.ID {
+			search.Results = append(search.Results, result)
+		}
+	}
+	return search, nil
+}
+
+// parseOptionalFloat converts a form value to a float, returning nil for empty values
+func parseOptionalFloat(value string) (*float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	res, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, errors.New("invalid number: " + value)
+	}
+	return &res, nil
+}
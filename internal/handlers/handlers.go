@@ -20,7 +20,11 @@ import (
 // The various html files used in this project
 var htmlFiles = []string{
 	"account.html", "home.html", "login.html", "register.html", "warehouse.html", "warehouses.html", "items.html", "item.html",
-	"items_search.html", "warehouses_search.html", "not_found.html", "navbar.html", "notifications.html", "head.html"}
+	"items_search.html", "warehouses_search.html", "not_found.html", "navbar.html", "notifications.html", "head.html",
+	"stock_batch.html", "consistency.html", "categories.html", "category_attributes.html", "attachments.html",
+	"product.html", "replenishment.html", "classification.html", "inventory.html", "transfers.html", "rebalancing.html",
+	"putaway.html", "returns.html", "reasons.html", "shrinkage.html", "suppliers.html", "loans.html", "projects.html",
+	"project_report.html", "approvals.html"}
 
 // We complete the file path by appending the "templates/" prefix and parse them to generate a template file
 var templates *template.Template
@@ -95,6 +99,10 @@ type WarehousesPage struct {
 type ItemsPage struct {
 	Page
 	Content []model.Item
+	// Products groups the items which are variants, with their aggregated stock
+	Products []model.ProductSummary
+	// Archived items are listed apart so that they can be restored
+	Archived []model.Item
 }
 
 // ItemPage represents the particular page obtained by calling GET /item/{id:[0-9]+}
@@ -103,6 +111,28 @@ type ItemPage struct {
 	Item                 model.Item
 	ItemPacks            []model.LoadedItemPack
 	WarehousesWithAmount []AugmentedWarehouse
+	// current values of the item when an edit was rejected because of a concurrent change
+	Conflict *model.Item
+	// WarehousesWithCoordinates are the possible destinations of a nearest stock search
+	WarehousesWithCoordinates []model.Warehouse
+	NearestStock              *NearestStockSearch
+	// Attributes applying to the category of the item, with their current values
+	Attributes []model.ItemAttribute
+	// BOM lists the components of the item when it is a kit, OtherItems the items which can become components
+	BOM        []model.BOMComponent
+	Buildable  model.BuildableStock
+	OtherItems []model.Item
+	// Statuses is the breakdown of the stock by status in each warehouse
+	Statuses      []model.StockStatusLine
+	StockStatuses []model.StockStatus
+	// Reasons are the reason codes of the ordinary operations, WriteOffReasons those of the write-offs
+	Reasons         []model.ReasonCode
+	WriteOffReasons []model.ReasonCode
+	// Catalog lists the suppliers selling the item, Suppliers every supplier
+	Catalog   []model.SupplierItemEntry
+	Suppliers []model.Supplier
+	// Projects which consumptions can be charged to
+	Projects []model.Project
 }
 
 type AugmentedWarehouse struct {
@@ -125,7 +155,13 @@ type WarehouseInfoForItemPage struct {
 type WarehousePage struct {
 	Page
 	Warehouse model.Warehouse
-	Items     []model.Item
+	ItemPacks []model.LoadedItemPack
+	// current values of the warehouse when an edit was rejected because of a concurrent change
+	Conflict *model.Warehouse
+	// Incoming is the number of items in transit to the warehouse
+	Incoming int
+	// Statuses is the breakdown of the stock of each item by status
+	Statuses []model.StockStatusLine
 }
 
 // SearchPage display the result of a searching operation
@@ -133,6 +169,8 @@ type SearchPage struct {
 	Page
 	Warehouses []model.Warehouse
 	Items      []model.Item
+	// numbers of the attribute filter rows offered by the item search form
+	AttributeFilterRows []int
 }
 
 type AccountPage struct {
@@ -170,14 +208,18 @@ func SessionIsAbsentHomeHandler(nextHandler http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// HomeHandler displays the home page
+// HomeHandler displays the home page with the dashboard of the user
 func HomeHandler(w http.ResponseWriter, r *http.Request) {
-	var page Page
+	var page DashboardPage
 	session, _ := getSession(&w, r)
 	page.LoggedIn = true
-	page.APPNtf = evaluateItems(session)
+	// the low supply notification is part of the alerts of the dashboard
 	page.AuthMsg = processFlashMessage(&w, r, "authMsg", r.URL.Path)
 	page.APPError = processFlashMessage(&w, r, "error", r.URL.Path)
+	err1 := fillDashboard(&page, session)
+	if err1 != nil {
+		page.APPError += err1.Error()
+	}
 	err2 := templates.ExecuteTemplate(w, "home.html", page)
 	if err2 != nil {
 		http.Error(w, err2.Error(), http.StatusInternalServerError)
@@ -193,7 +235,7 @@ func evaluateItems(session userSession) string {
 		return err1.Error()
 	}
 	for _, v := range items {
-		if v.Quantity < 100 {
+		if v.Quantity < lowSupplyThreshold {
 			notification += v.Name + " "
 			resupply = true
 		}
@@ -266,6 +308,18 @@ func ItemsHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		page.Content = items
+		products, err3 := authManager.ListProducts(session.id)
+		if err3 != nil {
+			http.Error(w, err3.Error(), http.StatusInternalServerError)
+			return
+		}
+		page.Products = products
+		archived, err5 := authManager.ListArchivedItems(session.id)
+		if err5 != nil {
+			http.Error(w, err5.Error(), http.StatusInternalServerError)
+			return
+		}
+		page.Archived = archived
 		err4 := templates.ExecuteTemplate(w, "items.html", page)
 		if err4 != nil {
 			http.Error(w, err4.Error(), http.StatusInternalServerError)
@@ -383,7 +437,16 @@ func ConsumeItemHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err2.Error(), http.StatusInternalServerError)
 			return
 		}
-		err := authManager.ConsumeItems(session.id, uint(itemID), uint(warehouseID), amount)
+		note := formMovementNote(r)
+		projectID, err := parseOptionalID(r.FormValue("projectID"))
+		if err == nil {
+			note.ProjectID = projectID
+			err = authManager.ConsumeItemsFromStatus(session.id, uint(itemID), uint(warehouseID), amount, formStockStatus(r), note)
+		}
+		if errors.Is(err, auth.ErrApprovalPending) {
+			http.Redirect(w, r, "/approvals", http.StatusFound)
+			return
+		}
 		if err != nil {
 			setFlashMessage(&w, "error", err.Error(), "/item/"+mux.Vars(r)["itemID"])
 			http.Redirect(w, r, "/item/"+mux.Vars(r)["itemID"], http.StatusFound)
@@ -406,7 +469,8 @@ func SupplyItemHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err2.Error(), http.StatusInternalServerError)
 			return
 		}
-		err := authManager.SupplyItems(session.id, uint(itemID), uint(warehouseID), amount)
+		err := authManager.SupplyItemsWithStatus(session.id, uint(itemID), uint(warehouseID), amount, formStockStatus(r),
+			formMovementNote(r))
 		if err != nil {
 			setFlashMessage(&w, "error", err.Error(), "/item/"+mux.Vars(r)["itemID"])
 			http.Redirect(w, r, "/item/"+mux.Vars(r)["itemID"], http.StatusFound)
@@ -453,7 +517,11 @@ func TransferItemHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err2.Error(), http.StatusInternalServerError)
 			return
 		}
-		err3 := authManager.TransferItems(session.id, uint(itemID), uint(srcID), amount, uint(destID))
+		err3 := authManager.TransferItemsWithNote(session.id, uint(itemID), uint(srcID), amount, uint(destID), formMovementNote(r))
+		if errors.Is(err3, auth.ErrApprovalPending) {
+			http.Redirect(w, r, "/approvals", http.StatusFound)
+			return
+		}
 		if err3 != nil {
 			setFlashMessage(&w, "error", err3.Error(), "/item/"+mux.Vars(r)["itemID"])
 			http.Redirect(w, r, "/item/"+mux.Vars(r)["itemID"], http.StatusFound)
@@ -533,18 +601,28 @@ func EditItemHandler(w http.ResponseWriter, r *http.Request) {
 	itemName := r.FormValue("itemName")
 	itemCategory := r.FormValue("itemCategory")
 	itemDescription := r.FormValue("itemDescription")
-	updateItem(&w, r, session, itemID, itemName, itemCategory, itemDescription)
+	version, err2 := strconv.Atoi(r.FormValue("version"))
+	if err2 != nil {
+		http.Error(w, err2.Error(), http.StatusBadRequest)
+		return
+	}
+	updateItem(&w, r, session, itemID, itemName, itemCategory, itemDescription, uint(version))
 	return
 }
 
 // utility method extracted to increase code readability
-func updateItem(w *http.ResponseWriter, r *http.Request, session userSession, itemID int, itemName string, itemCategory string, itemDescription string) {
+func updateItem(w *http.ResponseWriter, r *http.Request, session userSession, itemID int, itemName string, itemCategory string, itemDescription string, version uint) {
 	_, err2 := authManager.FindItemByID(session.id, uint(itemID))
 	if err2 != nil {
 		http.Error(*w, err2.Error(), http.StatusInternalServerError)
 		return
 	}
-	err3 := authManager.UpdateItem(session.id, uint(itemID), itemName, itemCategory, itemDescription)
+	err3 := authManager.UpdateItem(session.id, uint(itemID), itemName, itemCategory, itemDescription, version)
+	var conflict *model.VersionConflictError
+	if errors.As(err3, &conflict) {
+		showItemConflict(w, r, session, itemID, itemName, itemCategory, itemDescription, conflict)
+		return
+	}
 	if err3 != nil {
 		setFlashMessage(w, "error", err3.Error(), "/item/")
 		http.Redirect(*w, r, "/item/"+strconv.Itoa(itemID), http.StatusFound)
@@ -554,6 +632,29 @@ func updateItem(w *http.ResponseWriter, r *http.Request, session userSession, it
 	return
 }
 
+// showItemConflict renders the item page again keeping the user's changes in the form and showing the current values.
+// The form carries the current version so that submitting it again deliberately overwrites the other changes.
+func showItemConflict(w *http.ResponseWriter, r *http.Request, session userSession, itemID int, itemName string, itemCategory string, itemDescription string, conflict *model.VersionConflictError) {
+	current, err1 := authManager.FindItemByID(session.id, uint(itemID))
+	if err1 != nil {
+		http.Error(*w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	page := buildItemPage(w, r, session, current)
+	page.Conflict = &current
+	page.Item.Name = itemName
+	page.Item.Category = itemCategory
+	page.Item.Description = itemDescription
+	page.APPError += conflict.Error()
+	(*w).WriteHeader(http.StatusConflict)
+	err2 := templates.ExecuteTemplate(*w, "item.html", page)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
 // getItem shows the corresponding /item/{id} page
 func getItem(w *http.ResponseWriter, r *http.Request, session userSession, itemID int) {
 	item, err2 := authManager.FindItemByID(session.id, uint(itemID))
@@ -561,14 +662,57 @@ func getItem(w *http.ResponseWriter, r *http.Request, session userSession, itemI
 		NotFoundHandler(*w, r)
 		return
 	}
+	page := buildItemPage(w, r, session, item)
+	nearestStock, err4 := searchNearestStock(r, session, item.ID)
+	if err4 != nil {
+		page.APPError += err4.Error()
+	}
+	page.NearestStock = nearestStock
+	err3 := templates.ExecuteTemplate(*w, "item.html", page)
+	if err3 != nil {
+		http.Error(*w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+// buildItemPage collects the data shown by the /item/{id} page, reporting lookup errors on the page itself
+func buildItemPage(w *http.ResponseWriter, r *http.Request, session userSession, item model.Item) ItemPage {
 	page2 := ItemPage{}
 	page2.LoggedIn = true
 	page2.Item = item
-	itemPacks, err1 := authManager.FindWarehousesForItem(session.id, uint(itemID))
+	itemPacks, err1 := authManager.FindWarehousesForItem(session.id, item.ID)
 	page2.ItemPacks = itemPacks
+	attributes, err5 := authManager.FindItemAttributes(session.id, item.ID)
+	page2.Attributes = attributes
+	err6 := fillKitSection(&page2, session)
+	statuses, err7 := authManager.FindStockStatuses(session.id, item.ID, 0)
+	page2.Statuses = statuses
+	page2.StockStatuses = model.StockStatuses
+	catalog, err9 := authManager.ListSupplierItems(session.id, item.ID, 0)
+	page2.Catalog = catalog
+	suppliers, err10 := authManager.ListSuppliers(session.id)
+	page2.Suppliers = suppliers
+	projects, err11 := authManager.ListProjects(session.id)
+	for _, project := range projects {
+		if !project.Closed {
+			page2.Projects = append(page2.Projects, project)
+		}
+	}
+	reasons, err8 := authManager.ListReasonCodes(session.id)
+	for _, reason := range reasons {
+		if reason.WriteOff {
+			page2.WriteOffReasons = append(page2.WriteOffReasons, reason)
+		} else {
+			page2.Reasons = append(page2.Reasons, reason)
+		}
+	}
 	warehouses, err4 := authManager.ListAllWarehouses(session.id)
 	augmentedWarehouses := make([]AugmentedWarehouse, 0)
 	for _, v1 := range warehouses {
+		if v1.Location.HasCoordinates() {
+			page2.WarehousesWithCoordinates = append(page2.WarehousesWithCoordinates, v1)
+		}
 		quantity := 0
 		for _, v2 := range itemPacks {
 			if v1.ID == v2.WarehouseID {
@@ -592,13 +736,28 @@ func getItem(w *http.ResponseWriter, r *http.Request, session userSession, itemI
 	if err4 != nil {
 		page2.APPError += err4.Error()
 	}
-	page := page2
-	err3 := templates.ExecuteTemplate(*w, "item.html", page)
-	if err3 != nil {
-		http.Error(*w, err3.Error(), http.StatusInternalServerError)
-		return
+	if err5 != nil {
+		page2.APPError += err5.Error()
 	}
-	return
+	if err6 != nil {
+		page2.APPError += err6.Error()
+	}
+	if err7 != nil {
+		page2.APPError += err7.Error()
+	}
+	if err8 != nil {
+		page2.APPError += err8.Error()
+	}
+	if err9 != nil {
+		page2.APPError += err9.Error()
+	}
+	if err10 != nil {
+		page2.APPError += err10.Error()
+	}
+	if err11 != nil {
+		page2.APPError += err11.Error()
+	}
+	return page2
 }
 
 // WarehouseHandler handlers the Warehouse page
@@ -670,18 +829,28 @@ func EditWarehouseHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err4.Error(), http.StatusInternalServerError)
 		return
 	}
-	updateWarehouse(&w, r, session, warehouseID, warehouseName, warehousePosition, warehouseCapacity)
+	version, err5 := strconv.Atoi(r.FormValue("version"))
+	if err5 != nil {
+		http.Error(w, err5.Error(), http.StatusBadRequest)
+		return
+	}
+	updateWarehouse(&w, r, session, warehouseID, warehouseName, warehousePosition, warehouseCapacity, uint(version))
 	return
 }
 
 // utility method extracted from putWarehouse
-func updateWarehouse(w *http.ResponseWriter, r *http.Request, session userSession, warehouseID int, warehouseName string, warehousePosition string, warehouseCapacity int) {
+func updateWarehouse(w *http.ResponseWriter, r *http.Request, session userSession, warehouseID int, warehouseName string, warehousePosition string, warehouseCapacity int, version uint) {
 	_, err3 := authManager.FindWarehouseByID(session.id, uint(warehouseID))
 	if err3 != nil {
 		http.Error(*w, err3.Error(), http.StatusInternalServerError)
 		return
 	}
-	err5 := authManager.UpdateWarehouse(session.id, uint(warehouseID), warehouseName, warehousePosition, warehouseCapacity)
+	err5 := authManager.UpdateWarehouse(session.id, uint(warehouseID), warehouseName, warehousePosition, warehouseCapacity, version)
+	var conflict *model.VersionConflictError
+	if errors.As(err5, &conflict) {
+		showWarehouseConflict(w, r, session, warehouseID, warehouseName, warehousePosition, warehouseCapacity, conflict)
+		return
+	}
 	if err5 != nil {
 		setFlashMessage(w, "error", err5.Error(), "/warehouse/")
 		http.Redirect(*w, r, "/warehouse/"+strconv.Itoa(warehouseID), http.StatusFound)
@@ -691,6 +860,32 @@ func updateWarehouse(w *http.ResponseWriter, r *http.Request, session userSessio
 	return
 }
 
+// showWarehouseConflict is the warehouse counterpart of showItemConflict
+func showWarehouseConflict(w *http.ResponseWriter, r *http.Request, session userSession, warehouseID int, warehouseName string, warehousePosition string, warehouseCapacity int, conflict *model.VersionConflictError) {
+	current, err1 := authManager.FindWarehouseByID(session.id, uint(warehouseID))
+	if err1 != nil {
+		http.Error(*w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	page, err2 := buildWarehousePage(w, r, session, current)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Conflict = &current
+	page.Warehouse.Name = warehouseName
+	page.Warehouse.Position = warehousePosition
+	page.Warehouse.Capacity = warehouseCapacity
+	page.APPError += conflict.Error()
+	(*w).WriteHeader(http.StatusConflict)
+	err3 := templates.ExecuteTemplate(*w, "warehouse.html", page)
+	if err3 != nil {
+		http.Error(*w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
 // getWarehouse shows the warehouse page
 func getWarehouse(w *http.ResponseWriter, r *http.Request, session userSession, warehouseID int) {
 	warehouse, err2 := authManager.FindWarehouseByID(session.id, uint(warehouseID))
@@ -698,26 +893,11 @@ func getWarehouse(w *http.ResponseWriter, r *http.Request, session userSession,
 		NotFoundHandler(*w, r)
 		return
 	}
-	page := WarehousePage{}
-	itemPacks, err4 := authManager.FindItemsInWarehouse(session.id, uint(warehouseID))
+	page, err4 := buildWarehousePage(w, r, session, warehouse)
 	if err4 != nil {
 		http.Error(*w, err4.Error(), http.StatusInternalServerError)
 		return
 	}
-	var itemList []model.Item
-	for _, item := range itemPacks {
-		retrievedItem, err := authManager.FindItemByID(session.id, item.ItemID)
-		if err != nil {
-			http.Error(*w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		itemList = append(itemList, retrievedItem)
-	}
-	page.Items = itemList
-	page.LoggedIn = true
-	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
-	page.APPNtf = evaluateItems(session)
-	page.Warehouse = warehouse
 	err3 := templates.ExecuteTemplate(*w, "warehouse.html", page)
 	if err3 != nil {
 		http.Error(*w, err3.Error(), http.StatusInternalServerError)
@@ -726,6 +906,31 @@ func getWarehouse(w *http.ResponseWriter, r *http.Request, session userSession,
 	return
 }
 
+// buildWarehousePage collects the data shown by the /warehouse/{id} page
+func buildWarehousePage(w *http.ResponseWriter, r *http.Request, session userSession, warehouse model.Warehouse) (WarehousePage, error) {
+	page := WarehousePage{}
+	itemPacks, err4 := authManager.FindItemsInWarehouse(session.id, warehouse.ID)
+	if err4 != nil {
+		return page, err4
+	}
+	incoming, err5 := authManager.FindIncomingQuantity(session.id, warehouse.ID)
+	if err5 != nil {
+		return page, err5
+	}
+	statuses, err6 := authManager.FindStockStatuses(session.id, 0, warehouse.ID)
+	if err6 != nil {
+		return page, err6
+	}
+	page.ItemPacks = itemPacks
+	page.Incoming = incoming
+	page.Statuses = statuses
+	page.LoggedIn = true
+	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	page.APPNtf = evaluateItems(session)
+	page.Warehouse = warehouse
+	return page, nil
+}
+
 func fillSearchPage(w *http.ResponseWriter, r *http.Request, session userSession, resItems []model.Item, resWarehouses []model.Warehouse) SearchPage {
 	var page SearchPage
 	if resItems != nil {
@@ -737,6 +942,7 @@ func fillSearchPage(w *http.ResponseWriter, r *http.Request, session userSession
 	page.APPNtf = evaluateItems(session)
 	page.LoggedIn = true
 	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	page.AttributeFilterRows = []int{1, 2, 3}
 	return page
 }
 
@@ -770,17 +976,30 @@ func searchItem(w *http.ResponseWriter, r *http.Request) {
 	itemName := r.FormValue("itemName")
 	itemCategory := r.FormValue("itemCategory")
 	itemDescription := r.FormValue("itemDescription")
+	partNumber := strings.TrimSpace(r.FormValue("partNumber"))
+	attributeFilters, err1 := parseAttributeFilters(r)
+	if err1 != nil {
+		setFlashMessage(w, "error", err1.Error(), "/items/search")
+		http.Redirect(*w, r, "/items/search", http.StatusFound)
+		return
+	}
 	if itemIDStr != "" {
 		searchItemID(w, r, session, itemIDStr)
 		return
 	} else if itemName != "" {
 		searchItemName(w, r, session, itemName)
 		return
+	} else if partNumber != "" {
+		searchItemPartNumber(w, r, session, partNumber)
+		return
 	} else if itemDescription != "" {
-		searchItemKeyword(w, r, session, itemDescription, itemCategory)
+		searchItemKeyword(w, r, session, itemDescription, itemCategory, attributeFilters)
 		return
 	} else if itemCategory != "" {
-		searchItemCategory(w, r, session, itemCategory)
+		searchItemCategory(w, r, session, itemCategory, attributeFilters)
+		return
+	} else if len(attributeFilters) != 0 {
+		searchItemAttributes(w, r, session, attributeFilters)
 		return
 	}
 	setFlashMessage(w, "error", "Missing search arguments", "/items/search")
@@ -789,13 +1008,22 @@ func searchItem(w *http.ResponseWriter, r *http.Request) {
 }
 
 // searches item by category
-func searchItemCategory(w *http.ResponseWriter, r *http.Request, session userSession, itemCategory string) {
+func searchItemCategory(w *http.ResponseWriter, r *http.Request, session userSession, itemCategory string, attributeFilters []model.AttributeFilter) {
 	resItems, err1 := authManager.FindItemsByCategory(session.id, itemCategory)
 	if err1 != nil {
 		setFlashMessage(w, "error", err1.Error(), "/items/search")
 		http.Redirect(*w, r, "/items/search", http.StatusFound)
 		return
 	}
+	if len(attributeFilters) != 0 {
+		attributeItems, err2 := authManager.FindItemsByAttributes(session.id, attributeFilters)
+		if err2 != nil {
+			setFlashMessage(w, "error", err2.Error(), "/items/search")
+			http.Redirect(*w, r, "/items/search", http.StatusFound)
+			return
+		}
+		resItems = keepItems(resItems, attributeItems)
+	}
 	if len(resItems) == 0 {
 		setFlashMessage(w, "error", "No record found", "/items/search")
 		http.Redirect(*w, r, "/items/search", http.StatusFound)
@@ -810,8 +1038,30 @@ func searchItemCategory(w *http.ResponseWriter, r *http.Request, session userSes
 	return
 }
 
+// searches item by the values of their attributes
+func searchItemAttributes(w *http.ResponseWriter, r *http.Request, session userSession, attributeFilters []model.AttributeFilter) {
+	resItems, err1 := authManager.FindItemsByAttributes(session.id, attributeFilters)
+	if err1 != nil {
+		setFlashMessage(w, "error", err1.Error(), "/items/search")
+		http.Redirect(*w, r, "/items/search", http.StatusFound)
+		return
+	}
+	if len(resItems) == 0 {
+		setFlashMessage(w, "error", "No record found", "/items/search")
+		http.Redirect(*w, r, "/items/search", http.StatusFound)
+		return
+	}
+	page := fillSearchPage(w, r, session, resItems, nil)
+	err2 := templates.ExecuteTemplate(*w, "items_search.html", page)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
 // extracted method to improve readability
-func searchItemKeyword(w *http.ResponseWriter, r *http.Request, session userSession, itemDescription string, itemCategory string) {
+func searchItemKeyword(w *http.ResponseWriter, r *http.Request, session userSession, itemDescription string, itemCategory string, attributeFilters []model.AttributeFilter) {
 	resItems, err1 := authManager.FindItemsByKeyword(session.id, itemDescription)
 	if err1 != nil {
 		setFlashMessage(w, "error", err1.Error(), "/items/search")
@@ -819,13 +1069,45 @@ func searchItemKeyword(w *http.ResponseWriter, r *http.Request, session userSess
 		return
 	}
 	if itemCategory != "" {
-		temp := make([]model.Item, 0)
-		for _, item := range resItems {
-			if item.Category == itemCategory {
-				temp = append(temp, item)
-			}
+		// the category search includes the subcategories, so its results are used as a filter
+		categoryItems, err3 := authManager.FindItemsByCategory(session.id, itemCategory)
+		if err3 != nil {
+			setFlashMessage(w, "error", err3.Error(), "/items/search")
+			http.Redirect(*w, r, "/items/search", http.StatusFound)
+			return
 		}
-		resItems = temp
+		resItems = keepItems(resItems, categoryItems)
+	}
+	if len(attributeFilters) != 0 {
+		attributeItems, err4 := authManager.FindItemsByAttributes(session.id, attributeFilters)
+		if err4 != nil {
+			setFlashMessage(w, "error", err4.Error(), "/items/search")
+			http.Redirect(*w, r, "/items/search", http.StatusFound)
+			return
+		}
+		resItems = keepItems(resItems, attributeItems)
+	}
+	if len(resItems) == 0 {
+		setFlashMessage(w, "error", "No record found", "/items/search")
+		http.Redirect(*w, r, "/items/search", http.StatusFound)
+		return
+	}
+	page := fillSearchPage(w, r, session, resItems, nil)
+	err2 := templates.ExecuteTemplate(*w, "items_search.html", page)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+// searches items by the part number given to them by their suppliers
+func searchItemPartNumber(w *http.ResponseWriter, r *http.Request, session userSession, partNumber string) {
+	resItems, err1 := authManager.FindItemsBySupplierPartNumber(session.id, partNumber)
+	if err1 != nil {
+		setFlashMessage(w, "error", err1.Error(), "/items/search")
+		http.Redirect(*w, r, "/items/search", http.StatusFound)
+		return
 	}
 	if len(resItems) == 0 {
 		setFlashMessage(w, "error", "No record found", "/items/search")
@@ -1377,6 +1659,68 @@ func BuildAPPRouter(prefix string) *mux.Router {
 	router.HandleFunc("/item/{itemID:[0-9]+}/supply", SessionIsAbsentRedirectHandler(SupplyItemHandler))
 	router.HandleFunc("/item/{itemID:[0-9]+}/consume", SessionIsAbsentRedirectHandler(ConsumeItemHandler))
 	router.HandleFunc("/item/{itemID:[0-9]+}/transfer", SessionIsAbsentRedirectHandler(TransferItemHandler))
+	router.HandleFunc("/stock/batch", SessionIsAbsentRedirectHandler(StockBatchHandler))
+	router.HandleFunc("/admin/consistency", SessionIsAbsentRedirectHandler(ConsistencyHandler))
+	router.HandleFunc("/warehouse/{warehouseID:[0-9]+}/location", SessionIsAbsentRedirectHandler(EditWarehouseLocationHandler)).Methods("POST")
+	router.HandleFunc("/categories", SessionIsAbsentRedirectHandler(CategoriesHandler))
+	router.HandleFunc("/category/{categoryID:[0-9]+}/rename", SessionIsAbsentRedirectHandler(RenameCategoryHandler)).Methods("POST")
+	router.HandleFunc("/category/{categoryID:[0-9]+}/move", SessionIsAbsentRedirectHandler(MoveCategoryHandler)).Methods("POST")
+	router.HandleFunc("/category/{categoryID:[0-9]+}/merge", SessionIsAbsentRedirectHandler(MergeCategoryHandler)).Methods("POST")
+	router.HandleFunc("/category/{categoryID:[0-9]+}/attributes", SessionIsAbsentRedirectHandler(CategoryAttributesHandler))
+	router.HandleFunc("/attribute/{definitionID:[0-9]+}/delete", SessionIsAbsentRedirectHandler(DeleteAttributeHandler)).Methods("POST")
+	router.HandleFunc("/item/{itemID:[0-9]+}/attributes", SessionIsAbsentRedirectHandler(EditItemAttributesHandler)).Methods("POST")
+	router.HandleFunc("/item/{itemID:[0-9]+}/bom", SessionIsAbsentRedirectHandler(SetBOMLineHandler)).Methods("POST")
+	router.HandleFunc("/item/{itemID:[0-9]+}/status", SessionIsAbsentRedirectHandler(ChangeStockStatusHandler)).Methods("POST")
+	router.HandleFunc("/item/{itemID:[0-9]+}/assemble", SessionIsAbsentRedirectHandler(AssembleKitsHandler(false))).Methods("POST")
+	router.HandleFunc("/item/{itemID:[0-9]+}/disassemble", SessionIsAbsentRedirectHandler(AssembleKitsHandler(true))).Methods("POST")
+	router.HandleFunc("/reports/abc", SessionIsAbsentRedirectHandler(ClassificationHandler)).Methods("GET", "POST")
+	router.HandleFunc("/item/{itemID:[0-9]+}/cost", SessionIsAbsentRedirectHandler(EditItemUnitCostHandler)).Methods("POST")
+	router.HandleFunc("/inventory/asof", SessionIsAbsentRedirectHandler(InventoryAsOfHandler)).Methods("GET")
+	router.HandleFunc("/inventory/snapshots", SessionIsAbsentRedirectHandler(TakeInventorySnapshotHandler)).Methods("POST")
+	router.HandleFunc("/transfers", SessionIsAbsentRedirectHandler(TransfersHandler))
+	router.HandleFunc("/transfer/{shipmentID:[0-9]+}/receive", SessionIsAbsentRedirectHandler(ReceiveShipmentHandler)).Methods("POST")
+	router.HandleFunc("/rebalancing", SessionIsAbsentRedirectHandler(RebalancingHandler))
+	router.HandleFunc("/rebalancing/levels", SessionIsAbsentRedirectHandler(SetStockLevelHandler)).Methods("POST")
+	router.HandleFunc("/putaway", SessionIsAbsentRedirectHandler(PutawayHandler))
+	router.HandleFunc("/warehouse/{warehouseID:[0-9]+}/priority", SessionIsAbsentRedirectHandler(EditWarehousePriorityHandler)).Methods("POST")
+	router.HandleFunc("/returns", SessionIsAbsentRedirectHandler(ReturnsHandler))
+	router.HandleFunc("/return/{returnID:[0-9]+}/receive", SessionIsAbsentRedirectHandler(ReceiveReturnHandler)).Methods("POST")
+	router.HandleFunc("/return/{returnID:[0-9]+}/ship", SessionIsAbsentRedirectHandler(ShipSupplierReturnHandler)).Methods("POST")
+	router.HandleFunc("/reasons", SessionIsAbsentRedirectHandler(ReasonsHandler))
+	router.HandleFunc("/reason/{reasonID:[0-9]+}/delete", SessionIsAbsentRedirectHandler(DeleteReasonHandler)).Methods("POST")
+	router.HandleFunc("/item/{itemID:[0-9]+}/writeoff", SessionIsAbsentRedirectHandler(WriteOffItemsHandler)).Methods("POST")
+	router.HandleFunc("/reports/shrinkage", SessionIsAbsentRedirectHandler(ShrinkageHandler)).Methods("GET")
+	router.HandleFunc("/item/{itemID:[0-9]+}/archive", SessionIsAbsentRedirectHandler(ArchiveItemHandler(true))).Methods("POST")
+	router.HandleFunc("/item/{itemID:[0-9]+}/restore", SessionIsAbsentRedirectHandler(ArchiveItemHandler(false))).Methods("POST")
+	router.HandleFunc("/item/{itemID:[0-9]+}/merge", SessionIsAbsentRedirectHandler(MergeItemsHandler)).Methods("POST")
+	router.HandleFunc("/suppliers", SessionIsAbsentRedirectHandler(SuppliersHandler))
+	router.HandleFunc("/supplier/{supplierID:[0-9]+}/delete", SessionIsAbsentRedirectHandler(DeleteSupplierHandler)).Methods("POST")
+	router.HandleFunc("/item/{itemID:[0-9]+}/suppliers", SessionIsAbsentRedirectHandler(SetSupplierItemHandler)).Methods("POST")
+	router.HandleFunc("/item/{itemID:[0-9]+}/suppliers/{supplierID:[0-9]+}/remove", SessionIsAbsentRedirectHandler(RemoveSupplierItemHandler)).Methods("POST")
+	router.HandleFunc("/loans", SessionIsAbsentRedirectHandler(LoansHandler))
+	router.HandleFunc("/loan/{loanID:[0-9]+}/checkin", SessionIsAbsentRedirectHandler(CheckInLoanHandler)).Methods("POST")
+	router.HandleFunc("/projects", SessionIsAbsentRedirectHandler(ProjectsHandler))
+	router.HandleFunc("/project/{projectID:[0-9]+}/close", SessionIsAbsentRedirectHandler(CloseProjectHandler(true))).Methods("POST")
+	router.HandleFunc("/project/{projectID:[0-9]+}/reopen", SessionIsAbsentRedirectHandler(CloseProjectHandler(false))).Methods("POST")
+	router.HandleFunc("/reports/projects", SessionIsAbsentRedirectHandler(ProjectReportHandler))
+	router.HandleFunc("/approvals", SessionIsAbsentRedirectHandler(ApprovalsHandler))
+	router.HandleFunc("/approvals/team/{memberID:[0-9]+}/release", SessionIsAbsentRedirectHandler(ReleaseTeamMemberHandler)).Methods("POST")
+	router.HandleFunc("/approvals/team/{memberID:[0-9]+}/threshold", SessionIsAbsentRedirectHandler(SetMemberThresholdHandler)).Methods("POST")
+	router.HandleFunc("/approval/{memberID:[0-9]+}/{requestID:[0-9]+}/approve", SessionIsAbsentRedirectHandler(DecideApprovalHandler(true))).Methods("POST")
+	router.HandleFunc("/approval/{memberID:[0-9]+}/{requestID:[0-9]+}/reject", SessionIsAbsentRedirectHandler(DecideApprovalHandler(false))).Methods("POST")
+	router.HandleFunc("/item/{itemID:[0-9]+}/threshold", SessionIsAbsentRedirectHandler(SetItemApprovalThresholdHandler)).Methods("POST")
+	router.HandleFunc("/reports/replenishment", SessionIsAbsentRedirectHandler(ReplenishmentHandler)).Methods("GET")
+	router.HandleFunc("/products", SessionIsAbsentRedirectHandler(ProductsHandler)).Methods("POST")
+	router.HandleFunc("/product/{productID:[0-9]+}", SessionIsAbsentRedirectHandler(ProductHandler)).Methods("GET")
+	router.HandleFunc("/product/{productID:[0-9]+}/variants", SessionIsAbsentRedirectHandler(AddVariantHandler)).Methods("POST")
+	router.HandleFunc("/product/{productID:[0-9]+}/variants/{itemID:[0-9]+}/remove", SessionIsAbsentRedirectHandler(RemoveVariantHandler)).Methods("POST")
+	for _, owner := range []model.AttachmentOwner{model.ItemAttachment, model.WarehouseAttachment} {
+		attachmentsPath := "/" + string(owner) + "/{" + string(owner) + "ID:[0-9]+}/attachments"
+		router.HandleFunc(attachmentsPath, SessionIsAbsentRedirectHandler(AttachmentsHandler(owner)))
+		router.HandleFunc(attachmentsPath+"/{attachmentID:[0-9]+}", SessionIsAbsentRedirectHandler(AttachmentFileHandler(owner, false))).Methods("GET")
+		router.HandleFunc(attachmentsPath+"/{attachmentID:[0-9]+}/thumbnail", SessionIsAbsentRedirectHandler(AttachmentFileHandler(owner, true))).Methods("GET")
+		router.HandleFunc(attachmentsPath+"/{attachmentID:[0-9]+}/delete", SessionIsAbsentRedirectHandler(DeleteAttachmentHandler(owner))).Methods("POST")
+	}
 	router.NotFoundHandler = http.HandlerFunc(NotFoundHandler)
 	return router
 }
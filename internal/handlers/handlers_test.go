@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"bytes"
 	"github.com/gorilla/mux"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -97,6 +100,25 @@ func TestHandlers(t *testing.T) {
 		"/items/search",
 		"/warehouses/search",
 		"/account",
+		"/stock/batch",
+		"/admin/consistency",
+		"/categories",
+		"/category/1/attributes",
+		"/item/1/attachments",
+		"/reports/replenishment",
+		"/reports/abc",
+		"/inventory/asof",
+		"/transfers",
+		"/rebalancing",
+		"/putaway",
+		"/returns",
+		"/reasons",
+		"/reports/shrinkage",
+		"/suppliers",
+		"/loans",
+		"/projects",
+		"/reports/projects",
+		"/approvals",
 	}
 	for _, homeURL := range urls {
 		t.Run("routing tests redirect to login page path "+homeURL, func(t *testing.T) {
@@ -387,6 +409,880 @@ func TestHandlers(t *testing.T) {
 				t.Errorf("Wrong redirect- expected redirect: /items actual redirect: %s\nError: %v", rr.Header().Get("Location"), rr.Body)
 			}
 		})
+		t.Run("Batch stock operations", func(t *testing.T) {
+			for _, action := range []string{"preview", "commit"} {
+				req, err := http.NewRequest(http.MethodPost, "/stock/batch", nil)
+				if err != nil {
+					t.Fatalf("Reported error: " + err.Error())
+				}
+				neededCookies := rr1.Result().Cookies()
+				for _, cookie := range neededCookies {
+					req.AddCookie(cookie)
+				}
+				rr = httptest.NewRecorder()
+				form, _ := url.ParseQuery(req.URL.RawQuery)
+				form.Add("lines", "supply\tKeyboard\tLa Rosa 1\t3\ntransfer\t#1\tLa Rosa 1\t2\t#2")
+				form.Add("action", action)
+				req.URL.RawQuery = form.Encode()
+				router.ServeHTTP(rr, req)
+				if action == "preview" && rr.Code != http.StatusOK {
+					t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusOK, rr.Code)
+				}
+				if action == "commit" && rr.Header().Get("Location") != "/items" {
+					t.Errorf("Wrong redirect- expected redirect: /items actual redirect: %s", rr.Header().Get("Location"))
+				}
+			}
+			packs, err := GetManager().ActiveUsers[0].DB.FindWarehousesForItem(1)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			stock := make(map[uint]int)
+			for _, pack := range packs {
+				stock[pack.WarehouseID] = pack.ItemQuantity
+			}
+			if stock[1] != 5 || stock[2] != 3 {
+				t.Errorf("Wrong stock after the batch: %v", stock)
+			}
+			// a row missing a column is rejected instead of shifting the other fields
+			req, err := http.NewRequest(http.MethodPost, "/stock/batch", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range rr1.Result().Cookies() {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			form, _ := url.ParseQuery(req.URL.RawQuery)
+			form.Add("lines", "consume,Keyboard,3")
+			form.Add("separator", ",")
+			form.Add("action", "commit")
+			req.URL.RawQuery = form.Encode()
+			router.ServeHTTP(rr, req)
+			rejected := false
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == "error" && cookie.Value != "" {
+					rejected = true
+				}
+			}
+			if rr.Code != http.StatusFound || !rejected {
+				t.Errorf("Row with a missing column wasn't rejected")
+			}
+		})
+		t.Run("Concurrent edits", func(t *testing.T) {
+			expectedCodes := []int{http.StatusFound, http.StatusConflict}
+			for i, description := range []string{"mechanical keyboard", "wireless keyboard"} {
+				req, err := http.NewRequest(http.MethodPost, "/item/1/edit", nil)
+				if err != nil {
+					t.Fatalf("Reported error: " + err.Error())
+				}
+				neededCookies := rr1.Result().Cookies()
+				for _, cookie := range neededCookies {
+					req.AddCookie(cookie)
+				}
+				rr = httptest.NewRecorder()
+				form, _ := url.ParseQuery(req.URL.RawQuery)
+				form.Add("itemName", "Keyboard")
+				form.Add("itemCategory", "electronics")
+				form.Add("itemDescription", description)
+				form.Add("version", "1")
+				req.URL.RawQuery = form.Encode()
+				router.ServeHTTP(rr, req)
+				if rr.Code != expectedCodes[i] {
+					t.Errorf("Returned wrong status code. Expected %d, got %d", expectedCodes[i], rr.Code)
+				}
+			}
+		})
+		t.Run("Warehouse location", func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/warehouse/1/location", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			neededCookies := rr1.Result().Cookies()
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			form, _ := url.ParseQuery(req.URL.RawQuery)
+			form.Add("latitude", "43.7696")
+			form.Add("longitude", "11.2558")
+			form.Add("city", "Florence")
+			req.URL.RawQuery = form.Encode()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusFound {
+				t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+			}
+			req2, err2 := http.NewRequest(http.MethodGet, "/item/1?nearTo=1&minQuantity=1", nil)
+			if err2 != nil {
+				t.Fatalf("Reported error: " + err2.Error())
+			}
+			for _, cookie := range neededCookies {
+				req2.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req2)
+			if rr.Code != http.StatusOK {
+				t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusOK, rr.Code)
+			}
+		})
+		t.Run("Categories", func(t *testing.T) {
+			requests := []struct {
+				url    string
+				values map[string]string
+			}{
+				{"/categories", map[string]string{"categoryName": "Peripherals"}},
+				{"/category/1/move", map[string]string{"parentID": "2"}},
+				{"/category/2/rename", map[string]string{"categoryName": "Computer peripherals"}},
+			}
+			for _, request := range requests {
+				req, err := http.NewRequest(http.MethodPost, request.url, nil)
+				if err != nil {
+					t.Fatalf("Reported error: " + err.Error())
+				}
+				neededCookies := rr1.Result().Cookies()
+				for _, cookie := range neededCookies {
+					req.AddCookie(cookie)
+				}
+				rr = httptest.NewRecorder()
+				form, _ := url.ParseQuery(req.URL.RawQuery)
+				for key, value := range request.values {
+					form.Add(key, value)
+				}
+				req.URL.RawQuery = form.Encode()
+				router.ServeHTTP(rr, req)
+				if rr.Code != http.StatusFound {
+					t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+				}
+				for _, cookie := range rr.Result().Cookies() {
+					if cookie.Name == "error" && cookie.Value != "" {
+						t.Errorf("Unexpected error for %s: %s", request.url, cookie.Value)
+					}
+				}
+			}
+		})
+		t.Run("Item attributes", func(t *testing.T) {
+			requests := []struct {
+				url          string
+				values       map[string]string
+				expectedCode int
+			}{
+				{"/category/1/attributes", map[string]string{"attributeName": "layout", "attributeType": "enum", "attributeOptions": "ISO, ANSI"}, http.StatusFound},
+				{"/item/1/attributes", map[string]string{"attributeID": "1", "attributeValue": "iso"}, http.StatusFound},
+				{"/items/search", map[string]string{"attributeName": "Layout", "attributeOperator": "=", "attributeValue": "ISO"}, http.StatusOK},
+			}
+			for _, request := range requests {
+				req, err := http.NewRequest(http.MethodPost, request.url, nil)
+				if err != nil {
+					t.Fatalf("Reported error: " + err.Error())
+				}
+				neededCookies := rr1.Result().Cookies()
+				for _, cookie := range neededCookies {
+					req.AddCookie(cookie)
+				}
+				rr = httptest.NewRecorder()
+				form, _ := url.ParseQuery(req.URL.RawQuery)
+				for key, value := range request.values {
+					form.Add(key, value)
+				}
+				req.URL.RawQuery = form.Encode()
+				router.ServeHTTP(rr, req)
+				if rr.Code != request.expectedCode {
+					t.Errorf("Returned wrong status code for %s. Expected %d, got %d", request.url, request.expectedCode, rr.Code)
+				}
+				for _, cookie := range rr.Result().Cookies() {
+					if cookie.Name == "error" && cookie.Value != "" {
+						t.Errorf("Unexpected error for %s: %s", request.url, cookie.Value)
+					}
+				}
+			}
+		})
+		t.Run("Attachments", func(t *testing.T) {
+			var body bytes.Buffer
+			writer := multipart.NewWriter(&body)
+			part, _ := writer.CreateFormFile("file", "manual.txt")
+			_, _ = part.Write([]byte("press the keys gently"))
+			_ = writer.Close()
+			req, err := http.NewRequest(http.MethodPost, "/item/1/attachments", &body)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			neededCookies := rr1.Result().Cookies()
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusFound {
+				t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+			}
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == "error" && cookie.Value != "" {
+					t.Errorf("Unexpected error: %s", cookie.Value)
+				}
+			}
+			req2, err2 := http.NewRequest(http.MethodGet, "/item/1/attachments/1", nil)
+			if err2 != nil {
+				t.Fatalf("Reported error: " + err2.Error())
+			}
+			for _, cookie := range neededCookies {
+				req2.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req2)
+			if rr.Code != http.StatusOK || rr.Body.String() != "press the keys gently" {
+				t.Errorf("Attachment wasn't downloaded: status %d, content %q", rr.Code, rr.Body.String())
+			}
+			req3, err3 := http.NewRequest(http.MethodGet, "/warehouse/1/attachments/1", nil)
+			if err3 != nil {
+				t.Fatalf("Reported error: " + err3.Error())
+			}
+			for _, cookie := range neededCookies {
+				req3.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req3)
+			if rr.Code != http.StatusNotFound {
+				t.Errorf("Attachment of another record was served: status %d", rr.Code)
+			}
+		})
+		t.Run("Products", func(t *testing.T) {
+			requests := []struct {
+				url    string
+				values map[string]string
+			}{
+				{"/products", map[string]string{"productName": "Keyboards", "productDimensions": "layout, colour"}},
+				{"/product/1/variants", map[string]string{"itemID": "1", "dimension_layout": "ISO", "dimension_colour": "black"}},
+			}
+			for _, request := range requests {
+				req, err := http.NewRequest(http.MethodPost, request.url, nil)
+				if err != nil {
+					t.Fatalf("Reported error: " + err.Error())
+				}
+				neededCookies := rr1.Result().Cookies()
+				for _, cookie := range neededCookies {
+					req.AddCookie(cookie)
+				}
+				rr = httptest.NewRecorder()
+				form, _ := url.ParseQuery(req.URL.RawQuery)
+				for key, value := range request.values {
+					form.Add(key, value)
+				}
+				req.URL.RawQuery = form.Encode()
+				router.ServeHTTP(rr, req)
+				if rr.Code != http.StatusFound {
+					t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+				}
+				for _, cookie := range rr.Result().Cookies() {
+					if cookie.Name == "error" && cookie.Value != "" {
+						t.Errorf("Unexpected error for %s: %s", request.url, cookie.Value)
+					}
+				}
+			}
+			req, err := http.NewRequest(http.MethodGet, "/product/1", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range rr1.Result().Cookies() {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusOK, rr.Code)
+			}
+		})
+		t.Run("Kits", func(t *testing.T) {
+			req1, err1 := http.NewRequest(http.MethodPost, "/items", nil)
+			if err1 != nil {
+				t.Fatalf("Reported error: " + err1.Error())
+			}
+			neededCookies := rr1.Result().Cookies()
+			for _, cookie := range neededCookies {
+				req1.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			form1, _ := url.ParseQuery(req1.URL.RawQuery)
+			form1.Add("itemName", "Keyboard kit")
+			form1.Add("itemCategory", "electronics")
+			form1.Add("itemDescription", "two keyboards")
+			req1.URL.RawQuery = form1.Encode()
+			router.ServeHTTP(rr, req1)
+			if rr.Header().Get("Location") != "/item/2" {
+				t.Fatalf("Kit wasn't created, redirected to %s", rr.Header().Get("Location"))
+			}
+			req, err := http.NewRequest(http.MethodPost, "/item/2/bom", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			form, _ := url.ParseQuery(req.URL.RawQuery)
+			form.Add("componentID", "1")
+			form.Add("quantity", "2")
+			req.URL.RawQuery = form.Encode()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusFound {
+				t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+			}
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == "error" && cookie.Value != "" {
+					t.Errorf("Unexpected error: %s", cookie.Value)
+				}
+			}
+			req2, err2 := http.NewRequest(http.MethodGet, "/item/2", nil)
+			if err2 != nil {
+				t.Fatalf("Reported error: " + err2.Error())
+			}
+			for _, cookie := range neededCookies {
+				req2.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req2)
+			if rr.Code != http.StatusOK {
+				t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusOK, rr.Code)
+			}
+		})
+		t.Run("Replenishment CSV", func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/reports/replenishment?format=csv&leadTimeDays=10", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			neededCookies := rr1.Result().Cookies()
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusOK, rr.Code)
+			}
+			if rr.Header().Get("Content-Type") != "text/csv" {
+				t.Errorf("Returned wrong content type: %s", rr.Header().Get("Content-Type"))
+			}
+			if !strings.HasPrefix(rr.Body.String(), "item ID,item,stock") {
+				t.Errorf("Returned wrong CSV header: %s", rr.Body.String())
+			}
+		})
+		t.Run("Classification", func(t *testing.T) {
+			neededCookies := rr1.Result().Cookies()
+			requests := []string{"/item/1/cost?unitCost=12.5", "/reports/abc?days=30&basis=volume"}
+			for _, path := range requests {
+				req, err := http.NewRequest(http.MethodPost, path, nil)
+				if err != nil {
+					t.Fatalf("Reported error: " + err.Error())
+				}
+				for _, cookie := range neededCookies {
+					req.AddCookie(cookie)
+				}
+				rr = httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				if rr.Code != http.StatusFound {
+					t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+				}
+				for _, cookie := range rr.Result().Cookies() {
+					if cookie.Name == "error" && cookie.Value != "" {
+						t.Errorf("Unexpected error: %s", cookie.Value)
+					}
+				}
+			}
+			req, err := http.NewRequest(http.MethodGet, "/items", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if !strings.Contains(rr.Body.String(), "class=\"badge\"") {
+				t.Errorf("Items page doesn't show the class of the items")
+			}
+		})
+		t.Run("Dashboard", func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/home", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			neededCookies := rr1.Result().Cookies()
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusOK, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), "<svg") {
+				t.Errorf("Home page doesn't show the charts of the dashboard")
+			}
+			if !strings.Contains(rr.Body.String(), "is low on supply") {
+				t.Errorf("Home page doesn't show the low supply alert")
+			}
+		})
+		t.Run("Inventory As Of", func(t *testing.T) {
+			neededCookies := rr1.Result().Cookies()
+			req1, err1 := http.NewRequest(http.MethodPost, "/inventory/snapshots", nil)
+			if err1 != nil {
+				t.Fatalf("Reported error: " + err1.Error())
+			}
+			for _, cookie := range neededCookies {
+				req1.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req1)
+			if rr.Header().Get("Location") != "/inventory/asof" {
+				t.Errorf("Redirected to wrong URL. Expected /inventory/asof, got %s", rr.Header().Get("Location"))
+			}
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == "error" && cookie.Value != "" {
+					t.Errorf("Unexpected error: %s", cookie.Value)
+				}
+			}
+			req2, err2 := http.NewRequest(http.MethodGet, "/inventory/asof?at=2020-03-31&from=2020-03-31&to="+time.Now().Format(time.DateOnly), nil)
+			if err2 != nil {
+				t.Fatalf("Reported error: " + err2.Error())
+			}
+			for _, cookie := range neededCookies {
+				req2.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req2)
+			if rr.Code != http.StatusOK {
+				t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusOK, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), "change") {
+				t.Errorf("Inventory page doesn't show the differences between the dates")
+			}
+		})
+		t.Run("Shipments", func(t *testing.T) {
+			neededCookies := rr1.Result().Cookies()
+			requests := []string{
+				"/warehouses?warehouseName=Depot&warehousePosition=Rome&warehouseCapacity=500",
+				"/item/1/supply?amount=10&warehouseID=1",
+				"/transfers?itemID=1&sourceWarehouseID=1&destinationWarehouseID=2&quantity=4",
+				"/transfer/1/receive?receivedQuantity=3",
+			}
+			for _, path := range requests {
+				req, err := http.NewRequest(http.MethodPost, path, nil)
+				if err != nil {
+					t.Fatalf("Reported error: " + err.Error())
+				}
+				for _, cookie := range neededCookies {
+					req.AddCookie(cookie)
+				}
+				rr = httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				if rr.Code != http.StatusFound {
+					t.Errorf("Returned wrong status code for %s. Expected %d, got %d", path, http.StatusFound, rr.Code)
+				}
+				for _, cookie := range rr.Result().Cookies() {
+					if cookie.Name == "error" && cookie.Value != "" {
+						t.Errorf("Unexpected error for %s: %s", path, cookie.Value)
+					}
+				}
+			}
+		})
+		t.Run("Rebalancing", func(t *testing.T) {
+			neededCookies := rr1.Result().Cookies()
+			req1, err1 := http.NewRequest(http.MethodPost, "/rebalancing/levels?itemID=1&warehouseID=2&min=8&max=0", nil)
+			if err1 != nil {
+				t.Fatalf("Reported error: " + err1.Error())
+			}
+			for _, cookie := range neededCookies {
+				req1.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req1)
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == "error" && cookie.Value != "" {
+					t.Errorf("Unexpected error: %s", cookie.Value)
+				}
+			}
+			req2, err2 := http.NewRequest(http.MethodGet, "/rebalancing", nil)
+			if err2 != nil {
+				t.Fatalf("Reported error: " + err2.Error())
+			}
+			for _, cookie := range neededCookies {
+				req2.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req2)
+			if !strings.Contains(rr.Body.String(), "Execute the accepted transfers") {
+				t.Fatalf("Rebalancing page doesn't propose any transfer")
+			}
+			req3, err3 := http.NewRequest(http.MethodPost, "/rebalancing?accept=0&moveItem=1&moveSource=1&moveDestination=2&moveQuantity=2", nil)
+			if err3 != nil {
+				t.Fatalf("Reported error: " + err3.Error())
+			}
+			for _, cookie := range neededCookies {
+				req3.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req3)
+			if rr.Code != http.StatusFound {
+				t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+			}
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == "error" && cookie.Value != "" {
+					t.Errorf("Unexpected error: %s", cookie.Value)
+				}
+			}
+		})
+		t.Run("Putaway", func(t *testing.T) {
+			neededCookies := rr1.Result().Cookies()
+			requests := map[string]string{
+				"/warehouse/1/priority?priority=3":               "/warehouse/1",
+				"/putaway?itemID=1&quantity=3&strategy=emptiest": "/item/1",
+			}
+			for path, location := range requests {
+				req, err := http.NewRequest(http.MethodPost, path, nil)
+				if err != nil {
+					t.Fatalf("Reported error: " + err.Error())
+				}
+				for _, cookie := range neededCookies {
+					req.AddCookie(cookie)
+				}
+				rr = httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				if rr.Header().Get("Location") != location {
+					t.Errorf("Redirected to wrong URL. Expected %s, got %s", location, rr.Header().Get("Location"))
+				}
+			}
+			req, err := http.NewRequest(http.MethodGet, "/putaway?itemID=1&quantity=3&strategy=priority", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if !strings.Contains(rr.Body.String(), "Suggested allocation") {
+				t.Errorf("Putaway page doesn't suggest an allocation")
+			}
+		})
+		t.Run("Stock Statuses", func(t *testing.T) {
+			neededCookies := rr1.Result().Cookies()
+			paths := []string{
+				"/item/1/supply?warehouseID=1&amount=5&status=quarantined",
+				"/item/1/status?warehouseID=1&amount=5&from=quarantined&to=available",
+			}
+			for _, path := range paths {
+				req, err := http.NewRequest(http.MethodPost, path, nil)
+				if err != nil {
+					t.Fatalf("Reported error: " + err.Error())
+				}
+				for _, cookie := range neededCookies {
+					req.AddCookie(cookie)
+				}
+				rr = httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				if rr.Code != http.StatusFound {
+					t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+				}
+				for _, cookie := range rr.Result().Cookies() {
+					if cookie.Name == "error" && cookie.Value != "" {
+						t.Errorf("Unexpected error: %s", cookie.Value)
+					}
+				}
+			}
+			req, err := http.NewRequest(http.MethodGet, "/item/1", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if !strings.Contains(rr.Body.String(), "quarantined") {
+				t.Errorf("Item page doesn't show the stock statuses")
+			}
+		})
+		t.Run("Returns", func(t *testing.T) {
+			neededCookies := rr1.Result().Cookies()
+			paths := []string{
+				"/returns?kind=customer&itemID=1&quantity=2&reason=damaged&orderReference=SO-1",
+				"/return/1/receive?warehouseID=1&quantity=2&disposition=quarantine",
+			}
+			for _, path := range paths {
+				req, err := http.NewRequest(http.MethodPost, path, nil)
+				if err != nil {
+					t.Fatalf("Reported error: " + err.Error())
+				}
+				for _, cookie := range neededCookies {
+					req.AddCookie(cookie)
+				}
+				rr = httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				if rr.Code != http.StatusFound {
+					t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+				}
+				for _, cookie := range rr.Result().Cookies() {
+					if cookie.Name == "error" && cookie.Value != "" {
+						t.Errorf("Unexpected error: %s", cookie.Value)
+					}
+				}
+			}
+			req, err := http.NewRequest(http.MethodGet, "/returns", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if !strings.Contains(rr.Body.String(), "SO-1") {
+				t.Errorf("Returns page doesn't list the completed return")
+			}
+		})
+		t.Run("Write-offs", func(t *testing.T) {
+			neededCookies := rr1.Result().Cookies()
+			paths := []string{
+				"/item/1/consume?warehouseID=1&amount=1&reason=sample&note=fair",
+				"/item/1/writeoff?warehouseID=1&amount=1&status=available&reason=theft&note=missing",
+			}
+			for _, path := range paths {
+				req, err := http.NewRequest(http.MethodPost, path, nil)
+				if err != nil {
+					t.Fatalf("Reported error: " + err.Error())
+				}
+				for _, cookie := range neededCookies {
+					req.AddCookie(cookie)
+				}
+				rr = httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				if rr.Code != http.StatusFound {
+					t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+				}
+				for _, cookie := range rr.Result().Cookies() {
+					if cookie.Name == "error" && cookie.Value != "" {
+						t.Errorf("Unexpected error: %s", cookie.Value)
+					}
+				}
+			}
+			req, err := http.NewRequest(http.MethodGet, "/reports/shrinkage", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if !strings.Contains(rr.Body.String(), "theft") {
+				t.Errorf("Shrinkage report doesn't show the write-off")
+			}
+		})
+		t.Run("Archive", func(t *testing.T) {
+			neededCookies := rr1.Result().Cookies()
+			requests := []struct {
+				path  string
+				error bool
+			}{
+				{"/item/2/archive", false},
+				{"/item/2/supply?warehouseID=1&amount=1", true},
+				{"/item/2/restore", false},
+			}
+			for _, request := range requests {
+				req, err := http.NewRequest(http.MethodPost, request.path, nil)
+				if err != nil {
+					t.Fatalf("Reported error: " + err.Error())
+				}
+				for _, cookie := range neededCookies {
+					req.AddCookie(cookie)
+				}
+				rr = httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				failed := false
+				for _, cookie := range rr.Result().Cookies() {
+					if cookie.Name == "error" && cookie.Value != "" {
+						failed = true
+					}
+				}
+				if failed != request.error {
+					t.Errorf("Unexpected outcome of %s, error reported: %t", request.path, failed)
+				}
+			}
+		})
+		t.Run("Suppliers", func(t *testing.T) {
+			neededCookies := rr1.Result().Cookies()
+			paths := []string{
+				"/suppliers?supplierName=Acme&supplierContact=orders",
+				"/item/1/suppliers?supplierID=1&partNumber=AC-100&unitPrice=2.5&minimumOrderQuantity=10&leadTimeDays=4&preferred=on",
+			}
+			for _, path := range paths {
+				req, err := http.NewRequest(http.MethodPost, path, nil)
+				if err != nil {
+					t.Fatalf("Reported error: " + err.Error())
+				}
+				for _, cookie := range neededCookies {
+					req.AddCookie(cookie)
+				}
+				rr = httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				if rr.Code != http.StatusFound {
+					t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+				}
+				for _, cookie := range rr.Result().Cookies() {
+					if cookie.Name == "error" && cookie.Value != "" {
+						t.Errorf("Unexpected error: %s", cookie.Value)
+					}
+				}
+			}
+			req, err := http.NewRequest(http.MethodPost, "/items/search?partNumber=ac-1", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "/items/1") {
+				t.Errorf("Item wasn't found by supplier part number, status %d", rr.Code)
+			}
+		})
+		t.Run("Loans", func(t *testing.T) {
+			neededCookies := rr1.Result().Cookies()
+			req, err := http.NewRequest(http.MethodPost, "/loans?itemID=1&warehouseID=1&quantity=1&serialNumber=SN-1&borrower=Alice&dueAt=2000-01-01", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusFound {
+				t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+			}
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == "error" && cookie.Value != "" {
+					t.Errorf("Unexpected error: %s", cookie.Value)
+				}
+			}
+			req, err = http.NewRequest(http.MethodGet, "/", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Overdue loans") {
+				t.Errorf("Overdue loan wasn't shown on the home page, status %d", rr.Code)
+			}
+			req, err = http.NewRequest(http.MethodPost, "/loan/1/checkin?warehouseID=1", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusFound {
+				t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+			}
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == "error" && cookie.Value != "" {
+					t.Errorf("Unexpected error: %s", cookie.Value)
+				}
+			}
+		})
+		t.Run("Projects", func(t *testing.T) {
+			neededCookies := rr1.Result().Cookies()
+			paths := []string{
+				"/projects?code=PRJ-7&name=Roof",
+				"/item/1/consume?warehouseID=1&amount=1&projectID=1",
+			}
+			for _, path := range paths {
+				req, err := http.NewRequest(http.MethodPost, path, nil)
+				if err != nil {
+					t.Fatalf("Reported error: " + err.Error())
+				}
+				for _, cookie := range neededCookies {
+					req.AddCookie(cookie)
+				}
+				rr = httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				if rr.Code != http.StatusFound {
+					t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+				}
+				for _, cookie := range rr.Result().Cookies() {
+					if cookie.Name == "error" && cookie.Value != "" {
+						t.Errorf("Unexpected error: %s", cookie.Value)
+					}
+				}
+			}
+			req, err := http.NewRequest(http.MethodGet, "/reports/projects", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "PRJ-7") {
+				t.Errorf("Consumption wasn't charged to the project, status %d", rr.Code)
+			}
+		})
+		t.Run("Approvals", func(t *testing.T) {
+			neededCookies := rr1.Result().Cookies()
+			req, err := http.NewRequest(http.MethodPost, "/item/1/threshold?threshold=500", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusFound {
+				t.Errorf("Returned wrong status code. Expected %d, got %d", http.StatusFound, rr.Code)
+			}
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == "error" && cookie.Value != "" {
+					t.Errorf("Unexpected error: %s", cookie.Value)
+				}
+			}
+			req, err = http.NewRequest(http.MethodPost, "/approvals?manager=nobody", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			found := false
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == "error" && cookie.Value != "" {
+					found = true
+				}
+			}
+			if rr.Code != http.StatusFound || !found {
+				t.Errorf("Unknown user was chosen as manager, status %d", rr.Code)
+			}
+			req, err = http.NewRequest(http.MethodGet, "/item/1", nil)
+			if err != nil {
+				t.Fatalf("Reported error: " + err.Error())
+			}
+			for _, cookie := range neededCookies {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `value="500"`) {
+				t.Errorf("Approval threshold wasn't set, status %d", rr.Code)
+			}
+		})
 		t.Run("Search Operations", func(t *testing.T) {
 			req2, err2 := http.NewRequest(http.MethodPost, "/warehouses/search", nil)
 			if err2 != nil {
@@ -414,6 +1310,25 @@ func TestHandlers(t *testing.T) {
 		"/items/search",
 		"/warehouses/search",
 		"/account",
+		"/stock/batch",
+		"/admin/consistency",
+		"/categories",
+		"/category/1/attributes",
+		"/item/1/attachments",
+		"/reports/replenishment",
+		"/reports/abc",
+		"/inventory/asof",
+		"/transfers",
+		"/rebalancing",
+		"/putaway",
+		"/returns",
+		"/reasons",
+		"/reports/shrinkage",
+		"/suppliers",
+		"/loans",
+		"/projects",
+		"/reports/projects",
+		"/approvals",
 	}
 	for _, homeURL := range urls {
 		t.Run("get various resource pages "+homeURL, func(t *testing.T) {
@@ -472,6 +1387,12 @@ func clean(t *testing.T) {
 				t.Fatalf("Failed to remove file\nerror: %v", err3)
 			}
 		}
+		if v.IsDir() && strings.HasSuffix(v.Name(), "_attachments") {
+			err4 := os.RemoveAll("data/" + v.Name())
+			if err4 != nil {
+				t.Fatalf("Failed to remove directory\nerror: %v", err4)
+			}
+		}
 		_ = os.Remove("users.json")
 	}
 }
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// InventoryPage represents the page obtained by calling /inventory/asof
+type InventoryPage struct {
+	Page
+	Warehouses  []model.Warehouse
+	Snapshots   []model.InventorySnapshot
+	WarehouseID uint
+	// dates as entered in the forms
+	At   string
+	From string
+	To   string
+	// Lines is the content of the warehouses at At, Differences the changes between From and To
+	Lines       []model.InventoryLine
+	Differences []model.InventoryDifference
+	Compared    bool
+}
+
+// InventoryAsOfHandler shows the content of the warehouses at a past date or, when two dates are given,
+// the differences between them
+func InventoryAsOfHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := InventoryPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(&w, r, "error", r.URL.Path)
+	query := r.URL.Query()
+	page.At = query.Get("at")
+	if page.At == "" {
+		page.At = time.Now().Format(time.DateOnly)
+	}
+	page.From = query.Get("from")
+	page.To = query.Get("to")
+	warehouseID, err1 := parseOptionalID(query.Get("warehouseID"))
+	if err1 != nil {
+		setFlashMessage(&w, "error", err1.Error(), "/inventory/asof")
+		http.Redirect(w, r, "/inventory/asof", http.StatusFound)
+		return
+	}
+	if warehouseID != nil {
+		page.WarehouseID = *warehouseID
+	}
+	err2 := fillInventoryPage(&page, session)
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), "/inventory/asof")
+		http.Redirect(w, r, "/inventory/asof", http.StatusFound)
+		return
+	}
+	err3 := templates.ExecuteTemplate(w, "inventory.html", page)
+	if err3 != nil {
+		http.Error(w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+func fillInventoryPage(page *InventoryPage, session userSession) error {
+	warehouses, err1 := authManager.ListAllWarehouses(session.id)
+	if err1 != nil {
+		return err1
+	}
+	snapshots, err2 := authManager.ListInventorySnapshots(session.id)
+	if err2 != nil {
+		return err2
+	}
+	page.Warehouses = warehouses
+	page.Snapshots = snapshots
+	at, err3 := parseInventoryTime(page.At)
+	if err3 != nil {
+		return err3
+	}
+	lines, err4 := authManager.FindInventoryAsOf(session.id, at, page.WarehouseID)
+	if err4 != nil {
+		return err4
+	}
+	page.Lines = lines
+	if page.From == "" || page.To == "" {
+		return nil
+	}
+	from, err5 := parseInventoryTime(page.From)
+	if err5 != nil {
+		return err5
+	}
+	to, err6 := parseInventoryTime(page.To)
+	if err6 != nil {
+		return err6
+	}
+	differences, err7 := authManager.CompareInventory(session.id, from, to, page.WarehouseID)
+	if err7 != nil {
+		return err7
+	}
+	page.Differences = differences
+	page.Compared = true
+	return nil
+}
+
+// parseInventoryTime accepts either a date and a time, or a date alone which stands for the end of that day
+func parseInventoryTime(value string) (time.Time, error) {
+	at, err1 := time.ParseInLocation("2006-01-02T15:04", value, time.Local)
+	if err1 == nil {
+		return at, nil
+	}
+	day, err2 := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err2 != nil {
+		return day, errors.New("invalid date: " + value)
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// TakeInventorySnapshotHandler stores the current content of the warehouses
+func TakeInventorySnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	_, err := authManager.TakeInventorySnapshot(session.id)
+	if err != nil {
+		setFlashMessage(&w, "error", err.Error(), "/inventory/asof")
+		http.Redirect(w, r, "/inventory/asof", http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/inventory/asof", http.StatusFound)
+	return
+}
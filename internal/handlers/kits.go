@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// SetBOMLineHandler adds, changes or removes a component of the bill of materials of the item
+func SetBOMLineHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	itemIDStr := mux.Vars(r)["itemID"]
+	itemID, err1 := strconv.Atoi(itemIDStr)
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	componentID, err2 := strconv.Atoi(r.FormValue("componentID"))
+	quantity, err3 := strconv.Atoi(r.FormValue("quantity"))
+	if err2 != nil || err3 != nil {
+		setFlashMessage(&w, "error", "choose a component and its quantity", "/item/"+itemIDStr)
+		http.Redirect(w, r, "/item/"+itemIDStr, http.StatusFound)
+		return
+	}
+	err4 := authManager.SetBOMLine(session.id, uint(itemID), uint(componentID), quantity)
+	if err4 != nil {
+		setFlashMessage(&w, "error", err4.Error(), "/item/"+itemIDStr)
+	}
+	http.Redirect(w, r, "/item/"+itemIDStr, http.StatusFound)
+	return
+}
+
+// AssembleKitsHandler assembles kits in a warehouse, or disassembles them when disassemble is true
+func AssembleKitsHandler(disassemble bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := getSession(&w, r)
+		if !ok {
+			http.Error(w, "no session found", http.StatusInternalServerError)
+			return
+		}
+		itemIDStr := mux.Vars(r)["itemID"]
+		itemID, err1 := strconv.Atoi(itemIDStr)
+		if err1 != nil {
+			http.Error(w, err1.Error(), http.StatusInternalServerError)
+			return
+		}
+		warehouseID, err2 := strconv.Atoi(r.FormValue("warehouseID"))
+		count, err3 := strconv.Atoi(r.FormValue("count"))
+		if err2 != nil || err3 != nil {
+			setFlashMessage(&w, "error", "choose a warehouse and the number of kits", "/item/"+itemIDStr)
+			http.Redirect(w, r, "/item/"+itemIDStr, http.StatusFound)
+			return
+		}
+		var err4 error
+		if disassemble {
+			err4 = authManager.DisassembleKits(session.id, uint(itemID), uint(warehouseID), count)
+		} else {
+			err4 = authManager.AssembleKits(session.id, uint(itemID), uint(warehouseID), count)
+		}
+		if err4 != nil {
+			setFlashMessage(&w, "error", err4.Error(), "/item/"+itemIDStr)
+		}
+		http.Redirect(w, r, "/item/"+itemIDStr, http.StatusFound)
+		return
+	}
+}
+
+// fillKitSection loads the bill of materials of the item shown by the page and the kits buildable from it
+func fillKitSection(page *ItemPage, session userSession) error {
+	components, err1 := authManager.FindBOM(session.id, page.Item.ID)
+	if err1 != nil {
+		return err1
+	}
+	page.BOM = components
+	if len(components) != 0 {
+		buildable, err2 := authManager.FindBuildableQuantity(session.id, page.Item.ID)
+		if err2 != nil {
+			return err2
+		}
+		page.Buildable = buildable
+	}
+	items, err3 := authManager.ListAllItems(session.id)
+	if err3 != nil {
+		return err3
+	}
+	for _, item := range items {
+		if item.ID != page.Item.ID {
+			page.OtherItems = append(page.OtherItems, item)
+		}
+	}
+	return nil
+}
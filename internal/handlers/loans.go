@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// LoansPage represents the page obtained by calling /loans
+type LoansPage struct {
+	Page
+	Items      []model.Item
+	Warehouses []model.Warehouse
+	Open       []model.LoanEntry
+	Returned   []model.LoanEntry
+	Now        time.Time
+}
+
+// LoansHandler lists the open and returned loans on GET and checks out items to a borrower on POST
+func LoansHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		{
+			getLoans(&w, r)
+			return
+		}
+	case http.MethodPost:
+		{
+			postLoans(&w, r)
+			return
+		}
+	default:
+		{
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+}
+
+func getLoans(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := LoansPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	items, err1 := authManager.ListAllItems(session.id)
+	if err1 != nil {
+		http.Error(*w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	warehouses, err2 := authManager.ListAllWarehouses(session.id)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	open, err3 := authManager.ListLoans(session.id, true)
+	if err3 != nil {
+		http.Error(*w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	returned, err4 := authManager.ListLoans(session.id, false)
+	if err4 != nil {
+		http.Error(*w, err4.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Items = items
+	page.Warehouses = warehouses
+	page.Open = open
+	page.Returned = returned
+	page.Now = time.Now()
+	err5 := templates.ExecuteTemplate(*w, "loans.html", page)
+	if err5 != nil {
+		http.Error(*w, err5.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+func postLoans(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	itemID, err1 := strconv.Atoi(r.FormValue("itemID"))
+	warehouseID, err2 := strconv.Atoi(r.FormValue("warehouseID"))
+	quantity, err3 := strconv.Atoi(r.FormValue("quantity"))
+	dueAt, err4 := parseInventoryTime(r.FormValue("dueAt"))
+	err5 := errors.Join(err1, err2, err3, err4)
+	if err5 == nil {
+		_, err5 = authManager.CheckOutItems(session.id, uint(itemID), uint(warehouseID), quantity, r.FormValue("borrower"),
+			r.FormValue("serialNumber"), dueAt)
+	}
+	if err5 != nil {
+		setFlashMessage(w, "error", err5.Error(), "/loans")
+	}
+	http.Redirect(*w, r, "/loans", http.StatusFound)
+	return
+}
+
+// CheckInLoanHandler closes a loan, returning its items to the chosen warehouse
+func CheckInLoanHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	loanID, err1 := strconv.Atoi(mux.Vars(r)["loanID"])
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	warehouseID, err2 := strconv.Atoi(r.FormValue("warehouseID"))
+	if err2 == nil {
+		err2 = authManager.CheckInLoan(session.id, uint(loanID), uint(warehouseID))
+	}
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), "/loans")
+	}
+	http.Redirect(w, r, "/loans", http.StatusFound)
+	return
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ProductPage represents the page obtained by calling /product/{id}
+type ProductPage struct {
+	Page
+	Matrix model.VariantMatrix
+	// items which aren't variants of any product and can become variants of this one
+	StandaloneItems []model.Item
+}
+
+// ProductsHandler creates a new product from the form of the /items page
+func ProductsHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	err1 := authManager.CreateProduct(session.id, r.FormValue("productName"), r.FormValue("productDescription"),
+		strings.Split(r.FormValue("productDimensions"), ","))
+	if err1 != nil {
+		setFlashMessage(&w, "error", err1.Error(), "/items")
+	}
+	http.Redirect(w, r, "/items", http.StatusFound)
+	return
+}
+
+// ProductHandler shows the variant matrix of a product
+func ProductHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	productID, err1 := strconv.Atoi(mux.Vars(r)["productID"])
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	matrix, err2 := authManager.FindVariantMatrix(session.id, uint(productID))
+	if err2 != nil {
+		NotFoundHandler(w, r)
+		return
+	}
+	page := ProductPage{Matrix: matrix}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(&w, r, "error", r.URL.Path)
+	items, err3 := authManager.ListAllItems(session.id)
+	if err3 != nil {
+		http.Error(w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, item := range items {
+		if item.ProductID == nil {
+			page.StandaloneItems = append(page.StandaloneItems, item)
+		}
+	}
+	err4 := templates.ExecuteTemplate(w, "product.html", page)
+	if err4 != nil {
+		http.Error(w, err4.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+// AddVariantHandler makes an existing item a variant of the product, reading a value for each dimension
+func AddVariantHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	productIDStr := mux.Vars(r)["productID"]
+	productID, err1 := strconv.Atoi(productIDStr)
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	path := "/product/" + productIDStr
+	itemID, err2 := strconv.Atoi(r.FormValue("itemID"))
+	if err2 != nil {
+		setFlashMessage(&w, "error", "choose the item to add as a variant", path)
+		http.Redirect(w, r, path, http.StatusFound)
+		return
+	}
+	product, err3 := authManager.FindProductByID(session.id, uint(productID))
+	if err3 != nil {
+		NotFoundHandler(w, r)
+		return
+	}
+	values := make(map[string]string)
+	for _, dimension := range product.DimensionList() {
+		values[dimension] = r.FormValue("dimension_" + dimension)
+	}
+	err4 := authManager.AddVariant(session.id, uint(productID), uint(itemID), values)
+	if err4 != nil {
+		setFlashMessage(&w, "error", err4.Error(), path)
+	}
+	http.Redirect(w, r, path, http.StatusFound)
+	return
+}
+
+// RemoveVariantHandler turns a variant of the product back into a standalone item
+func RemoveVariantHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	vars := mux.Vars(r)
+	path := "/product/" + vars["productID"]
+	itemID, err1 := strconv.Atoi(vars["itemID"])
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	err2 := authManager.RemoveVariant(session.id, uint(itemID))
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), path)
+	}
+	http.Redirect(w, r, path, http.StatusFound)
+	return
+}
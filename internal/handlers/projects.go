@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// number of days covered by the project report when no dates are given
+const projectReportDays = 30
+
+// ProjectsPage represents the page obtained by calling /projects
+type ProjectsPage struct {
+	Page
+	Projects []model.Project
+}
+
+// ProjectReportPage represents the page obtained by calling /reports/projects
+type ProjectReportPage struct {
+	Page
+	Report model.ProjectReport
+}
+
+// ProjectsHandler lists the projects on GET and creates a new one on POST
+func ProjectsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		{
+			getProjects(&w, r)
+			return
+		}
+	case http.MethodPost:
+		{
+			postProjects(&w, r)
+			return
+		}
+	default:
+		{
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+}
+
+func getProjects(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := ProjectsPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	projects, err1 := authManager.ListProjects(session.id)
+	if err1 != nil {
+		http.Error(*w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Projects = projects
+	err2 := templates.ExecuteTemplate(*w, "projects.html", page)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+func postProjects(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	err := authManager.CreateProject(session.id, r.FormValue("code"), r.FormValue("name"))
+	if err != nil {
+		setFlashMessage(w, "error", err.Error(), "/projects")
+	}
+	http.Redirect(*w, r, "/projects", http.StatusFound)
+	return
+}
+
+// CloseProjectHandler closes a project, or reopens it when closed is false
+func CloseProjectHandler(closed bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := getSession(&w, r)
+		if !ok {
+			http.Error(w, "no session found", http.StatusInternalServerError)
+			return
+		}
+		projectID, err1 := strconv.Atoi(mux.Vars(r)["projectID"])
+		if err1 != nil {
+			http.Error(w, err1.Error(), http.StatusInternalServerError)
+			return
+		}
+		err2 := authManager.SetProjectClosed(session.id, uint(projectID), closed)
+		if err2 != nil {
+			setFlashMessage(&w, "error", err2.Error(), "/projects")
+		}
+		http.Redirect(w, r, "/projects", http.StatusFound)
+		return
+	}
+}
+
+// ProjectReportHandler shows the consumptions charged to each project between the from and to dates, the last 30
+// days by default
+func ProjectReportHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := ProjectReportPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(&w, r, "error", r.URL.Path)
+	from, to, err1 := parseReportPeriod(r, projectReportDays)
+	if err1 != nil {
+		setFlashMessage(&w, "error", err1.Error(), "/reports/projects")
+		http.Redirect(w, r, "/reports/projects", http.StatusFound)
+		return
+	}
+	report, err2 := authManager.FindProjectConsumption(session.id, from, to)
+	if err2 != nil {
+		http.Error(w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Report = report
+	err3 := templates.ExecuteTemplate(w, "project_report.html", page)
+	if err3 != nil {
+		http.Error(w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// PutawayPage represents the page obtained by calling /putaway
+type PutawayPage struct {
+	Page
+	Items       []model.Item
+	Warehouses  []model.Warehouse
+	ItemID      uint
+	Quantity    int
+	Strategy    model.PutawayStrategy
+	Allocations []model.PutawayAllocation
+}
+
+// PutawayHandler proposes how to split a supply across the warehouses on GET and applies the split on POST
+func PutawayHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		{
+			getPutaway(&w, r)
+			return
+		}
+	case http.MethodPost:
+		{
+			postPutaway(&w, r)
+			return
+		}
+	default:
+		{
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+}
+
+func getPutaway(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := PutawayPage{Strategy: model.ConsolidateStrategy}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	items, err1 := authManager.ListAllItems(session.id)
+	if err1 != nil {
+		http.Error(*w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	warehouses, err2 := authManager.ListAllWarehouses(session.id)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Items = items
+	page.Warehouses = warehouses
+	itemID, quantity, strategy, err3 := parsePutawayRequest(r)
+	if strategy != "" {
+		page.Strategy = strategy
+	}
+	if itemID != 0 {
+		page.ItemID = itemID
+	}
+	page.Quantity = quantity
+	if err3 == nil && quantity > 0 {
+		page.Allocations, err3 = authManager.PlanPutaway(session.id, itemID, quantity, page.Strategy)
+	}
+	if err3 != nil {
+		// the request is shown again together with the reason why it can't be satisfied
+		page.APPError += err3.Error()
+	}
+	err4 := templates.ExecuteTemplate(*w, "putaway.html", page)
+	if err4 != nil {
+		http.Error(*w, err4.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+func postPutaway(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	itemID, quantity, strategy, err1 := parsePutawayRequest(r)
+	if err1 == nil {
+		_, err1 = authManager.ApplyPutaway(session.id, itemID, quantity, strategy)
+	}
+	if err1 != nil {
+		setFlashMessage(w, "error", err1.Error(), "/putaway")
+		http.Redirect(*w, r, "/putaway", http.StatusFound)
+		return
+	}
+	http.Redirect(*w, r, "/item/"+strconv.Itoa(int(itemID)), http.StatusFound)
+	return
+}
+
+// parsePutawayRequest reads the item, the quantity and the strategy of a putaway. The quantity is 0 when missing,
+// so that the page can be opened with just the item chosen.
+func parsePutawayRequest(r *http.Request) (uint, int, model.PutawayStrategy, error) {
+	strategy := model.PutawayStrategy(r.FormValue("strategy"))
+	if r.FormValue("itemID") == "" {
+		return 0, 0, strategy, nil
+	}
+	itemID, err1 := strconv.Atoi(r.FormValue("itemID"))
+	if err1 != nil || itemID <= 0 {
+		return 0, 0, strategy, errors.New("invalid item: " + r.FormValue("itemID"))
+	}
+	if r.FormValue("quantity") == "" {
+		return uint(itemID), 0, strategy, nil
+	}
+	quantity, err2 := strconv.Atoi(r.FormValue("quantity"))
+	if err2 != nil {
+		return uint(itemID), 0, strategy, errors.New("invalid quantity: " + r.FormValue("quantity"))
+	}
+	return uint(itemID), quantity, strategy, nil
+}
+
+// EditWarehousePriorityHandler changes the putaway priority of a warehouse
+func EditWarehousePriorityHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	warehouseIDStr := mux.Vars(r)["warehouseID"]
+	warehouseID, err1 := strconv.Atoi(warehouseIDStr)
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	priority, err2 := strconv.Atoi(r.FormValue("priority"))
+	if err2 != nil {
+		err2 = errors.New("invalid priority: " + r.FormValue("priority"))
+	} else {
+		err2 = authManager.UpdateWarehousePutawayPriority(session.id, uint(warehouseID), priority)
+	}
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), "/warehouse/"+warehouseIDStr)
+	}
+	http.Redirect(w, r, "/warehouse/"+warehouseIDStr, http.StatusFound)
+	return
+}
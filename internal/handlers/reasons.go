@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// number of days covered by the shrinkage report when no dates are given
+const shrinkageDays = 30
+
+// ReasonsPage represents the page obtained by calling /reasons
+type ReasonsPage struct {
+	Page
+	Reasons []model.ReasonCode
+}
+
+// ShrinkagePage represents the page obtained by calling /reports/shrinkage
+type ShrinkagePage struct {
+	Page
+	Report model.ShrinkageReport
+}
+
+// ReasonsHandler lists the reason codes on GET and creates a new one on POST
+func ReasonsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		{
+			getReasons(&w, r)
+			return
+		}
+	case http.MethodPost:
+		{
+			postReasons(&w, r)
+			return
+		}
+	default:
+		{
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+}
+
+func getReasons(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := ReasonsPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	reasons, err1 := authManager.ListReasonCodes(session.id)
+	if err1 != nil {
+		http.Error(*w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Reasons = reasons
+	err2 := templates.ExecuteTemplate(*w, "reasons.html", page)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+func postReasons(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	err := authManager.CreateReasonCode(session.id, r.FormValue("code"), strings.TrimSpace(r.FormValue("description")),
+		r.FormValue("writeOff") == "on")
+	if err != nil {
+		setFlashMessage(w, "error", err.Error(), "/reasons")
+	}
+	http.Redirect(*w, r, "/reasons", http.StatusFound)
+	return
+}
+
+// DeleteReasonHandler removes a reason code
+func DeleteReasonHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	reasonID, err1 := strconv.Atoi(mux.Vars(r)["reasonID"])
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	err2 := authManager.DeleteReasonCode(session.id, uint(reasonID))
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), "/reasons")
+	}
+	http.Redirect(w, r, "/reasons", http.StatusFound)
+	return
+}
+
+// WriteOffItemsHandler writes off lost, stolen, expired or scrapped items through the /item/{id} page
+func WriteOffItemsHandler(w http.ResponseWriter, r *http.Request) {
+	path := "/item/" + mux.Vars(r)["itemID"]
+	session, amount, itemID := collectData(&w, r)
+	warehouseID, err1 := strconv.Atoi(r.FormValue("warehouseID"))
+	if err1 != nil {
+		setFlashMessage(&w, "error", "invalid warehouse: "+r.FormValue("warehouseID"), path)
+		http.Redirect(w, r, path, http.StatusFound)
+		return
+	}
+	err2 := authManager.WriteOffItems(session.id, uint(itemID), uint(warehouseID), amount, formStockStatus(r), formMovementNote(r))
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), path)
+	}
+	http.Redirect(w, r, path, http.StatusFound)
+	return
+}
+
+// ShrinkageHandler shows the stock written off between the from and to dates, the last 30 days by default
+func ShrinkageHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := ShrinkagePage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(&w, r, "error", r.URL.Path)
+	from, to, err1 := parseReportPeriod(r, shrinkageDays)
+	if err1 != nil {
+		setFlashMessage(&w, "error", err1.Error(), "/reports/shrinkage")
+		http.Redirect(w, r, "/reports/shrinkage", http.StatusFound)
+		return
+	}
+	report, err2 := authManager.FindShrinkage(session.id, from, to)
+	if err2 != nil {
+		http.Error(w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Report = report
+	err3 := templates.ExecuteTemplate(w, "shrinkage.html", page)
+	if err3 != nil {
+		http.Error(w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+// parseReportPeriod reads the from and to dates of a report, which by default covers the last days up to now
+func parseReportPeriod(r *http.Request, days int) (time.Time, time.Time, error) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -days)
+	var err1, err2 error
+	if r.URL.Query().Get("from") != "" {
+		// a date alone starts the report at the beginning of that day
+		from, err1 = time.ParseInLocation(time.DateOnly, r.URL.Query().Get("from"), time.Local)
+		if err1 != nil {
+			from, err1 = parseInventoryTime(r.URL.Query().Get("from"))
+		}
+	}
+	if r.URL.Query().Get("to") != "" {
+		to, err2 = parseInventoryTime(r.URL.Query().Get("to"))
+	}
+	return from, to, errors.Join(err1, err2)
+}
+
+// formMovementNote reads the optional reason and note fields of the stock operation forms
+func formMovementNote(r *http.Request) model.MovementNote {
+	return model.MovementNote{Reason: strings.TrimSpace(r.FormValue("reason")), Note: strings.TrimSpace(r.FormValue("note"))}
+}
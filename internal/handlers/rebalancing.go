@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// RebalancingPage represents the page obtained by calling /rebalancing
+type RebalancingPage struct {
+	Page
+	Items      []model.Item
+	Warehouses []model.Warehouse
+	Levels     []model.StockLevelEntry
+	Moves      []model.RebalancingMove
+}
+
+// RebalancingHandler shows the stock levels and the proposed plan on GET and executes the accepted moves on POST
+func RebalancingHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		{
+			getRebalancing(&w, r)
+			return
+		}
+	case http.MethodPost:
+		{
+			postRebalancing(&w, r)
+			return
+		}
+	default:
+		{
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+}
+
+func getRebalancing(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := RebalancingPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	items, err1 := authManager.ListAllItems(session.id)
+	if err1 != nil {
+		http.Error(*w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	warehouses, err2 := authManager.ListAllWarehouses(session.id)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	levels, err3 := authManager.ListStockLevels(session.id)
+	if err3 != nil {
+		http.Error(*w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	moves, err4 := authManager.PlanRebalancing(session.id)
+	if err4 != nil {
+		http.Error(*w, err4.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Items = items
+	page.Warehouses = warehouses
+	page.Levels = levels
+	page.Moves = moves
+	err5 := templates.ExecuteTemplate(*w, "rebalancing.html", page)
+	if err5 != nil {
+		http.Error(*w, err5.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+// executes the accepted lines of the reviewed plan as a single batch of transfers
+func postRebalancing(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	lines, err1 := parseRebalancingLines(r)
+	if err1 == nil {
+		err1 = authManager.ApplyStockBatch(session.id, lines)
+	}
+	if err1 != nil {
+		setFlashMessage(w, "error", err1.Error(), "/rebalancing")
+	}
+	http.Redirect(*w, r, "/rebalancing", http.StatusFound)
+	return
+}
+
+// parseRebalancingLines converts the accepted rows of the plan into transfer lines.
+// Every row carries its item, source, destination and quantity, the "accept" field lists the indexes of the accepted rows.
+func parseRebalancingLines(r *http.Request) ([]model.StockLine, error) {
+	// FormValue parses the form, filling r.Form with the repeated fields of the plan
+	_ = r.FormValue("accept")
+	lines := make([]model.StockLine, 0)
+	for _, accepted := range r.Form["accept"] {
+		i, err1 := strconv.Atoi(accepted)
+		if err1 != nil {
+			return nil, errors.New("invalid plan line: " + accepted)
+		}
+		itemID, err2 := strconv.Atoi(formValueAt(r, "moveItem", i))
+		sourceID, err3 := strconv.Atoi(formValueAt(r, "moveSource", i))
+		destinationID, err4 := strconv.Atoi(formValueAt(r, "moveDestination", i))
+		quantity, err5 := strconv.Atoi(formValueAt(r, "moveQuantity", i))
+		if errors.Join(err2, err3, err4, err5) != nil {
+			return nil, errors.New("invalid plan line " + strconv.Itoa(i+1))
+		}
+		lines = append(lines, model.StockLine{Operation: model.TransferOperation, ItemID: uint(itemID),
+			WarehouseID: uint(sourceID), DestinationWarehouseID: uint(destinationID), Quantity: quantity})
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("no moves of the plan were accepted")
+	}
+	return lines, nil
+}
+
+// SetStockLevelHandler sets the minimum and maximum levels of an item in a warehouse
+func SetStockLevelHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	itemID, err1 := strconv.Atoi(r.FormValue("itemID"))
+	warehouseID, err2 := strconv.Atoi(r.FormValue("warehouseID"))
+	minLevel, err3 := strconv.Atoi(r.FormValue("min"))
+	maxLevel, err4 := strconv.Atoi(r.FormValue("max"))
+	err5 := errors.Join(err1, err2, err3, err4)
+	if err5 == nil {
+		err5 = authManager.SetStockLevel(session.id, uint(itemID), uint(warehouseID), minLevel, maxLevel)
+	}
+	if err5 != nil {
+		setFlashMessage(&w, "error", err5.Error(), "/rebalancing")
+	}
+	http.Redirect(w, r, "/rebalancing", http.StatusFound)
+	return
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// ReplenishmentPage represents the page obtained by calling /reports/replenishment
+type ReplenishmentPage struct {
+	Page
+	Parameters  model.ReplenishmentParameters
+	Suggestions []model.ReplenishmentSuggestion
+}
+
+// ReplenishmentHandler shows the demand forecast and the reorder suggestions, as a page or as CSV when format=csv
+func ReplenishmentHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := ReplenishmentPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(&w, r, "error", r.URL.Path)
+	parameters, err1 := parseReplenishmentParameters(r)
+	if err1 != nil {
+		setFlashMessage(&w, "error", err1.Error(), "/reports/replenishment")
+		http.Redirect(w, r, "/reports/replenishment", http.StatusFound)
+		return
+	}
+	suggestions, err2 := authManager.FindReplenishment(session.id, parameters)
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), "/reports/replenishment")
+		http.Redirect(w, r, "/reports/replenishment", http.StatusFound)
+		return
+	}
+	if r.URL.Query().Get("format") == "csv" {
+		writeReplenishmentCSV(w, suggestions)
+		return
+	}
+	page.Parameters = parameters
+	page.Suggestions = suggestions
+	err3 := templates.ExecuteTemplate(w, "replenishment.html", page)
+	if err3 != nil {
+		http.Error(w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+// parseReplenishmentParameters reads the parameters from the query string, using the defaults for the missing ones
+func parseReplenishmentParameters(r *http.Request) (model.ReplenishmentParameters, error) {
+	parameters := model.DefaultReplenishmentParameters()
+	query := r.URL.Query()
+	integers := map[string]*int{
+		"historyDays":       &parameters.HistoryDays,
+		"movingAverageDays": &parameters.MovingAverageDays,
+		"leadTimeDays":      &parameters.LeadTimeDays,
+		"safetyStockDays":   &parameters.SafetyStockDays,
+		"reviewPeriodDays":  &parameters.ReviewPeriodDays,
+	}
+	for name, field := range integers {
+		if query.Get(name) == "" {
+			continue
+		}
+		value, err := strconv.Atoi(query.Get(name))
+		if err != nil {
+			return parameters, errors.New("invalid value for " + name + ": " + query.Get(name))
+		}
+		*field = value
+	}
+	if query.Get("smoothingFactor") != "" {
+		value, err := strconv.ParseFloat(query.Get("smoothingFactor"), 64)
+		if err != nil {
+			return parameters, errors.New("invalid value for smoothingFactor: " + query.Get("smoothingFactor"))
+		}
+		parameters.SmoothingFactor = value
+	}
+	return parameters, nil
+}
+
+// writeReplenishmentCSV sends the suggestions as a CSV file
+func writeReplenishmentCSV(w http.ResponseWriter, suggestions []model.ReplenishmentSuggestion) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"replenishment.csv\"")
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"item ID", "item", "stock", "consumed", "moving average", "exponential smoothing",
+		"days of cover", "safety stock", "reorder point", "suggested quantity", "supplier", "part number",
+		"lead time", "unit price"})
+	for _, suggestion := range suggestions {
+		daysOfCover := ""
+		if suggestion.DaysOfCover >= 0 {
+			daysOfCover = strconv.FormatFloat(suggestion.DaysOfCover, 'f', 1, 64)
+		}
+		_ = writer.Write([]string{
+			strconv.Itoa(int(suggestion.ItemID)),
+			suggestion.ItemName,
+			strconv.Itoa(suggestion.Quantity),
+			strconv.Itoa(suggestion.Consumed),
+			strconv.FormatFloat(suggestion.MovingAverage, 'f', 2, 64),
+			strconv.FormatFloat(suggestion.Smoothed, 'f', 2, 64),
+			daysOfCover,
+			strconv.Itoa(suggestion.SafetyStock),
+			strconv.Itoa(suggestion.ReorderPoint),
+			strconv.Itoa(suggestion.SuggestedQuantity),
+			suggestion.SupplierName,
+			suggestion.PartNumber,
+			strconv.Itoa(suggestion.LeadTimeDays),
+			strconv.FormatFloat(suggestion.UnitPrice, 'f', 2, 64),
+		})
+	}
+	writer.Flush()
+}
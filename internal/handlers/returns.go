@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ReturnsPage represents the page obtained by calling /returns
+type ReturnsPage struct {
+	Page
+	Items         []model.Item
+	Warehouses    []model.Warehouse
+	Open          []model.ReturnEntry
+	Completed     []model.ReturnEntry
+	StockStatuses []model.StockStatus
+	Dispositions  []model.ReturnDisposition
+}
+
+// ReturnsHandler lists the return authorisations on GET and authorizes a new return on POST
+func ReturnsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		{
+			getReturns(&w, r)
+			return
+		}
+	case http.MethodPost:
+		{
+			postReturns(&w, r)
+			return
+		}
+	default:
+		{
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+}
+
+func getReturns(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := ReturnsPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	items, err1 := authManager.ListAllItems(session.id)
+	if err1 != nil {
+		http.Error(*w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	warehouses, err2 := authManager.ListAllWarehouses(session.id)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	open, err3 := authManager.ListReturns(session.id, model.AuthorizedReturn)
+	if err3 != nil {
+		http.Error(*w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	completed, err4 := authManager.ListReturns(session.id, model.CompletedReturn)
+	if err4 != nil {
+		http.Error(*w, err4.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Items = items
+	page.Warehouses = warehouses
+	page.Open = open
+	page.Completed = completed
+	page.StockStatuses = model.StockStatuses
+	page.Dispositions = []model.ReturnDisposition{model.RestockDisposition, model.QuarantineDisposition, model.ScrapDisposition}
+	err5 := templates.ExecuteTemplate(*w, "returns.html", page)
+	if err5 != nil {
+		http.Error(*w, err5.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+func postReturns(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	itemID, err1 := strconv.Atoi(r.FormValue("itemID"))
+	quantity, err2 := strconv.Atoi(r.FormValue("quantity"))
+	movementID, err3 := parseOptionalID(strings.TrimSpace(r.FormValue("movementID")))
+	err4 := errors.Join(err1, err2, err3)
+	if err4 == nil {
+		_, err4 = authManager.AuthorizeReturn(session.id, model.ReturnKind(r.FormValue("kind")), uint(itemID), quantity,
+			strings.TrimSpace(r.FormValue("reason")), strings.TrimSpace(r.FormValue("orderReference")), movementID)
+	}
+	if err4 != nil {
+		setFlashMessage(w, "error", err4.Error(), "/returns")
+	}
+	http.Redirect(*w, r, "/returns", http.StatusFound)
+	return
+}
+
+// ReceiveReturnHandler receives the goods of a customer return into a warehouse
+func ReceiveReturnHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	returnID, err1 := strconv.Atoi(mux.Vars(r)["returnID"])
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	warehouseID, err2 := strconv.Atoi(r.FormValue("warehouseID"))
+	quantity, err3 := strconv.Atoi(r.FormValue("quantity"))
+	err4 := errors.Join(err2, err3)
+	if err4 == nil {
+		err4 = authManager.ReceiveReturn(session.id, uint(returnID), uint(warehouseID), quantity,
+			model.ReturnDisposition(r.FormValue("disposition")))
+	}
+	if err4 != nil {
+		setFlashMessage(&w, "error", err4.Error(), "/returns")
+	}
+	http.Redirect(w, r, "/returns", http.StatusFound)
+	return
+}
+
+// ShipSupplierReturnHandler sends the goods of a supplier return back from a warehouse
+func ShipSupplierReturnHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	returnID, err1 := strconv.Atoi(mux.Vars(r)["returnID"])
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	warehouseID, err2 := strconv.Atoi(r.FormValue("warehouseID"))
+	if err2 == nil {
+		err2 = authManager.ShipSupplierReturn(session.id, uint(returnID), uint(warehouseID), formStockStatus(r))
+	}
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), "/returns")
+	}
+	http.Redirect(w, r, "/returns", http.StatusFound)
+	return
+}
@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// number of empty rows offered by the grid of the /stock/batch page
+const stockBatchGridRows = 8
+
+// StockBatchPage represents the page obtained by calling /stock/batch
+type StockBatchPage struct {
+	Page
+	Items      []model.Item
+	Warehouses []model.Warehouse
+	GridRows   []int
+	// normalized text of the previewed lines, submitted again when the batch is committed
+	Lines   string
+	Preview []StockBatchRow
+	Valid   bool
+}
+
+// StockBatchRow is a previewed stock line with names in place of IDs
+type StockBatchRow struct {
+	Number          int
+	Operation       string
+	ItemName        string
+	WarehouseName   string
+	DestinationName string
+	Quantity        int
+	Error           string
+}
+
+// StockBatchHandler operates the /stock/batch page where many stock lines are validated and applied at once
+func StockBatchHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		{
+			getStockBatch(&w, r)
+			return
+		}
+	case http.MethodPost:
+		{
+			postStockBatch(&w, r)
+			return
+		}
+	default:
+		{
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+}
+
+// shows the grid and the text area used to enter a batch
+func getStockBatch(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page, err := fillStockBatchPage(w, r, session)
+	if err != nil {
+		http.Error(*w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	err2 := templates.ExecuteTemplate(*w, "stock_batch.html", page)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+// previews the submitted batch or, when the commit action is chosen, applies it
+func postStockBatch(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	lines, err1 := parseStockLines(r, session)
+	if err1 != nil {
+		setFlashMessage(w, "error", err1.Error(), "/stock/batch")
+		http.Redirect(*w, r, "/stock/batch", http.StatusFound)
+		return
+	}
+	if r.FormValue("action") == "commit" {
+		err2 := authManager.ApplyStockBatch(session.id, lines)
+		if err2 != nil {
+			setFlashMessage(w, "error", err2.Error(), "/stock/batch")
+			http.Redirect(*w, r, "/stock/batch", http.StatusFound)
+			return
+		}
+		http.Redirect(*w, r, "/items", http.StatusFound)
+		return
+	}
+	results, err3 := authManager.PreviewStockBatch(session.id, lines)
+	if err3 != nil {
+		setFlashMessage(w, "error", err3.Error(), "/stock/batch")
+		http.Redirect(*w, r, "/stock/batch", http.StatusFound)
+		return
+	}
+	page, err4 := fillStockBatchPage(w, r, session)
+	if err4 != nil {
+		http.Error(*w, err4.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Lines = formatStockLines(lines)
+	page.Preview, page.Valid = describeStockLineResults(results, page.Items, page.Warehouses)
+	err5 := templates.ExecuteTemplate(*w, "stock_batch.html", page)
+	if err5 != nil {
+		http.Error(*w, err5.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+func fillStockBatchPage(w *http.ResponseWriter, r *http.Request, session userSession) (StockBatchPage, error) {
+	page := StockBatchPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	items, err1 := authManager.ListAllItems(session.id)
+	if err1 != nil {
+		return page, err1
+	}
+	warehouses, err2 := authManager.ListAllWarehouses(session.id)
+	if err2 != nil {
+		return page, err2
+	}
+	page.Items = items
+	page.Warehouses = warehouses
+	page.GridRows = make([]int, stockBatchGridRows)
+	for i := range page.GridRows {
+		page.GridRows[i] = i + 1
+	}
+	return page, nil
+}
+
+// parseStockLines collects the rows of the grid followed by the rows pasted in the text area.
+// A pasted row contains operation, item, warehouse, quantity and, for transfers, the destination warehouse
+// separated by the separator chosen in the form. Items and warehouses may be referenced by name or by ID, written as #ID.
+func parseStockLines(r *http.Request, session userSession) ([]model.StockLine, error) {
+	// FormValue parses the form, filling r.Form with the repeated fields of the grid
+	pasted := r.FormValue("lines")
+	rows := make([][]string, 0)
+	operations := r.Form["gridOperation"]
+	for i := range operations {
+		row := []string{operations[i], formValueAt(r, "gridItem", i), formValueAt(r, "gridWarehouse", i),
+			formValueAt(r, "gridQuantity", i)}
+		if row[1] == "" && row[2] == "" && row[3] == "" {
+			continue
+		}
+		if destination := formValueAt(r, "gridDestination", i); destination != "" {
+			row = append(row, destination)
+		}
+		rows = append(rows, row)
+	}
+	pastedRows, err1 := splitPastedRows(pasted, r.FormValue("separator"))
+	if err1 != nil {
+		return nil, err1
+	}
+	rows = append(rows, pastedRows...)
+	if len(rows) == 0 {
+		return nil, errors.New("no stock lines were entered")
+	}
+	lines := make([]model.StockLine, 0, len(rows))
+	for i, row := range rows {
+		line, err2 := parseStockRow(row, session)
+		if err2 != nil {
+			return nil, errors.New("line " + strconv.Itoa(i+1) + ": " + err2.Error())
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// splitPastedRows splits the pasted text into rows of fields using the separator chosen in the form: "tab", ";" or ",".
+// Fields containing the separator can be quoted. Every row with the wrong number of columns is reported at once, since
+// it usually means that the separator doesn't match the pasted text.
+func splitPastedRows(pasted string, separator string) ([][]string, error) {
+	if strings.TrimSpace(pasted) == "" {
+		return nil, nil
+	}
+	reader := csv.NewReader(strings.NewReader(pasted))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+	switch separator {
+	case "", "tab":
+		reader.Comma = '\t'
+	case ";", ",":
+		reader.Comma = rune(separator[0])
+	default:
+		return nil, errors.New("unknown separator: " + separator)
+	}
+	rows := make([][]string, 0)
+	mismatched := make([]string, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("invalid pasted lines: " + err.Error())
+		}
+		if strings.EqualFold(strings.TrimSpace(record[0]), "operation") {
+			continue
+		}
+		columns := 4
+		if model.StockOperation(strings.ToLower(strings.TrimSpace(record[0]))) == model.TransferOperation {
+			columns = 5
+		}
+		if len(record) != columns {
+			line, _ := reader.FieldPos(0)
+			mismatched = append(mismatched, strconv.Itoa(line))
+		}
+		rows = append(rows, record)
+	}
+	if len(mismatched) > 0 {
+		return nil, errors.New("pasted rows with the wrong number of columns for the chosen separator: " +
+			strings.Join(mismatched, ", "))
+	}
+	return rows, nil
+}
+
+// converts a single row of fields into a stock line
+func parseStockRow(row []string, session userSession) (model.StockLine, error) {
+	var line model.StockLine
+	for i := range row {
+		row[i] = strings.TrimSpace(row[i])
+	}
+	line.Operation = model.StockOperation(strings.ToLower(row[0]))
+	if line.Operation == model.TransferOperation && len(row) != 5 {
+		return line, errors.New("expected operation, item, warehouse, quantity and destination, got " +
+			strconv.Itoa(len(row)) + " fields")
+	}
+	if line.Operation != model.TransferOperation && len(row) != 4 {
+		return line, errors.New("expected operation, item, warehouse and quantity, got " + strconv.Itoa(len(row)) + " fields")
+	}
+	itemID, err1 := resolveItemID(session, row[1])
+	if err1 != nil {
+		return line, err1
+	}
+	warehouseID, err2 := resolveWarehouseID(session, row[2])
+	if err2 != nil {
+		return line, err2
+	}
+	quantity, err3 := strconv.Atoi(row[3])
+	if err3 != nil {
+		return line, errors.New("invalid quantity: " + row[3])
+	}
+	line.ItemID = itemID
+	line.WarehouseID = warehouseID
+	line.Quantity = quantity
+	if line.Operation == model.TransferOperation {
+		if row[4] == "" {
+			return line, errors.New("transfers need a destination warehouse")
+		}
+		destinationID, err4 := resolveWarehouseID(session, row[4])
+		if err4 != nil {
+			return line, err4
+		}
+		line.DestinationWarehouseID = destinationID
+	}
+	return line, nil
+}
+
+// resolveItemID accepts the name of an item or its ID written as #ID. A plain number which isn't the name of an item
+// is read as an ID too.
+func resolveItemID(session userSession, reference string) (uint, error) {
+	if strings.HasPrefix(reference, "#") {
+		return parseReferenceID(reference)
+	}
+	items, err1 := authManager.FindItemByName(session.id, reference)
+	if err1 != nil {
+		return 0, err1
+	}
+	if len(items) > 0 {
+		return items[0].ID, nil
+	}
+	itemID, err2 := strconv.Atoi(reference)
+	if err2 != nil || itemID <= 0 {
+		return 0, errors.New("item not found: " + reference)
+	}
+	return uint(itemID), nil
+}
+
+// resolveWarehouseID accepts the name of a warehouse or its ID written as #ID. A plain number which isn't the name
+// of a warehouse is read as an ID too.
+func resolveWarehouseID(session userSession, reference string) (uint, error) {
+	if strings.HasPrefix(reference, "#") {
+		return parseReferenceID(reference)
+	}
+	warehouses, err1 := authManager.FindWarehouseByName(session.id, reference)
+	if err1 != nil {
+		return 0, err1
+	}
+	if len(warehouses) > 0 {
+		return warehouses[0].ID, nil
+	}
+	warehouseID, err2 := strconv.Atoi(reference)
+	if err2 != nil || warehouseID <= 0 {
+		return 0, errors.New("warehouse not found: " + reference)
+	}
+	return uint(warehouseID), nil
+}
+
+// parseReferenceID reads an ID written as #ID
+func parseReferenceID(reference string) (uint, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(reference, "#"))
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid ID: " + reference)
+	}
+	return uint(id), nil
+}
+
+// formatStockLines writes the lines back as tab separated rows using #ID references
+func formatStockLines(lines []model.StockLine) string {
+	var builder strings.Builder
+	for _, line := range lines {
+		builder.WriteString(string(line.Operation) + "\t#" + strconv.Itoa(int(line.ItemID)) + "\t#" +
+			strconv.Itoa(int(line.WarehouseID)) + "\t" + strconv.Itoa(line.Quantity))
+		if line.Operation == model.TransferOperation {
+			builder.WriteString("\t#" + strconv.Itoa(int(line.DestinationWarehouseID)))
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// describeStockLineResults replaces IDs with names and reports whether every line is valid
+func describeStockLineResults(results []model.StockLineResult, items []model.Item, warehouses []model.Warehouse) ([]StockBatchRow, bool) {
+	itemNames := make(map[uint]string)
+	for _, item := range items {
+		itemNames[item.ID] = item.Name
+	}
+	warehouseNames := make(map[uint]string)
+	for _, warehouse := range warehouses {
+		warehouseNames[warehouse.ID] = warehouse.Name
+	}
+	valid := true
+	rows := make([]StockBatchRow, 0, len(results))
+	for i, result := range results {
+		if result.Error != "" {
+			valid = false
+		}
+		rows = append(rows, StockBatchRow{
+			Number:          i + 1,
+			Operation:       string(result.Line.Operation),
+			ItemName:        itemNames[result.Line.ItemID],
+			WarehouseName:   warehouseNames[result.Line.WarehouseID],
+			DestinationName: warehouseNames[result.Line.DestinationWarehouseID],
+			Quantity:        result.Line.Quantity,
+			Error:           result.Error,
+		})
+	}
+	return rows, valid
+}
+
+// formValueAt returns the i-th value of a repeated form field or an empty string
+func formValueAt(r *http.Request, key string, i int) string {
+	values := r.Form[key]
+	if i < len(values) {
+		return strings.TrimSpace(values[i])
+	}
+	return ""
+}
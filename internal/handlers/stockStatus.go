@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// ChangeStockStatusHandler moves items stored in a warehouse from a status to another through the /item/{id} page,
+// for example releasing quarantined items once they pass the quality control
+func ChangeStockStatusHandler(w http.ResponseWriter, r *http.Request) {
+	path := "/item/" + mux.Vars(r)["itemID"]
+	session, amount, itemID := collectData(&w, r)
+	warehouseID, err1 := strconv.Atoi(r.FormValue("warehouseID"))
+	if err1 != nil {
+		setFlashMessage(&w, "error", "invalid warehouse: "+r.FormValue("warehouseID"), path)
+		http.Redirect(w, r, path, http.StatusFound)
+		return
+	}
+	from := model.StockStatus(r.FormValue("from"))
+	to := model.StockStatus(r.FormValue("to"))
+	err2 := authManager.ChangeStockStatus(session.id, uint(itemID), uint(warehouseID), amount, from, to)
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), path)
+	}
+	http.Redirect(w, r, path, http.StatusFound)
+	return
+}
+
+// formStockStatus reads the optional status field of the supply and consume forms, defaulting to available stock
+func formStockStatus(r *http.Request) model.StockStatus {
+	status := model.StockStatus(r.FormValue("status"))
+	if status == "" {
+		return model.AvailableStatus
+	}
+	return status
+}
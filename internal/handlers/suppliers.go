@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SuppliersPage represents the page obtained by calling /suppliers
+type SuppliersPage struct {
+	Page
+	Suppliers []model.Supplier
+	Catalog   []model.SupplierItemEntry
+}
+
+// SuppliersHandler lists the suppliers and their catalogs on GET and creates a new supplier on POST
+func SuppliersHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		{
+			getSuppliers(&w, r)
+			return
+		}
+	case http.MethodPost:
+		{
+			postSuppliers(&w, r)
+			return
+		}
+	default:
+		{
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+}
+
+func getSuppliers(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := SuppliersPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	suppliers, err1 := authManager.ListSuppliers(session.id)
+	if err1 != nil {
+		http.Error(*w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	catalog, err2 := authManager.ListSupplierItems(session.id, 0, 0)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Suppliers = suppliers
+	page.Catalog = catalog
+	err3 := templates.ExecuteTemplate(*w, "suppliers.html", page)
+	if err3 != nil {
+		http.Error(*w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+func postSuppliers(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	err := authManager.CreateSupplier(session.id, r.FormValue("supplierName"), strings.TrimSpace(r.FormValue("supplierContact")))
+	if err != nil {
+		setFlashMessage(w, "error", err.Error(), "/suppliers")
+	}
+	http.Redirect(*w, r, "/suppliers", http.StatusFound)
+	return
+}
+
+// DeleteSupplierHandler removes a supplier and its catalog
+func DeleteSupplierHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	supplierID, err1 := strconv.Atoi(mux.Vars(r)["supplierID"])
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	err2 := authManager.DeleteSupplier(session.id, uint(supplierID))
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), "/suppliers")
+	}
+	http.Redirect(w, r, "/suppliers", http.StatusFound)
+	return
+}
+
+// SetSupplierItemHandler adds the item of the page to the catalog of a supplier or changes its conditions
+func SetSupplierItemHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	itemIDStr := mux.Vars(r)["itemID"]
+	itemID, err1 := strconv.Atoi(itemIDStr)
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	supplierID, err2 := strconv.Atoi(r.FormValue("supplierID"))
+	unitPrice, err3 := strconv.ParseFloat(r.FormValue("unitPrice"), 64)
+	minimumOrderQuantity, err4 := strconv.Atoi(r.FormValue("minimumOrderQuantity"))
+	leadTimeDays, err5 := strconv.Atoi(r.FormValue("leadTimeDays"))
+	err6 := errors.Join(err2, err3, err4, err5)
+	if err6 != nil {
+		err6 = errors.New("choose a supplier and enter price, minimum order quantity and lead time")
+	} else {
+		err6 = authManager.SetSupplierItem(session.id, model.SupplierItem{
+			SupplierID:           uint(supplierID),
+			ItemID:               uint(itemID),
+			PartNumber:           r.FormValue("partNumber"),
+			UnitPrice:            unitPrice,
+			MinimumOrderQuantity: minimumOrderQuantity,
+			LeadTimeDays:         leadTimeDays,
+			Preferred:            r.FormValue("preferred") == "on",
+		})
+	}
+	if err6 != nil {
+		setFlashMessage(&w, "error", err6.Error(), "/item/"+itemIDStr)
+	}
+	http.Redirect(w, r, "/item/"+itemIDStr, http.StatusFound)
+	return
+}
+
+// RemoveSupplierItemHandler removes the item of the page from the catalog of a supplier
+func RemoveSupplierItemHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	itemIDStr := mux.Vars(r)["itemID"]
+	itemID, err1 := strconv.Atoi(itemIDStr)
+	supplierID, err2 := strconv.Atoi(mux.Vars(r)["supplierID"])
+	if err3 := errors.Join(err1, err2); err3 != nil {
+		http.Error(w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	err4 := authManager.RemoveSupplierItem(session.id, uint(supplierID), uint(itemID))
+	if err4 != nil {
+		setFlashMessage(&w, "error", err4.Error(), "/item/"+itemIDStr)
+	}
+	http.Redirect(w, r, "/item/"+itemIDStr, http.StatusFound)
+	return
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"WarehouseManager/internal/model"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// TransfersPage represents the page obtained by calling /transfers
+type TransfersPage struct {
+	Page
+	Items      []model.Item
+	Warehouses []model.Warehouse
+	Open       []model.ShipmentEntry
+	Received   []model.ShipmentEntry
+}
+
+// TransfersHandler lists the shipments on GET and dispatches a new shipment on POST
+func TransfersHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		{
+			getTransfers(&w, r)
+			return
+		}
+	case http.MethodPost:
+		{
+			postTransfers(&w, r)
+			return
+		}
+	default:
+		{
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+}
+
+func getTransfers(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	page := TransfersPage{}
+	page.LoggedIn = true
+	page.APPNtf = evaluateItems(session)
+	page.APPError = processFlashMessage(w, r, "error", r.URL.Path)
+	items, err1 := authManager.ListAllItems(session.id)
+	if err1 != nil {
+		http.Error(*w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	warehouses, err2 := authManager.ListAllWarehouses(session.id)
+	if err2 != nil {
+		http.Error(*w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	open, err3 := authManager.ListShipments(session.id, model.InTransitShipment)
+	if err3 != nil {
+		http.Error(*w, err3.Error(), http.StatusInternalServerError)
+		return
+	}
+	received, err4 := authManager.ListShipments(session.id, model.ReceivedShipment)
+	if err4 != nil {
+		http.Error(*w, err4.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Items = items
+	page.Warehouses = warehouses
+	page.Open = open
+	page.Received = received
+	err5 := templates.ExecuteTemplate(*w, "transfers.html", page)
+	if err5 != nil {
+		http.Error(*w, err5.Error(), http.StatusInternalServerError)
+		return
+	}
+	return
+}
+
+func postTransfers(w *http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(w, r)
+	if !ok {
+		http.Error(*w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	itemID, err1 := strconv.Atoi(r.FormValue("itemID"))
+	sourceID, err2 := strconv.Atoi(r.FormValue("sourceWarehouseID"))
+	destinationID, err3 := strconv.Atoi(r.FormValue("destinationWarehouseID"))
+	quantity, err4 := strconv.Atoi(r.FormValue("quantity"))
+	err5 := errors.Join(err1, err2, err3, err4)
+	if err5 == nil {
+		_, err5 = authManager.DispatchShipment(session.id, uint(itemID), uint(sourceID), quantity, uint(destinationID))
+	}
+	if err5 != nil {
+		setFlashMessage(w, "error", err5.Error(), "/transfers")
+	}
+	http.Redirect(*w, r, "/transfers", http.StatusFound)
+	return
+}
+
+// ReceiveShipmentHandler records the receipt of a shipment at its destination
+func ReceiveShipmentHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := getSession(&w, r)
+	if !ok {
+		http.Error(w, "no session found", http.StatusInternalServerError)
+		return
+	}
+	shipmentID, err1 := strconv.Atoi(mux.Vars(r)["shipmentID"])
+	if err1 != nil {
+		http.Error(w, err1.Error(), http.StatusInternalServerError)
+		return
+	}
+	receivedQuantity, err2 := strconv.Atoi(r.FormValue("receivedQuantity"))
+	if err2 != nil {
+		err2 = errors.New("invalid received quantity: " + r.FormValue("receivedQuantity"))
+	} else {
+		err2 = authManager.ReceiveShipment(session.id, uint(shipmentID), receivedQuantity)
+	}
+	if err2 != nil {
+		setFlashMessage(&w, "error", err2.Error(), "/transfers")
+	}
+	http.Redirect(w, r, "/transfers", http.StatusFound)
+	return
+}
@@ -0,0 +1,196 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"strconv"
+	"time"
+)
+
+// ApprovalStatus tells whether an approval request is still waiting for a decision
+type ApprovalStatus string
+
+const (
+	PendingApproval  ApprovalStatus = "pending"
+	ApprovedApproval ApprovalStatus = "approved"
+	RejectedApproval ApprovalStatus = "rejected"
+)
+
+// ApprovalRequest is a consumption or a transfer held back because it exceeds an approval threshold. It is executed
+// when a manager approves it.
+type ApprovalRequest struct {
+	ID        uint `gorm:"primaryKey;<-:create;autoIncrement"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Kind is ConsumeMovement or TransferMovement
+	Kind        MovementKind `gorm:"not null"`
+	ItemID      uint         `gorm:"not null;index"`
+	WarehouseID uint         `gorm:"not null"`
+	// DestinationWarehouseID is the warehouse receiving the items of a transfer
+	DestinationWarehouseID uint
+	Quantity               int `gorm:"not null"`
+	// StockStatus, Reason, Note and ProjectID are the parameters of the held back operation
+	StockStatus StockStatus
+	Reason      string
+	Note        string
+	ProjectID   *uint
+	RequestedBy string         `gorm:"not null"`
+	Status      ApprovalStatus `gorm:"not null;index"`
+	// DecidedBy, DecidedAt and Comment are set by the manager approving or rejecting the request
+	DecidedBy string
+	DecidedAt *time.Time
+	Comment   string
+}
+
+// ApprovalEvent is an entry of the audit trail of the approval requests
+type ApprovalEvent struct {
+	ID        uint `gorm:"primaryKey;<-:create;autoIncrement"`
+	CreatedAt time.Time
+	RequestID uint   `gorm:"not null;index"`
+	Action    string `gorm:"not null"`
+	Actor     string `gorm:"not null"`
+	Detail    string
+}
+
+// ApprovalEntry is an ApprovalRequest together with the names of its item and warehouses
+type ApprovalEntry struct {
+	ApprovalRequest
+	ItemName                 string
+	WarehouseName            string
+	DestinationWarehouseName string
+}
+
+func (r *GORMSQLiteWarehouseRepository) SetItemApprovalThreshold(itemID uint, threshold int) error {
+	if threshold < 0 {
+		return errors.New("approval threshold can't be negative")
+	}
+	var item Item
+	err := r.DB.First(&item, itemID).Error
+	if err != nil {
+		return err
+	}
+	return r.DB.Model(&item).UpdateColumn("approval_threshold", threshold).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) RequestApproval(request ApprovalRequest) (ApprovalRequest, error) {
+	if request.Kind != ConsumeMovement && request.Kind != TransferMovement {
+		return request, errors.New("only consumptions and transfers need an approval")
+	}
+	if request.Quantity <= 0 {
+		return request, errors.New("quantity must be greater than 0")
+	}
+	request.Status = PendingApproval
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var item Item
+		err1 := tx.First(&item, request.ItemID).Error
+		if err1 != nil {
+			return err1
+		}
+		err2 := tx.Create(&request).Error
+		if err2 != nil {
+			return err2
+		}
+		return r.withTransaction(tx).recordApprovalEvent(request.ID, "requested", request.RequestedBy,
+			string(request.Kind)+" "+strconv.Itoa(request.Quantity)+" "+item.Name)
+	})
+	return request, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) ApproveRequest(requestID uint, approver string) error {
+	var failure error
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		request, err1 := txRepository.findPendingRequest(requestID)
+		if err1 != nil {
+			return err1
+		}
+		// the movements point back to the request they execute
+		note := MovementNote{Reason: request.Reason, Note: "approval " + strconv.Itoa(int(request.ID)), ProjectID: request.ProjectID}
+		if request.Note != "" {
+			note.Note = request.Note + " (" + note.Note + ")"
+		}
+		var err2 error
+		if request.Kind == TransferMovement {
+			err2 = txRepository.TransferItemsWithNote(request.ItemID, request.WarehouseID, request.Quantity, request.DestinationWarehouseID, note)
+		} else {
+			err2 = txRepository.ConsumeItemsFromStatus(request.ItemID, request.WarehouseID, request.Quantity, request.StockStatus, note)
+		}
+		if err2 != nil {
+			failure = err2
+			return err2
+		}
+		err3 := txRepository.decideRequest(&request, ApprovedApproval, approver, "")
+		if err3 != nil {
+			return err3
+		}
+		return txRepository.recordApprovalEvent(request.ID, "approved", approver, "movement executed")
+	})
+	if failure != nil {
+		// the request stays pending, the failure is kept in the audit trail
+		_ = r.recordApprovalEvent(requestID, "failed", approver, failure.Error())
+	}
+	return err
+}
+
+func (r *GORMSQLiteWarehouseRepository) RejectRequest(requestID uint, approver string, comment string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		request, err1 := txRepository.findPendingRequest(requestID)
+		if err1 != nil {
+			return err1
+		}
+		err2 := txRepository.decideRequest(&request, RejectedApproval, approver, comment)
+		if err2 != nil {
+			return err2
+		}
+		return txRepository.recordApprovalEvent(request.ID, "rejected", approver, comment)
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListApprovalRequests(status ApprovalStatus) ([]ApprovalEntry, error) {
+	var res []ApprovalEntry
+	err := r.DB.Table("approval_requests").
+		Select("approval_requests.*, items.name AS item_name, sources.name AS warehouse_name, "+
+			"destinations.name AS destination_warehouse_name").
+		Joins("LEFT JOIN items ON items.id = approval_requests.item_id").
+		Joins("LEFT JOIN warehouses AS sources ON sources.id = approval_requests.warehouse_id").
+		Joins("LEFT JOIN warehouses AS destinations ON destinations.id = approval_requests.destination_warehouse_id").
+		Where("approval_requests.status = ?", status).
+		Order("approval_requests.id DESC").Scan(&res).Error
+	return res, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindApprovalEvents(requestID uint) ([]ApprovalEvent, error) {
+	var res []ApprovalEvent
+	query := r.DB.Order("id DESC")
+	if requestID != 0 {
+		query = query.Where("request_id = ?", requestID)
+	}
+	err := query.Find(&res).Error
+	return res, err
+}
+
+// findPendingRequest returns the approval request identified by requestID if it is still waiting for a decision
+func (r *GORMSQLiteWarehouseRepository) findPendingRequest(requestID uint) (ApprovalRequest, error) {
+	var request ApprovalRequest
+	err := r.DB.First(&request, requestID).Error
+	if err != nil {
+		return request, err
+	}
+	if request.Status != PendingApproval {
+		return request, errors.New("approval request " + strconv.Itoa(int(requestID)) + " was already " + string(request.Status))
+	}
+	return request, nil
+}
+
+// decideRequest closes the approval request with the decision of the approver
+func (r *GORMSQLiteWarehouseRepository) decideRequest(request *ApprovalRequest, status ApprovalStatus, approver string, comment string) error {
+	now := time.Now()
+	return r.DB.Model(request).Updates(map[string]interface{}{"status": status, "decided_by": approver, "decided_at": &now,
+		"comment": comment}).Error
+}
+
+// recordApprovalEvent appends an entry to the audit trail of the approval request identified by requestID
+func (r *GORMSQLiteWarehouseRepository) recordApprovalEvent(requestID uint, action string, actor string, detail string) error {
+	return r.DB.Create(&ApprovalEvent{RequestID: requestID, Action: action, Actor: actor, Detail: detail}).Error
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestApprovals(t *testing.T) {
+	rep := newTestRepository(t, "test_approvals.db")
+	_ = rep.CreateWarehouse("North", "Milan", 1000)
+	_ = rep.CreateWarehouse("South", "Rome", 1000)
+	_ = rep.CreateItem("pumps", "hydraulics", "water pumps")
+	_ = rep.SupplyItems(1, 1, 100)
+	t.Run("SetItemApprovalThreshold", func(t *testing.T) {
+		err1 := rep.SetItemApprovalThreshold(1, 10)
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		err2 := rep.SetItemApprovalThreshold(1, -1)
+		if err2 == nil || err2.Error() != "approval threshold can't be negative" {
+			t.Errorf("unexpected error: %v", err2)
+		}
+		item, _ := rep.FindItemByID(1)
+		if item.ApprovalThreshold != 10 {
+			t.Errorf("Threshold wasn't set: %d", item.ApprovalThreshold)
+		}
+	})
+	t.Run("ApproveRequest", func(t *testing.T) {
+		request, err1 := rep.RequestApproval(ApprovalRequest{Kind: TransferMovement, ItemID: 1, WarehouseID: 1,
+			DestinationWarehouseID: 2, Quantity: 30, RequestedBy: "alice", Note: "new site"})
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		pending, _ := rep.ListApprovalRequests(PendingApproval)
+		if len(pending) != 1 || pending[0].DestinationWarehouseName != "South" || pending[0].ItemName != "pumps" {
+			t.Errorf("Pending request wasn't listed: %+v", pending)
+		}
+		err2 := rep.ApproveRequest(request.ID, "bob")
+		if err2 != nil {
+			t.Fatalf("Reported error: %v", err2)
+		}
+		err3 := rep.ApproveRequest(request.ID, "bob")
+		if err3 == nil || err3.Error() != "approval request 1 was already approved" {
+			t.Errorf("unexpected error: %v", err3)
+		}
+		south, _ := rep.FindItemsInWarehouse(2)
+		if len(south) != 1 || south[0].ItemQuantity != 30 {
+			t.Errorf("Approved transfer wasn't executed: %+v", south)
+		}
+		movements, _ := rep.FindRecentMovements(1)
+		if movements[0].Note != "new site (approval 1)" {
+			t.Errorf("Movement doesn't point to the request: %q", movements[0].Note)
+		}
+	})
+	t.Run("RejectRequest", func(t *testing.T) {
+		request, _ := rep.RequestApproval(ApprovalRequest{Kind: ConsumeMovement, ItemID: 1, WarehouseID: 1, Quantity: 20,
+			StockStatus: AvailableStatus, RequestedBy: "alice"})
+		err1 := rep.RejectRequest(request.ID, "bob", "too many")
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		rejected, _ := rep.ListApprovalRequests(RejectedApproval)
+		if len(rejected) != 1 || rejected[0].DecidedBy != "bob" || rejected[0].Comment != "too many" {
+			t.Errorf("Request wasn't rejected: %+v", rejected)
+		}
+	})
+	t.Run("FailedApproval", func(t *testing.T) {
+		request, _ := rep.RequestApproval(ApprovalRequest{Kind: ConsumeMovement, ItemID: 1, WarehouseID: 1, Quantity: 500,
+			StockStatus: AvailableStatus, RequestedBy: "alice"})
+		err1 := rep.ApproveRequest(request.ID, "bob")
+		if err1 == nil {
+			t.Fatalf("Request consuming more than the stock was executed")
+		}
+		pending, _ := rep.ListApprovalRequests(PendingApproval)
+		if len(pending) != 1 {
+			t.Errorf("Failed request didn't stay pending: %+v", pending)
+		}
+		events, _ := rep.FindApprovalEvents(request.ID)
+		if len(events) != 2 || events[0].Action != "failed" || events[1].Action != "requested" {
+			t.Errorf("Wrong audit trail: %+v", events)
+		}
+		all, _ := rep.FindApprovalEvents(0)
+		if len(all) != 6 {
+			t.Errorf("Wrong number of events: %d", len(all))
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *GORMSQLiteWarehouseRepository) SetItemArchived(itemID uint, archived bool) error {
+	var item Item
+	err := r.DB.First(&item, itemID).Error
+	if err != nil {
+		return err
+	}
+	return r.DB.Model(&item).UpdateColumn("archived", archived).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListArchivedItems() ([]Item, error) {
+	var items []Item
+	err := r.DB.Where("archived = ?", true).Order("name").Find(&items).Error
+	return items, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) MergeItems(duplicateID uint, canonicalID uint) error {
+	if duplicateID == canonicalID {
+		return errors.New("an item can't be merged into itself")
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		var duplicate, canonical Item
+		err1 := tx.First(&duplicate, duplicateID).Error
+		if err1 != nil {
+			return err1
+		}
+		err2 := tx.First(&canonical, canonicalID).Error
+		if err2 != nil {
+			return err2
+		}
+		steps := []func(uint, uint) error{
+			txRepository.mergeWarehouseItems,
+			txRepository.mergeStatusBuckets,
+			txRepository.mergeSnapshotLines,
+			txRepository.mergeBOMLines,
+			txRepository.mergeItemReferences,
+		}
+		for _, step := range steps {
+			err3 := step(duplicateID, canonicalID)
+			if err3 != nil {
+				return err3
+			}
+		}
+		err4 := tx.Model(&canonical).UpdateColumn("quantity", gorm.Expr("quantity + ?", duplicate.Quantity)).Error
+		if err4 != nil {
+			return err4
+		}
+		err5 := tx.Model(&duplicate).UpdateColumn("quantity", 0).Error
+		if err5 != nil {
+			return err5
+		}
+		return tx.Delete(&duplicate).Error
+	})
+}
+
+// mergeWarehouseItems adds the stock of the duplicate to the stock of the canonical item in every warehouse. The
+// capacity of the warehouses doesn't change.
+func (r *GORMSQLiteWarehouseRepository) mergeWarehouseItems(duplicateID uint, canonicalID uint) error {
+	var rows []WarehouseItem
+	err1 := r.DB.Where("item_id = ?", duplicateID).Find(&rows).Error
+	if err1 != nil {
+		return err1
+	}
+	for _, row := range rows {
+		err2 := r.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "item_id"}, {Name: "warehouse_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"quantity": gorm.Expr("warehouse_items.quantity + ?", row.Quantity)}),
+		}).Create(&WarehouseItem{ItemID: canonicalID, WarehouseID: row.WarehouseID, Quantity: row.Quantity}).Error
+		if err2 != nil {
+			return err2
+		}
+	}
+	return r.DB.Where("item_id = ?", duplicateID).Delete(&WarehouseItem{}).Error
+}
+
+// mergeStatusBuckets moves the quarantined, damaged and on hold stock of the duplicate to the canonical item
+func (r *GORMSQLiteWarehouseRepository) mergeStatusBuckets(duplicateID uint, canonicalID uint) error {
+	var buckets []StockStatusBucket
+	err1 := r.DB.Where("item_id = ?", duplicateID).Find(&buckets).Error
+	if err1 != nil {
+		return err1
+	}
+	for _, bucket := range buckets {
+		err2 := r.addToBucket(canonicalID, bucket.WarehouseID, bucket.Status, bucket.Quantity)
+		if err2 != nil {
+			return err2
+		}
+	}
+	return r.DB.Where("item_id = ?", duplicateID).Delete(&StockStatusBucket{}).Error
+}
+
+// mergeSnapshotLines adds the snapshotted stock of the duplicate to the one of the canonical item, so that the
+// inventory rebuilt from the merged movements stays consistent
+func (r *GORMSQLiteWarehouseRepository) mergeSnapshotLines(duplicateID uint, canonicalID uint) error {
+	var lines []SnapshotLine
+	err1 := r.DB.Where("item_id = ?", duplicateID).Find(&lines).Error
+	if err1 != nil {
+		return err1
+	}
+	for _, line := range lines {
+		err2 := r.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "snapshot_id"}, {Name: "item_id"}, {Name: "warehouse_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"quantity": gorm.Expr("snapshot_lines.quantity + ?", line.Quantity)}),
+		}).Create(&SnapshotLine{SnapshotID: line.SnapshotID, ItemID: canonicalID, WarehouseID: line.WarehouseID,
+			Quantity: line.Quantity}).Error
+		if err2 != nil {
+			return err2
+		}
+	}
+	return r.DB.Where("item_id = ?", duplicateID).Delete(&SnapshotLine{}).Error
+}
+
+// mergeBOMLines replaces the duplicate with the canonical item in the bills of materials. The bill of materials of
+// the duplicate is kept only when the canonical item has none. The merge is refused when it would create a cycle.
+func (r *GORMSQLiteWarehouseRepository) mergeBOMLines(duplicateID uint, canonicalID uint) error {
+	var canonicalLines int64
+	err1 := r.DB.Model(&BOMLine{}).Where("kit_id = ?", canonicalID).Count(&canonicalLines).Error
+	if err1 != nil {
+		return err1
+	}
+	// a kit can't contain itself, so the duplicate's lines using the canonical item are dropped
+	err2 := r.DB.Where("kit_id = ? AND component_id = ?", duplicateID, canonicalID).Delete(&BOMLine{}).Error
+	if err2 != nil {
+		return err2
+	}
+	if canonicalLines > 0 {
+		err3 := r.DB.Where("kit_id = ?", duplicateID).Delete(&BOMLine{}).Error
+		if err3 != nil {
+			return err3
+		}
+	}
+	err4 := r.DB.Model(&BOMLine{}).Where("kit_id = ?", duplicateID).Update("kit_id", canonicalID).Error
+	if err4 != nil {
+		return err4
+	}
+	var lines []BOMLine
+	err5 := r.DB.Where("component_id = ?", duplicateID).Find(&lines).Error
+	if err5 != nil {
+		return err5
+	}
+	for _, line := range lines {
+		err6 := r.DB.Where("kit_id = ? AND component_id = ?", line.KitID, duplicateID).Delete(&BOMLine{}).Error
+		if err6 != nil {
+			return err6
+		}
+		// a kit can't contain itself
+		if line.KitID == canonicalID {
+			continue
+		}
+		err7 := r.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "kit_id"}, {Name: "component_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"quantity": gorm.Expr("bom_lines.quantity + ?", line.Quantity)}),
+		}).Create(&BOMLine{KitID: line.KitID, ComponentID: canonicalID, Quantity: line.Quantity}).Error
+		if err7 != nil {
+			return err7
+		}
+	}
+	// the merged lines may still close a cycle through other kits
+	var contained []uint
+	err8 := r.DB.Raw(kitComponents, canonicalID).Scan(&contained).Error
+	if err8 != nil {
+		return err8
+	}
+	for _, id := range contained {
+		if id == canonicalID {
+			return errors.New("the merge would make the item contain itself through its components")
+		}
+	}
+	return nil
+}
+
+// mergeItemReferences points the history, shipments, returns, loans, approval requests and attachments of the
+// duplicate to the canonical item. The stock levels, attribute values, variant options and supplier conditions of the
+// canonical item win over the ones of the duplicate.
+func (r *GORMSQLiteWarehouseRepository) mergeItemReferences(duplicateID uint, canonicalID uint) error {
+	for _, model := range []interface{}{&StockMovement{}, &Shipment{}, &ReturnAuthorization{}, &Loan{}, &ApprovalRequest{}} {
+		err1 := r.DB.Model(model).Where("item_id = ?", duplicateID).Update("item_id", canonicalID).Error
+		if err1 != nil {
+			return err1
+		}
+	}
+	err2 := r.DB.Model(&Attachment{}).Where("owner_type = ? AND owner_id = ?", ItemAttachment, duplicateID).
+		Update("owner_id", canonicalID).Error
+	if err2 != nil {
+		return err2
+	}
+	keyed := map[string]interface{}{"warehouse_id": &StockLevel{}, "definition_id": &AttributeValue{}, "dimension": &VariantOption{},
+		"supplier_id": &SupplierItem{}}
+	for key, model := range keyed {
+		taken := r.DB.Model(model).Select(key).Where("item_id = ?", canonicalID)
+		err3 := r.DB.Model(model).Where("item_id = ? AND "+key+" NOT IN (?)", duplicateID, taken).
+			Update("item_id", canonicalID).Error
+		if err3 != nil {
+			return err3
+		}
+		err4 := r.DB.Where("item_id = ?", duplicateID).Delete(model).Error
+		if err4 != nil {
+			return err4
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestArchive(t *testing.T) {
+	rep := newTestRepository(t, "test_archive.db")
+	_ = rep.CreateWarehouse("North", "Milan", 100)
+	_ = rep.CreateWarehouse("South", "Naples", 100)
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.CreateItem("Gloves ", "safety", "work gloves, duplicate")
+	_ = rep.CreateItem("helmets", "safety", "yellow helmets")
+	_ = rep.SupplyItems(1, 1, 10)
+	_ = rep.SupplyItems(2, 1, 5)
+	_ = rep.SupplyItems(2, 2, 7)
+	_ = rep.ChangeStockStatus(2, 2, 2, AvailableStatus, DamagedStatus)
+	_ = rep.SetBOMLine(3, 2, 1)
+	t.Run("SetItemArchived", func(t *testing.T) {
+		err1 := rep.SetItemArchived(3, true)
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		items, _ := rep.ListAllItems()
+		archived, _ := rep.ListArchivedItems()
+		if len(items) != 2 || len(archived) != 1 || archived[0].Name != "helmets" {
+			t.Errorf("Archived item wasn't hidden\nlisted items: %d archived items: %d", len(items), len(archived))
+		}
+		found, _ := rep.FindItemsByKeyword("helmets")
+		if len(found) != 0 {
+			t.Errorf("Search found an archived item")
+		}
+		err2 := rep.SupplyItems(3, 1, 5)
+		if err2 == nil || err2.Error() != "item \"helmets\" is archived" {
+			t.Errorf("unexpected error: %v", err2)
+		}
+		_ = rep.SetItemArchived(3, false)
+		err3 := rep.SupplyItems(3, 1, 5)
+		if err3 != nil {
+			t.Errorf("Restored item can't be supplied: %v", err3)
+		}
+	})
+	t.Run("MergeItems", func(t *testing.T) {
+		err1 := rep.MergeItems(2, 2)
+		if err1 == nil || err1.Error() != "an item can't be merged into itself" {
+			t.Errorf("unexpected error: %v", err1)
+		}
+		err2 := rep.MergeItems(2, 1)
+		if err2 != nil {
+			t.Fatalf("Reported error: %v", err2)
+		}
+		gloves, _ := rep.FindItemByID(1)
+		if gloves.Quantity != 22 {
+			t.Errorf("Stock wasn't merged\nexpected quantity: 22\nactual quantity: %d", gloves.Quantity)
+		}
+		lines, _ := rep.FindStockStatuses(1, 0)
+		if len(lines) != 2 || lines[0].Total != 15 || lines[1].Total != 7 || lines[1].Damaged != 2 {
+			t.Errorf("Warehouse stock wasn't merged: %+v", lines)
+		}
+		var movements int64
+		rep.DB.Model(&StockMovement{}).Where("item_id = ?", 1).Count(&movements)
+		if movements != 3 {
+			t.Errorf("History wasn't merged\nexpected movements: 3\nactual movements: %d", movements)
+		}
+		bom, _ := rep.FindBOM(3)
+		if len(bom) != 1 || bom[0].ComponentID != 1 {
+			t.Errorf("Bill of materials wasn't merged: %+v", bom)
+		}
+		_, err3 := rep.FindItemByID(2)
+		if err3 == nil {
+			t.Errorf("Duplicate item wasn't deleted")
+		}
+		inconsistencies, _ := rep.CheckConsistency()
+		if len(inconsistencies) != 0 {
+			t.Errorf("Merge left inconsistencies: %+v", inconsistencies)
+		}
+	})
+	t.Run("MergeKitContainingCanonical", func(t *testing.T) {
+		_ = rep.CreateItem("first aid kit", "safety", "first aid kit")
+		_ = rep.CreateItem("First aid kit", "safety", "first aid kit, duplicate")
+		_ = rep.SetBOMLine(5, 4, 1)
+		_ = rep.SetBOMLine(5, 3, 2)
+		err := rep.MergeItems(5, 4)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		bom, _ := rep.FindBOM(4)
+		if len(bom) != 1 || bom[0].ComponentID != 3 || bom[0].Quantity != 2 {
+			t.Errorf("Merged kit contains itself: %+v", bom)
+		}
+	})
+	t.Run("MergeKitWithIndirectCycle", func(t *testing.T) {
+		_ = rep.CreateItem("eye wash kit", "safety", "eye wash kit")
+		_ = rep.CreateItem("Eye wash kit", "safety", "eye wash kit, duplicate")
+		_ = rep.CreateItem("refill pack", "safety", "refills")
+		var kit, duplicate, refill Item
+		rep.DB.Where("name = ?", "eye wash kit").First(&kit)
+		rep.DB.Where("name = ?", "Eye wash kit").First(&duplicate)
+		rep.DB.Where("name = ?", "refill pack").First(&refill)
+		_ = rep.SetBOMLine(refill.ID, kit.ID, 1)
+		_ = rep.SetBOMLine(duplicate.ID, refill.ID, 1)
+		err := rep.MergeItems(duplicate.ID, kit.ID)
+		if err == nil || err.Error() != "the merge would make the item contain itself through its components" {
+			t.Errorf("unexpected error: %v", err)
+		}
+		_, err = rep.FindItemByID(duplicate.ID)
+		if err != nil {
+			t.Errorf("Refused merge deleted the duplicate")
+		}
+	})
+}
@@ -0,0 +1,258 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"gorm.io/gorm"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AttachmentOwner is the kind of record a file is attached to
+type AttachmentOwner string
+
+const (
+	ItemAttachment      AttachmentOwner = "item"
+	WarehouseAttachment AttachmentOwner = "warehouse"
+)
+
+// MaxAttachmentSize is the biggest file accepted as an attachment, in bytes
+const MaxAttachmentSize = 10 << 20
+
+// thumbnailSize is the length in pixels of the longest side of the generated thumbnails
+const thumbnailSize = 160
+
+// maxThumbnailPixels is the size of the biggest image decoded to make a thumbnail, bigger images are stored without
+const maxThumbnailPixels = 40_000_000
+
+// allowedAttachmentTypes lists the accepted MIME types, detected from the content of the files
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+// Attachment describes a file attached to an item or a warehouse. The content is stored in the attachment directory
+// of the repository, in a file named after the ID of the attachment.
+type Attachment struct {
+	ID           uint `gorm:"primaryKey;<-:create;autoIncrement"`
+	CreatedAt    time.Time
+	OwnerType    AttachmentOwner `gorm:"not null;index:idx_attachment_owner"`
+	OwnerID      uint            `gorm:"not null;index:idx_attachment_owner"`
+	FileName     string          `gorm:"not null"`
+	MIMEType     string          `gorm:"not null"`
+	Size         int64           `gorm:"not null"`
+	HasThumbnail bool            `gorm:"not null;default:false"`
+}
+
+// IsImage reports whether the attachment is shown as an image
+func (a Attachment) IsImage() bool {
+	return strings.HasPrefix(a.MIMEType, "image/")
+}
+
+// attachmentDirectory returns the directory holding the attachments of the database with the given name
+func attachmentDirectory(DBName string) string {
+	return strings.TrimSuffix(DBName, filepath.Ext(DBName)) + "_attachments"
+}
+
+// attachmentPath returns the path of the content, or of the thumbnail, of an attachment
+func (r *GORMSQLiteWarehouseRepository) attachmentPath(attachmentID uint, thumbnail bool) string {
+	name := "attachment" + strconv.Itoa(int(attachmentID))
+	if thumbnail {
+		name += "_thumbnail.png"
+	}
+	return filepath.Join(r.AttachmentDir, name)
+}
+
+// checkAttachmentOwner verifies that the record receiving an attachment exists
+func (r *GORMSQLiteWarehouseRepository) checkAttachmentOwner(ownerType AttachmentOwner, ownerID uint) error {
+	switch ownerType {
+	case ItemAttachment:
+		return r.DB.First(&Item{}, ownerID).Error
+	case WarehouseAttachment:
+		return r.DB.First(&Warehouse{}, ownerID).Error
+	default:
+		return errors.New("unknown attachment owner: " + string(ownerType))
+	}
+}
+
+func (r *GORMSQLiteWarehouseRepository) AddAttachment(ownerType AttachmentOwner, ownerID uint, fileName string, content []byte) (Attachment, error) {
+	attachment := Attachment{OwnerType: ownerType, OwnerID: ownerID, FileName: filepath.Base(fileName), Size: int64(len(content))}
+	err1 := r.checkAttachmentOwner(ownerType, ownerID)
+	if err1 != nil {
+		return attachment, err1
+	}
+	if len(content) == 0 {
+		return attachment, errors.New("attachment is empty")
+	}
+	if len(content) > MaxAttachmentSize {
+		return attachment, errors.New("attachment is too big: " + strconv.Itoa(len(content)) + " > " + strconv.Itoa(MaxAttachmentSize) + " bytes")
+	}
+	attachment.MIMEType = strings.Split(http.DetectContentType(content), ";")[0]
+	if !allowedAttachmentTypes[attachment.MIMEType] {
+		return attachment, errors.New("attachment type not allowed: " + attachment.MIMEType)
+	}
+	var thumbnail []byte
+	if attachment.IsImage() {
+		var err2 error
+		thumbnail, err2 = makeThumbnail(content)
+		if err2 != nil {
+			return attachment, errors.New("invalid image: " + err2.Error())
+		}
+		attachment.HasThumbnail = thumbnail != nil
+	}
+	err3 := os.MkdirAll(r.AttachmentDir, 0755)
+	if err3 != nil {
+		return attachment, err3
+	}
+	// the row is rolled back when the files can't be written
+	err4 := r.DB.Transaction(func(tx *gorm.DB) error {
+		err5 := tx.Create(&attachment).Error
+		if err5 != nil {
+			return err5
+		}
+		err6 := os.WriteFile(r.attachmentPath(attachment.ID, false), content, 0644)
+		if err6 != nil {
+			return err6
+		}
+		if thumbnail != nil {
+			err7 := os.WriteFile(r.attachmentPath(attachment.ID, true), thumbnail, 0644)
+			if err7 != nil {
+				_ = os.Remove(r.attachmentPath(attachment.ID, false))
+				return err7
+			}
+		}
+		return nil
+	})
+	return attachment, err4
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListAttachments(ownerType AttachmentOwner, ownerID uint) ([]Attachment, error) {
+	var attachments []Attachment
+	err := r.DB.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) OpenAttachment(attachmentID uint, thumbnail bool) (Attachment, []byte, error) {
+	var attachment Attachment
+	err1 := r.DB.First(&attachment, attachmentID).Error
+	if err1 != nil {
+		return attachment, nil, err1
+	}
+	if thumbnail && !attachment.HasThumbnail {
+		return attachment, nil, errors.New("attachment has no thumbnail")
+	}
+	content, err2 := os.ReadFile(r.attachmentPath(attachmentID, thumbnail))
+	return attachment, content, err2
+}
+
+func (r *GORMSQLiteWarehouseRepository) DeleteAttachment(attachmentID uint) error {
+	var attachment Attachment
+	err1 := r.DB.First(&attachment, attachmentID).Error
+	if err1 != nil {
+		return err1
+	}
+	err2 := r.DB.Delete(&attachment).Error
+	if err2 != nil {
+		return err2
+	}
+	return r.removeAttachmentFiles(attachment)
+}
+
+// deleteAttachmentsOf removes every attachment of a record, used when the record itself is deleted
+func (r *GORMSQLiteWarehouseRepository) deleteAttachmentsOf(ownerType AttachmentOwner, ownerID uint) error {
+	attachments, err1 := r.ListAttachments(ownerType, ownerID)
+	if err1 != nil {
+		return err1
+	}
+	err2 := r.DB.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).Delete(&Attachment{}).Error
+	if err2 != nil {
+		return err2
+	}
+	for _, attachment := range attachments {
+		err3 := r.removeAttachmentFiles(attachment)
+		if err3 != nil {
+			return err3
+		}
+	}
+	return nil
+}
+
+// removeAttachmentFiles deletes the content and the thumbnail of an attachment, ignoring the files already missing
+func (r *GORMSQLiteWarehouseRepository) removeAttachmentFiles(attachment Attachment) error {
+	err1 := os.Remove(r.attachmentPath(attachment.ID, false))
+	if err1 != nil && !errors.Is(err1, os.ErrNotExist) {
+		return err1
+	}
+	if attachment.HasThumbnail {
+		err2 := os.Remove(r.attachmentPath(attachment.ID, true))
+		if err2 != nil && !errors.Is(err2, os.ErrNotExist) {
+			return err2
+		}
+	}
+	return nil
+}
+
+// makeThumbnail decodes an image and scales it down, keeping its proportions, to a PNG whose longest side is
+// thumbnailSize pixels. Every pixel of the thumbnail is the average of the pixels of the area it covers. Images
+// declaring more than maxThumbnailPixels pixels aren't decoded and get no thumbnail.
+func makeThumbnail(content []byte) ([]byte, error) {
+	config, _, err1 := image.DecodeConfig(bytes.NewReader(content))
+	if err1 != nil {
+		return nil, err1
+	}
+	if int64(config.Width)*int64(config.Height) > maxThumbnailPixels {
+		return nil, nil
+	}
+	source, _, err2 := image.Decode(bytes.NewReader(content))
+	if err2 != nil {
+		return nil, err2
+	}
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, errors.New("image has no pixels")
+	}
+	thumbnailWidth, thumbnailHeight := width, height
+	if width >= height && width > thumbnailSize {
+		thumbnailWidth, thumbnailHeight = thumbnailSize, max(1, height*thumbnailSize/width)
+	} else if height > width && height > thumbnailSize {
+		thumbnailWidth, thumbnailHeight = max(1, width*thumbnailSize/height), thumbnailSize
+	}
+	thumbnail := image.NewRGBA(image.Rect(0, 0, thumbnailWidth, thumbnailHeight))
+	for y := 0; y < thumbnailHeight; y++ {
+		y0, y1 := bounds.Min.Y+y*height/thumbnailHeight, bounds.Min.Y+max((y+1)*height/thumbnailHeight, y*height/thumbnailHeight+1)
+		for x := 0; x < thumbnailWidth; x++ {
+			x0, x1 := bounds.Min.X+x*width/thumbnailWidth, bounds.Min.X+max((x+1)*width/thumbnailWidth, x*width/thumbnailWidth+1)
+			var red, green, blue, alpha, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := source.At(sx, sy).RGBA()
+					red, green, blue, alpha = red+uint64(pr), green+uint64(pg), blue+uint64(pb), alpha+uint64(pa)
+					count++
+				}
+			}
+			offset := thumbnail.PixOffset(x, y)
+			thumbnail.Pix[offset] = uint8(red / count >> 8)
+			thumbnail.Pix[offset+1] = uint8(green / count >> 8)
+			thumbnail.Pix[offset+2] = uint8(blue / count >> 8)
+			thumbnail.Pix[offset+3] = uint8(alpha / count >> 8)
+		}
+	}
+	var buffer bytes.Buffer
+	err3 := png.Encode(&buffer, thumbnail)
+	if err3 != nil {
+		return nil, err3
+	}
+	return buffer.Bytes(), nil
+}
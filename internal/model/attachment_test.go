@@ -0,0 +1,112 @@
+package model
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"testing"
+)
+
+func TestAttachment(t *testing.T) {
+	rep := newTestRepository(t, "test_attachment.db")
+	t.Cleanup(func() {
+		_ = os.RemoveAll(rep.AttachmentDir)
+	})
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.CreateWarehouse("North", "Milan", 100)
+	picture := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for x := 0; x < 400; x++ {
+		for y := 0; y < 200; y++ {
+			picture.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	var buffer bytes.Buffer
+	_ = png.Encode(&buffer, picture)
+	t.Run("AddImageAttachment", func(t *testing.T) {
+		attachment, err := rep.AddAttachment(ItemAttachment, 1, "photos/gloves.png", buffer.Bytes())
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if attachment.FileName != "gloves.png" || attachment.MIMEType != "image/png" || !attachment.HasThumbnail {
+			t.Errorf("Incorrect attachment: %+v", attachment)
+		}
+		_, content, err2 := rep.OpenAttachment(attachment.ID, true)
+		if err2 != nil {
+			t.Fatalf("Reported error: %v", err2)
+		}
+		thumbnail, err3 := png.Decode(bytes.NewReader(content))
+		if err3 != nil {
+			t.Fatalf("Reported error: %v", err3)
+		}
+		if thumbnail.Bounds().Dx() != thumbnailSize || thumbnail.Bounds().Dy() != thumbnailSize/2 {
+			t.Errorf("Incorrect thumbnail size\nexpected size: %dx%d\nactual size: %dx%d", thumbnailSize, thumbnailSize/2,
+				thumbnail.Bounds().Dx(), thumbnail.Bounds().Dy())
+		}
+	})
+	t.Run("AddDocumentAttachment", func(t *testing.T) {
+		attachment, err := rep.AddAttachment(WarehouseAttachment, 1, "notes.txt", []byte("loading dock on the north side"))
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		_, content, _ := rep.OpenAttachment(attachment.ID, false)
+		if string(content) != "loading dock on the north side" || attachment.HasThumbnail {
+			t.Errorf("Incorrect attachment: %+v", attachment)
+		}
+	})
+	t.Run("AddAttachmentInvalid", func(t *testing.T) {
+		_, err1 := rep.AddAttachment(ItemAttachment, 1, "tool.exe", []byte{0x4d, 0x5a, 0x90, 0x00, 0x03, 0x00, 0x00, 0x00})
+		if err1 == nil {
+			t.Errorf("No error reported for a forbidden type")
+		}
+		_, err2 := rep.AddAttachment(ItemAttachment, 1, "big.txt", bytes.Repeat([]byte("a"), MaxAttachmentSize+1))
+		if err2 == nil {
+			t.Errorf("No error reported for a file bigger than the limit")
+		}
+		_, err3 := rep.AddAttachment(ItemAttachment, 5, "notes.txt", []byte("notes"))
+		if err3 == nil {
+			t.Errorf("No error reported for a missing owner")
+		}
+		attachments, _ := rep.ListAttachments(ItemAttachment, 1)
+		if len(attachments) != 1 {
+			t.Errorf("Incorrect number of attachments.\nexpected number: 1 actual number: %d", len(attachments))
+		}
+	})
+	t.Run("DeleteAttachment", func(t *testing.T) {
+		err := rep.DeleteAttachment(2)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		_, err2 := os.Stat(rep.attachmentPath(2, false))
+		if !os.IsNotExist(err2) {
+			t.Errorf("File of the deleted attachment wasn't removed")
+		}
+	})
+	t.Run("DeleteItemRemovesAttachments", func(t *testing.T) {
+		err := rep.DeleteItem(1)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		attachments, _ := rep.ListAttachments(ItemAttachment, 1)
+		_, err2 := os.Stat(rep.attachmentPath(1, true))
+		if len(attachments) != 0 || !os.IsNotExist(err2) {
+			t.Errorf("Attachments of the deleted item weren't removed")
+		}
+	})
+	t.Run("AddHugeImageAttachment", func(t *testing.T) {
+		var small bytes.Buffer
+		_ = gif.Encode(&small, image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Black}), nil)
+		content := small.Bytes()
+		// the logical screen declares a 65535x65535 image
+		copy(content[6:10], []byte{0xff, 0xff, 0xff, 0xff})
+		attachment, err := rep.AddAttachment(WarehouseAttachment, 1, "bomb.gif", content)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if attachment.HasThumbnail {
+			t.Errorf("Thumbnail was made for an image above the pixel limit")
+		}
+	})
+}
@@ -0,0 +1,270 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AttributeType is the type of the values accepted by an attribute definition
+type AttributeType string
+
+const (
+	TextAttribute   AttributeType = "text"
+	NumberAttribute AttributeType = "number"
+	DateAttribute   AttributeType = "date"
+	EnumAttribute   AttributeType = "enum"
+)
+
+// layout of the values of date attributes, which makes them sortable as strings
+const attributeDateLayout = "2006-01-02"
+
+// AttributeDefinition describes a custom attribute of the items of a category and of its subcategories
+type AttributeDefinition struct {
+	ID         uint `gorm:"primaryKey;<-:create;autoIncrement"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	CategoryID uint          `gorm:"not null;index"`
+	Name       string        `gorm:"not null"`
+	Type       AttributeType `gorm:"not null"`
+	// Options holds the values allowed by enum attributes separated by commas
+	Options string
+}
+
+// OptionList returns the values allowed by an enum attribute
+func (d AttributeDefinition) OptionList() []string {
+	res := make([]string, 0)
+	for _, option := range strings.Split(d.Options, ",") {
+		if strings.TrimSpace(option) != "" {
+			res = append(res, strings.TrimSpace(option))
+		}
+	}
+	return res
+}
+
+// AttributeValue is the value of an attribute for a single item, stored as text
+type AttributeValue struct {
+	ItemID       uint   `gorm:"primaryKey"`
+	DefinitionID uint   `gorm:"primaryKey;index"`
+	Value        string `gorm:"not null"`
+}
+
+// ItemAttribute is an attribute applying to an item together with its value, empty when not set
+type ItemAttribute struct {
+	Definition AttributeDefinition
+	Value      string
+}
+
+// AttributeFilter selects the items having an attribute with the given name whose value satisfies the operator.
+// Supported operators are "=", "<", "<=", ">", ">=" and "contains". Comparisons apply to number and date attributes.
+type AttributeFilter struct {
+	Name     string
+	Operator string
+	Value    string
+}
+
+// categoryAncestors selects the ID of a category and of all the categories containing it
+const categoryAncestors = "WITH RECURSIVE tree(id, parent_id) AS (SELECT id, parent_id FROM categories WHERE id = ? " +
+	"UNION SELECT categories.id, categories.parent_id FROM categories JOIN tree ON categories.id = tree.parent_id) SELECT id FROM tree"
+
+// validateAttributeValue checks that a value is accepted by the definition and returns it normalized
+func validateAttributeValue(definition AttributeDefinition, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch definition.Type {
+	case TextAttribute:
+		return value, nil
+	case NumberAttribute:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", errors.New(definition.Name + ": \"" + value + "\" is not a number")
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), nil
+	case DateAttribute:
+		date, err := time.Parse(attributeDateLayout, value)
+		if err != nil {
+			return "", errors.New(definition.Name + ": \"" + value + "\" is not a date in the format YYYY-MM-DD")
+		}
+		return date.Format(attributeDateLayout), nil
+	case EnumAttribute:
+		for _, option := range definition.OptionList() {
+			if strings.EqualFold(option, value) {
+				return option, nil
+			}
+		}
+		return "", errors.New(definition.Name + ": \"" + value + "\" is not one of " + strings.Join(definition.OptionList(), ", "))
+	default:
+		return "", errors.New("unknown attribute type: " + string(definition.Type))
+	}
+}
+
+func (r *GORMSQLiteWarehouseRepository) CreateAttributeDefinition(categoryID uint, name string, attributeType AttributeType, options []string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("attribute name can't be empty")
+	}
+	switch attributeType {
+	case TextAttribute, NumberAttribute, DateAttribute:
+		options = nil
+	case EnumAttribute:
+		if len(options) == 0 {
+			return errors.New("enum attributes need at least one option")
+		}
+	default:
+		return errors.New("unknown attribute type: " + string(attributeType))
+	}
+	for _, option := range options {
+		if strings.Contains(option, ",") {
+			return errors.New("options can't contain commas: " + option)
+		}
+	}
+	definitions, err1 := r.ListAttributeDefinitions(categoryID)
+	if err1 != nil {
+		return err1
+	}
+	for _, definition := range definitions {
+		if strings.EqualFold(definition.Name, name) {
+			return errors.New("attribute \"" + name + "\" is already defined for this category")
+		}
+	}
+	return r.DB.Create(&AttributeDefinition{
+		CategoryID: categoryID,
+		Name:       name,
+		Type:       attributeType,
+		Options:    strings.Join(options, ","),
+	}).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) DeleteAttributeDefinition(definitionID uint) error {
+	var definition AttributeDefinition
+	err1 := r.DB.First(&definition, definitionID).Error
+	if err1 != nil {
+		return err1
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err2 := tx.Where("definition_id = ?", definitionID).Delete(&AttributeValue{}).Error
+		if err2 != nil {
+			return err2
+		}
+		return tx.Delete(&definition).Error
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListAttributeDefinitions(categoryID uint) ([]AttributeDefinition, error) {
+	err1 := r.DB.First(&Category{}, categoryID).Error
+	if err1 != nil {
+		return nil, err1
+	}
+	var definitions []AttributeDefinition
+	err2 := r.DB.Where("category_id IN (?)", r.DB.Raw(categoryAncestors, categoryID)).Order("name").Find(&definitions).Error
+	return definitions, err2
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindItemAttributes(itemID uint) ([]ItemAttribute, error) {
+	var item Item
+	err1 := r.DB.First(&item, itemID).Error
+	if err1 != nil {
+		return nil, err1
+	}
+	res := make([]ItemAttribute, 0)
+	if item.CategoryID == nil {
+		return res, nil
+	}
+	definitions, err2 := r.ListAttributeDefinitions(*item.CategoryID)
+	if err2 != nil {
+		return nil, err2
+	}
+	var values []AttributeValue
+	err3 := r.DB.Where("item_id = ?", itemID).Find(&values).Error
+	if err3 != nil {
+		return nil, err3
+	}
+	valueOf := make(map[uint]string)
+	for _, value := range values {
+		valueOf[value.DefinitionID] = value.Value
+	}
+	for _, definition := range definitions {
+		res = append(res, ItemAttribute{Definition: definition, Value: valueOf[definition.ID]})
+	}
+	return res, nil
+}
+
+func (r *GORMSQLiteWarehouseRepository) SetItemAttributes(itemID uint, values map[uint]string) error {
+	attributes, err1 := r.FindItemAttributes(itemID)
+	if err1 != nil {
+		return err1
+	}
+	definitions := make(map[uint]AttributeDefinition)
+	for _, attribute := range attributes {
+		definitions[attribute.Definition.ID] = attribute.Definition
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		for definitionID, value := range values {
+			definition, ok := definitions[definitionID]
+			if !ok {
+				return errors.New("attribute " + strconv.Itoa(int(definitionID)) + " doesn't apply to the category of the item")
+			}
+			if strings.TrimSpace(value) == "" {
+				err2 := tx.Where("item_id = ? AND definition_id = ?", itemID, definitionID).Delete(&AttributeValue{}).Error
+				if err2 != nil {
+					return err2
+				}
+				continue
+			}
+			normalized, err3 := validateAttributeValue(definition, value)
+			if err3 != nil {
+				return err3
+			}
+			err4 := tx.Clauses(clause.OnConflict{UpdateAll: true}).
+				Create(&AttributeValue{ItemID: itemID, DefinitionID: definitionID, Value: normalized}).Error
+			if err4 != nil {
+				return err4
+			}
+		}
+		return nil
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindItemsByAttributes(filters []AttributeFilter) ([]Item, error) {
+	query := r.DB.Model(&Item{})
+	for _, filter := range filters {
+		condition, argument, err1 := attributeCondition(filter)
+		if err1 != nil {
+			return nil, err1
+		}
+		matching := r.DB.Table("attribute_values").Select("attribute_values.item_id").
+			Joins("JOIN attribute_definitions ON attribute_definitions.id = attribute_values.definition_id").
+			Where("LOWER(attribute_definitions.name) = LOWER(?)", strings.TrimSpace(filter.Name)).
+			Where(condition, argument)
+		query = query.Where("items.id IN (?)", matching)
+	}
+	var items []Item
+	err2 := query.Find(&items).Error
+	return items, err2
+}
+
+// attributeCondition translates a filter into a condition on the joined attribute_values and attribute_definitions rows
+func attributeCondition(filter AttributeFilter) (string, interface{}, error) {
+	value := strings.TrimSpace(filter.Value)
+	switch filter.Operator {
+	case "contains":
+		return "attribute_values.value LIKE ?", "%" + value + "%", nil
+	case "=", "<", "<=", ">", ">=":
+		number, err1 := strconv.ParseFloat(value, 64)
+		if err1 == nil {
+			return "attribute_definitions.type = 'number' AND CAST(attribute_values.value AS REAL) " + filter.Operator + " ?", number, nil
+		}
+		_, err2 := time.Parse(attributeDateLayout, value)
+		if err2 == nil {
+			return "attribute_definitions.type = 'date' AND attribute_values.value " + filter.Operator + " ?", value, nil
+		}
+		if filter.Operator == "=" {
+			return "LOWER(attribute_values.value) = LOWER(?)", value, nil
+		}
+		return "", nil, errors.New("operator " + filter.Operator + " needs a number or a date in the format YYYY-MM-DD")
+	default:
+		return "", nil, errors.New("unknown operator: " + filter.Operator)
+	}
+}
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestAttribute(t *testing.T) {
+	rep := newTestRepository(t, "test_attribute.db")
+	_ = rep.CreateItem("AA batteries", "Batteries", "alkaline batteries")
+	_ = rep.CreateItem("9V batteries", "Batteries", "rectangular batteries")
+	_ = rep.CreateItem("gloves", "Safety", "work gloves")
+	batteries, _ := rep.findCategoryByName("Batteries")
+	safety, _ := rep.findCategoryByName("Safety")
+	_ = rep.CreateCategory("Rechargeable batteries", &batteries.ID)
+	_ = rep.CreateItem("NiMH batteries", "Rechargeable batteries", "rechargeable AA batteries")
+	rechargeable, _ := rep.findCategoryByName("Rechargeable batteries")
+	t.Run("CreateAttributeDefinition", func(t *testing.T) {
+		err1 := rep.CreateAttributeDefinition(batteries.ID, "voltage", NumberAttribute, nil)
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		_ = rep.CreateAttributeDefinition(safety.ID, "size", EnumAttribute, []string{"S", "M", "L"})
+		_ = rep.CreateAttributeDefinition(rechargeable.ID, "expiry", DateAttribute, nil)
+		err2 := rep.CreateAttributeDefinition(rechargeable.ID, "Voltage", TextAttribute, nil)
+		if err2 == nil {
+			t.Errorf("No error reported when redefining an inherited attribute")
+		}
+		err3 := rep.CreateAttributeDefinition(safety.ID, "color", EnumAttribute, nil)
+		if err3 == nil {
+			t.Errorf("No error reported for an enum attribute without options")
+		}
+	})
+	t.Run("ListAttributeDefinitionsIncludesParents", func(t *testing.T) {
+		definitions, err := rep.ListAttributeDefinitions(rechargeable.ID)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(definitions) != 2 || definitions[0].Name != "expiry" || definitions[1].Name != "voltage" {
+			t.Errorf("Incorrect definitions: %+v", definitions)
+		}
+	})
+	t.Run("SetItemAttributes", func(t *testing.T) {
+		_ = rep.SetItemAttributes(1, map[uint]string{1: "1.5"})
+		_ = rep.SetItemAttributes(2, map[uint]string{1: "9"})
+		_ = rep.SetItemAttributes(3, map[uint]string{2: "m"})
+		err1 := rep.SetItemAttributes(4, map[uint]string{1: "1.20", 3: "2027-03-01"})
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		attributes, _ := rep.FindItemAttributes(4)
+		if len(attributes) != 2 || attributes[0].Value != "2027-03-01" || attributes[1].Value != "1.2" {
+			t.Errorf("Incorrect attributes: %+v", attributes)
+		}
+		gloves, _ := rep.FindItemAttributes(3)
+		if gloves[0].Value != "M" {
+			t.Errorf("Enum value wasn't normalized\nexpected value: M\nactual value: %s", gloves[0].Value)
+		}
+	})
+	t.Run("SetItemAttributesInvalid", func(t *testing.T) {
+		tests := []map[uint]string{
+			{1: "high"},
+			{3: "01/03/2027"},
+			{2: "M"},
+		}
+		for _, values := range tests {
+			err := rep.SetItemAttributes(4, values)
+			if err == nil {
+				t.Errorf("No error reported for the values %v", values)
+			}
+		}
+		err := rep.SetItemAttributes(4, map[uint]string{1: "3.7", 3: "soon"})
+		if err == nil {
+			t.Fatalf("No error reported for an invalid date")
+		}
+		attributes, _ := rep.FindItemAttributes(4)
+		if attributes[1].Value != "1.2" {
+			t.Errorf("Valid values of a rejected update were stored: %s", attributes[1].Value)
+		}
+	})
+	t.Run("FindItemsByAttributes", func(t *testing.T) {
+		items1, err1 := rep.FindItemsByAttributes([]AttributeFilter{{Name: "Voltage", Operator: "<", Value: "5"}})
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		if len(items1) != 2 {
+			t.Errorf("Incorrect number of items.\nexpected number: 2 actual number: %d", len(items1))
+		}
+		items2, _ := rep.FindItemsByAttributes([]AttributeFilter{
+			{Name: "voltage", Operator: "<", Value: "5"},
+			{Name: "expiry", Operator: ">=", Value: "2027-01-01"},
+		})
+		if len(items2) != 1 || items2[0].Name != "NiMH batteries" {
+			t.Errorf("Filters weren't combined: %v", items2)
+		}
+		items3, _ := rep.FindItemsByAttributes([]AttributeFilter{{Name: "size", Operator: "=", Value: "m"}})
+		if len(items3) != 1 || items3[0].Name != "gloves" {
+			t.Errorf("Enum filter didn't match regardless of the case: %v", items3)
+		}
+		_, err2 := rep.FindItemsByAttributes([]AttributeFilter{{Name: "size", Operator: ">", Value: "M"}})
+		if err2 == nil {
+			t.Errorf("No error reported when comparing text values")
+		}
+	})
+	t.Run("DeleteAttributeDefinition", func(t *testing.T) {
+		err := rep.DeleteAttributeDefinition(3)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		var count int64
+		rep.DB.Model(&AttributeValue{}).Where("definition_id = ?", 3).Count(&count)
+		if count != 0 {
+			t.Errorf("Values of the deleted attribute weren't removed")
+		}
+	})
+}
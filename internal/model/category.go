@@ -0,0 +1,246 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// DefaultCategory is the category shown for items which don't belong to any Category
+const DefaultCategory = "No category"
+
+// Category is a node of the taxonomy used to classify items. Names are unique regardless of their case.
+type Category struct {
+	ID        uint `gorm:"primaryKey;<-:create;autoIncrement"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string `gorm:"not null"`
+	ParentID  *uint  `gorm:"index"`
+}
+
+// CategoryNode is a category listed in depth-first order together with its depth in the tree and its item counts
+type CategoryNode struct {
+	Category
+	Depth int
+	// items directly in the category
+	ItemCount int
+	// items in the category and in all its subcategories
+	TotalCount int
+}
+
+// categoryDescendants selects the ID of a category and of all its subcategories
+const categoryDescendants = "WITH RECURSIVE tree(id) AS (SELECT id FROM categories WHERE id = ? " +
+	"UNION SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id) SELECT id FROM tree"
+
+// migrateCategories links the items created before the taxonomy existed to the categories matching their
+// free-text category, creating top-level categories for the missing names
+func (r *GORMSQLiteWarehouseRepository) migrateCategories() error {
+	var items []Item
+	err1 := r.DB.Where("category_id IS NULL AND category IS NOT NULL AND category <> '' AND category <> ?", DefaultCategory).
+		Find(&items).Error
+	if err1 != nil {
+		return err1
+	}
+	for _, item := range items {
+		categoryID, name, err2 := r.resolveCategory(item.Category)
+		if err2 != nil {
+			return err2
+		}
+		err3 := r.DB.Model(&item).UpdateColumns(map[string]interface{}{"category_id": categoryID, "category": name}).Error
+		if err3 != nil {
+			return err3
+		}
+	}
+	return nil
+}
+
+// resolveCategory finds the category with the given name ignoring its case, creating it at the top level when missing.
+// It returns the ID and the stored name of the category, or nil and DefaultCategory for an empty name.
+func (r *GORMSQLiteWarehouseRepository) resolveCategory(name string) (*uint, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.EqualFold(name, DefaultCategory) {
+		return nil, DefaultCategory, nil
+	}
+	category, err1 := r.findCategoryByName(name)
+	if errors.Is(err1, gorm.ErrRecordNotFound) {
+		category = Category{Name: name}
+		err1 = r.DB.Create(&category).Error
+	}
+	if err1 != nil {
+		return nil, "", err1
+	}
+	return &category.ID, category.Name, nil
+}
+
+func (r *GORMSQLiteWarehouseRepository) findCategoryByName(name string) (Category, error) {
+	var category Category
+	err := r.DB.Where("LOWER(name) = LOWER(?)", strings.TrimSpace(name)).First(&category).Error
+	return category, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListCategories() ([]CategoryNode, error) {
+	var categories []Category
+	err1 := r.DB.Order("name").Find(&categories).Error
+	if err1 != nil {
+		return nil, err1
+	}
+	var counts []struct {
+		CategoryID uint
+		Count      int
+	}
+	err2 := r.DB.Model(&Item{}).Select("category_id, COUNT(*) AS count").Where("category_id IS NOT NULL").
+		Group("category_id").Scan(&counts).Error
+	if err2 != nil {
+		return nil, err2
+	}
+	itemCounts := make(map[uint]int)
+	for _, count := range counts {
+		itemCounts[count.CategoryID] = count.Count
+	}
+	children := make(map[uint][]Category)
+	roots := make([]Category, 0)
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+	res := make([]CategoryNode, 0, len(categories))
+	// visit appends the subtree of a category and returns the number of items it contains
+	var visit func(category Category, depth int) int
+	visit = func(category Category, depth int) int {
+		position := len(res)
+		res = append(res, CategoryNode{Category: category, Depth: depth, ItemCount: itemCounts[category.ID]})
+		total := itemCounts[category.ID]
+		for _, child := range children[category.ID] {
+			total += visit(child, depth+1)
+		}
+		res[position].TotalCount = total
+		return total
+	}
+	for _, root := range roots {
+		visit(root, 0)
+	}
+	return res, nil
+}
+
+func (r *GORMSQLiteWarehouseRepository) CreateCategory(name string, parentID *uint) error {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.EqualFold(name, DefaultCategory) {
+		return errors.New("invalid category name: \"" + name + "\"")
+	}
+	_, err1 := r.findCategoryByName(name)
+	if err1 == nil {
+		return errors.New("category \"" + name + "\" already exists")
+	}
+	if !errors.Is(err1, gorm.ErrRecordNotFound) {
+		return err1
+	}
+	if parentID != nil {
+		err2 := r.DB.First(&Category{}, *parentID).Error
+		if err2 != nil {
+			return err2
+		}
+	}
+	return r.DB.Create(&Category{Name: name, ParentID: parentID}).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) RenameCategory(categoryID uint, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.EqualFold(name, DefaultCategory) {
+		return errors.New("invalid category name: \"" + name + "\"")
+	}
+	var category Category
+	err1 := r.DB.First(&category, categoryID).Error
+	if err1 != nil {
+		return err1
+	}
+	existing, err2 := r.findCategoryByName(name)
+	if err2 == nil && existing.ID != categoryID {
+		return errors.New("category \"" + name + "\" already exists, merge the categories instead")
+	}
+	if err2 != nil && !errors.Is(err2, gorm.ErrRecordNotFound) {
+		return err2
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err3 := tx.Model(&category).Update("name", name).Error
+		if err3 != nil {
+			return err3
+		}
+		// the name stored in the items is only a copy used for display
+		return tx.Model(&Item{}).Where("category_id = ?", categoryID).UpdateColumn("category", name).Error
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) MoveCategory(categoryID uint, parentID *uint) error {
+	var category Category
+	err1 := r.DB.First(&category, categoryID).Error
+	if err1 != nil {
+		return err1
+	}
+	if parentID != nil {
+		err2 := r.DB.First(&Category{}, *parentID).Error
+		if err2 != nil {
+			return err2
+		}
+		descendants, err3 := r.categoryDescendantIDs(categoryID)
+		if err3 != nil {
+			return err3
+		}
+		for _, descendant := range descendants {
+			if descendant == *parentID {
+				return errors.New("a category can't be moved inside itself or one of its subcategories")
+			}
+		}
+	}
+	return r.DB.Model(&category).Update("parent_id", parentID).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) MergeCategories(sourceID uint, targetID uint) error {
+	if sourceID == targetID {
+		return errors.New("a category can't be merged with itself")
+	}
+	var source, target Category
+	err1 := r.DB.First(&source, sourceID).Error
+	if err1 != nil {
+		return err1
+	}
+	err2 := r.DB.First(&target, targetID).Error
+	if err2 != nil {
+		return err2
+	}
+	descendants, err3 := r.categoryDescendantIDs(sourceID)
+	if err3 != nil {
+		return err3
+	}
+	for _, descendant := range descendants {
+		if descendant == targetID {
+			return errors.New("a category can't be merged into one of its subcategories")
+		}
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err4 := tx.Model(&Item{}).Where("category_id = ?", sourceID).
+			UpdateColumns(map[string]interface{}{"category_id": targetID, "category": target.Name}).Error
+		if err4 != nil {
+			return err4
+		}
+		err5 := tx.Model(&Category{}).Where("parent_id = ?", sourceID).Update("parent_id", targetID).Error
+		if err5 != nil {
+			return err5
+		}
+		err6 := tx.Model(&AttributeDefinition{}).Where("category_id = ?", sourceID).Update("category_id", targetID).Error
+		if err6 != nil {
+			return err6
+		}
+		return tx.Delete(&source).Error
+	})
+}
+
+// categoryDescendantIDs returns the ID of a category followed by the IDs of all its subcategories
+func (r *GORMSQLiteWarehouseRepository) categoryDescendantIDs(categoryID uint) ([]uint, error) {
+	var ids []uint
+	err := r.DB.Raw(categoryDescendants, categoryID).Scan(&ids).Error
+	return ids, err
+}
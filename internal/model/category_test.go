@@ -0,0 +1,110 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestCategory(t *testing.T) {
+	rep := newTestRepository(t, "test_category.db")
+	_ = rep.CreateItem("hammer", "Tools", "steel hammer")
+	_ = rep.CreateItem("screwdriver", "tools", "flat screwdriver")
+	_ = rep.CreateItem("drill", "Power tools", "cordless drill")
+	_ = rep.CreateItem("wrench", "Tool", "adjustable wrench")
+	_ = rep.CreateItem("notebook", "", "paper notebook")
+	categoryID := func(name string) uint {
+		category, err := rep.findCategoryByName(name)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		return category.ID
+	}
+	t.Run("CreateItemResolvesCategory", func(t *testing.T) {
+		screwdriver, _ := rep.FindItemByName("screwdriver")
+		if screwdriver[0].Category != "Tools" || screwdriver[0].CategoryID == nil || *screwdriver[0].CategoryID != categoryID("Tools") {
+			t.Errorf("Category wasn't matched regardless of its case: %q", screwdriver[0].Category)
+		}
+		notebook, _ := rep.FindItemByName("notebook")
+		if notebook[0].Category != DefaultCategory || notebook[0].CategoryID != nil {
+			t.Errorf("Empty category wasn't stored as %q: %q", DefaultCategory, notebook[0].Category)
+		}
+	})
+	t.Run("MoveCategory", func(t *testing.T) {
+		tools := categoryID("tools")
+		err1 := rep.MoveCategory(categoryID("Power tools"), &tools)
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		powerTools := categoryID("Power tools")
+		err2 := rep.MoveCategory(tools, &powerTools)
+		if err2 == nil {
+			t.Errorf("No error reported when moving a category inside one of its subcategories")
+		}
+	})
+	t.Run("FindItemsByCategoryIncludesSubcategories", func(t *testing.T) {
+		items, err := rep.FindItemsByCategory("TOOLS")
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(items) != 3 {
+			t.Errorf("Incorrect number of items.\nexpected number: 3 actual number: %d", len(items))
+		}
+		uncategorized, _ := rep.FindItemsByCategory(DefaultCategory)
+		if len(uncategorized) != 1 || uncategorized[0].Name != "notebook" {
+			t.Errorf("Items without a category weren't found")
+		}
+	})
+	t.Run("MergeCategories", func(t *testing.T) {
+		err := rep.MergeCategories(categoryID("Tool"), categoryID("Tools"))
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		wrench, _ := rep.FindItemByName("wrench")
+		if wrench[0].Category != "Tools" {
+			t.Errorf("Items weren't moved to the target category: %q", wrench[0].Category)
+		}
+		_, err2 := rep.findCategoryByName("Tool")
+		if err2 == nil {
+			t.Errorf("Source category wasn't deleted")
+		}
+	})
+	t.Run("RenameCategory", func(t *testing.T) {
+		err1 := rep.RenameCategory(categoryID("Tools"), "Hand tools")
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		hammer, _ := rep.FindItemByName("hammer")
+		if hammer[0].Category != "Hand tools" {
+			t.Errorf("Items weren't renamed with their category: %q", hammer[0].Category)
+		}
+		err2 := rep.RenameCategory(categoryID("Hand tools"), "power TOOLS")
+		if err2 == nil {
+			t.Errorf("No error reported when renaming a category with the name of another one")
+		}
+	})
+	t.Run("ListCategories", func(t *testing.T) {
+		nodes, err := rep.ListCategories()
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(nodes) != 2 {
+			t.Fatalf("Incorrect number of categories.\nexpected number: 2 actual number: %d", len(nodes))
+		}
+		if nodes[0].Name != "Hand tools" || nodes[0].Depth != 0 || nodes[0].ItemCount != 3 || nodes[0].TotalCount != 4 {
+			t.Errorf("Incorrect root node: %+v", nodes[0])
+		}
+		if nodes[1].Name != "Power tools" || nodes[1].Depth != 1 || nodes[1].TotalCount != 1 {
+			t.Errorf("Incorrect child node: %+v", nodes[1])
+		}
+	})
+	t.Run("MigrateCategories", func(t *testing.T) {
+		_ = rep.DB.Create(&Item{Name: "saw", Category: "hand TOOLS", Description: "wood saw"}).Error
+		err := rep.migrateCategories()
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		saw, _ := rep.FindItemByName("saw")
+		if saw[0].CategoryID == nil || *saw[0].CategoryID != categoryID("Hand tools") || saw[0].Category != "Hand tools" {
+			t.Errorf("Free-text category wasn't migrated: %q", saw[0].Category)
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// ClassificationBasis chooses what the A/B/C classification ranks the items by
+type ClassificationBasis string
+
+const (
+	// ValueBasis ranks the items by the cost of the consumed units
+	ValueBasis ClassificationBasis = "value"
+	// VolumeBasis ranks the items by the number of consumed units
+	VolumeBasis ClassificationBasis = "volume"
+)
+
+// ClassificationParameters configures the ABC/XYZ classification
+type ClassificationParameters struct {
+	// days of consumption history analysed
+	Days  int
+	Basis ClassificationBasis
+	// AShare and BShare are the cumulative percentages of the consumption covered by the A and by the A and B items
+	AShare float64
+	BShare float64
+	// XLimit and YLimit are the highest coefficients of variation of the weekly demand of X and Y items
+	XLimit float64
+	YLimit float64
+}
+
+// DefaultClassificationParameters returns the parameters used when the user doesn't choose them
+func DefaultClassificationParameters() ClassificationParameters {
+	return ClassificationParameters{Days: 90, Basis: ValueBasis, AShare: 80, BShare: 95, XLimit: 0.5, YLimit: 1}
+}
+
+// ItemClassification is a row of the Pareto breakdown
+type ItemClassification struct {
+	ItemID   uint
+	ItemName string
+	Consumed int
+	UnitCost float64
+	// Value is the consumption according to the basis, either its cost or its units
+	Value float64
+	// Share and CumulativeShare are percentages of the total consumption
+	Share           float64
+	CumulativeShare float64
+	ABCClass        string
+	// WeeklyMean and Variation are the mean weekly demand and its coefficient of variation
+	WeeklyMean float64
+	Variation  float64
+	XYZClass   string
+}
+
+// ClassSummary counts the items of an A/B/C class and their share of the consumption
+type ClassSummary struct {
+	Class      string
+	ItemCount  int
+	ItemShare  float64
+	ValueShare float64
+}
+
+// ClassificationReport is the result of the classification, items ordered by decreasing consumption
+type ClassificationReport struct {
+	Parameters ClassificationParameters
+	TotalValue float64
+	Items      []ItemClassification
+	Classes    []ClassSummary
+}
+
+// validate checks that the parameters describe a meaningful classification
+func (p ClassificationParameters) validate() error {
+	if p.Days < 14 {
+		return errors.New("the classification needs at least 14 days of history")
+	}
+	if p.Basis != ValueBasis && p.Basis != VolumeBasis {
+		return errors.New("unknown classification basis: " + string(p.Basis))
+	}
+	if p.AShare <= 0 || p.AShare >= p.BShare || p.BShare > 100 {
+		return errors.New("the shares of the classes must satisfy 0 < A < B <= 100")
+	}
+	if p.XLimit <= 0 || p.XLimit >= p.YLimit {
+		return errors.New("the variation limits must satisfy 0 < X < Y")
+	}
+	return nil
+}
+
+func (r *GORMSQLiteWarehouseRepository) UpdateItemUnitCost(itemID uint, unitCost float64) error {
+	if unitCost < 0 || math.IsNaN(unitCost) || math.IsInf(unitCost, 0) {
+		return errors.New("invalid unit cost: " + strconv.FormatFloat(unitCost, 'f', -1, 64))
+	}
+	var item Item
+	err := r.DB.First(&item, itemID).Error
+	if err != nil {
+		return err
+	}
+	return r.DB.Model(&item).Update("unit_cost", unitCost).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindClassification(parameters ClassificationParameters) (ClassificationReport, error) {
+	report := ClassificationReport{Parameters: parameters}
+	err1 := parameters.validate()
+	if err1 != nil {
+		return report, err1
+	}
+	items, err2 := r.ListAllItems()
+	if err2 != nil {
+		return report, err2
+	}
+	series, err3 := r.dailyDemand(parameters.Days)
+	if err3 != nil {
+		return report, err3
+	}
+	report.Items = make([]ItemClassification, 0, len(items))
+	for _, item := range items {
+		row := ItemClassification{ItemID: item.ID, ItemName: item.Name, UnitCost: item.UnitCost}
+		total := 0.0
+		for _, consumed := range series[item.ID] {
+			total += consumed
+		}
+		row.Consumed = int(total)
+		row.Value = total
+		if parameters.Basis == ValueBasis {
+			row.Value = total * item.UnitCost
+		}
+		row.WeeklyMean, row.Variation = weeklyVariation(series[item.ID])
+		row.XYZClass = "Z"
+		if row.WeeklyMean > 0 && row.Variation <= parameters.XLimit {
+			row.XYZClass = "X"
+		} else if row.WeeklyMean > 0 && row.Variation <= parameters.YLimit {
+			row.XYZClass = "Y"
+		}
+		report.TotalValue += row.Value
+		report.Items = append(report.Items, row)
+	}
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		return report.Items[i].Value > report.Items[j].Value
+	})
+	report.Classes = []ClassSummary{{Class: "A"}, {Class: "B"}, {Class: "C"}}
+	cumulative := 0.0
+	for i := range report.Items {
+		row := &report.Items[i]
+		// an item belongs to a class when the consumption ranked before it doesn't reach the share of the class yet
+		class := 2
+		if row.Value > 0 && cumulative < parameters.AShare {
+			class = 0
+		} else if row.Value > 0 && cumulative < parameters.BShare {
+			class = 1
+		}
+		if report.TotalValue > 0 {
+			row.Share = row.Value / report.TotalValue * 100
+		}
+		cumulative += row.Share
+		row.CumulativeShare = cumulative
+		row.ABCClass = report.Classes[class].Class
+		report.Classes[class].ItemCount++
+		report.Classes[class].ValueShare += row.Share
+	}
+	for i := range report.Classes {
+		if len(report.Items) > 0 {
+			report.Classes[i].ItemShare = float64(report.Classes[i].ItemCount) / float64(len(report.Items)) * 100
+		}
+	}
+	return report, nil
+}
+
+func (r *GORMSQLiteWarehouseRepository) ClassifyItems(parameters ClassificationParameters) (ClassificationReport, error) {
+	report, err1 := r.FindClassification(parameters)
+	if err1 != nil {
+		return report, err1
+	}
+	err2 := r.DB.Transaction(func(tx *gorm.DB) error {
+		for _, row := range report.Items {
+			err3 := tx.Model(&Item{}).Where("id = ?", row.ItemID).
+				UpdateColumns(map[string]interface{}{"abc_class": row.ABCClass, "xyz_class": row.XYZClass}).Error
+			if err3 != nil {
+				return err3
+			}
+		}
+		return nil
+	})
+	return report, err2
+}
+
+// weeklyVariation sums the daily demand into weeks, the most recent week ending today, and returns the mean weekly
+// demand with its coefficient of variation. The oldest week is dropped when it's incomplete.
+func weeklyVariation(daily []float64) (float64, float64) {
+	weeks := len(daily) / 7
+	if weeks == 0 || daily == nil {
+		return 0, 0
+	}
+	totals := make([]float64, weeks)
+	for i := range totals {
+		end := len(daily) - i*7
+		for _, consumed := range daily[end-7 : end] {
+			totals[i] += consumed
+		}
+	}
+	mean := 0.0
+	for _, total := range totals {
+		mean += total
+	}
+	mean /= float64(weeks)
+	if mean == 0 {
+		return 0, 0
+	}
+	variance := 0.0
+	for _, total := range totals {
+		variance += (total - mean) * (total - mean)
+	}
+	return mean, math.Sqrt(variance/float64(weeks)) / mean
+}
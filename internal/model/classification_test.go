@@ -0,0 +1,83 @@
+package model
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestClassification(t *testing.T) {
+	rep := newTestRepository(t, "test_classification.db")
+	_ = rep.CreateWarehouse("North", "Milan", 10000)
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.CreateItem("helmets", "safety", "yellow helmets")
+	_ = rep.CreateItem("boots", "safety", "steel toe boots")
+	_ = rep.CreateItem("vests", "safety", "reflective vests")
+	// gloves: 10 a day, helmets: 20 on a single day, boots: 1 a day, vests never consumed
+	now := time.Now()
+	for day := 0; day < 28; day++ {
+		rep.DB.Create(&StockMovement{CreatedAt: now.AddDate(0, 0, -day), Kind: ConsumeMovement, ItemID: 1, WarehouseID: 1, Quantity: -10})
+		rep.DB.Create(&StockMovement{CreatedAt: now.AddDate(0, 0, -day), Kind: ConsumeMovement, ItemID: 3, WarehouseID: 1, Quantity: -1})
+	}
+	rep.DB.Create(&StockMovement{CreatedAt: now.AddDate(0, 0, -3), Kind: ConsumeMovement, ItemID: 2, WarehouseID: 1, Quantity: -20})
+	_ = rep.UpdateItemUnitCost(1, 1)
+	_ = rep.UpdateItemUnitCost(2, 100)
+	_ = rep.UpdateItemUnitCost(3, 5)
+	parameters := ClassificationParameters{Days: 28, Basis: ValueBasis, AShare: 70, BShare: 90, XLimit: 0.5, YLimit: 1}
+	t.Run("FindClassification", func(t *testing.T) {
+		report, err := rep.FindClassification(parameters)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		// values: helmets 2000, gloves 280, boots 140, vests 0
+		expected := []struct {
+			name  string
+			class string
+		}{{"helmets", "AZ"}, {"gloves", "BX"}, {"boots", "CX"}, {"vests", "CZ"}}
+		if len(report.Items) != len(expected) {
+			t.Fatalf("Incorrect number of items.\nexpected number: %d actual number: %d", len(expected), len(report.Items))
+		}
+		for i, v := range expected {
+			row := report.Items[i]
+			if row.ItemName != v.name || row.ABCClass+row.XYZClass != v.class {
+				t.Errorf("Item wasn't classified correctly\nexpected: %s %s\nactual: %s %s%s", v.name, v.class, row.ItemName, row.ABCClass, row.XYZClass)
+			}
+		}
+		if math.Abs(report.TotalValue-2420) > 1e-9 || math.Abs(report.Items[3].CumulativeShare-100) > 1e-9 {
+			t.Errorf("Shares weren't computed correctly\nexpected: 2420, 100\nactual: %f, %f", report.TotalValue, report.Items[3].CumulativeShare)
+		}
+		if report.Classes[2].ItemCount != 2 || math.Abs(report.Classes[2].ItemShare-50) > 1e-9 {
+			t.Errorf("Class C wasn't summarized correctly: %+v", report.Classes[2])
+		}
+		item, _ := rep.FindItemByID(1)
+		if item.ABCClass != "" {
+			t.Errorf("FindClassification stored the classes")
+		}
+	})
+	t.Run("ClassifyItemsByVolume", func(t *testing.T) {
+		// units: gloves 280, boots 28, helmets 20, vests 0
+		volume := parameters
+		volume.Basis = VolumeBasis
+		_, err := rep.ClassifyItems(volume)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		gloves, _ := rep.FindItemByID(1)
+		boots, _ := rep.FindItemByID(3)
+		if gloves.ABCClass != "A" || gloves.XYZClass != "X" || boots.ABCClass != "B" {
+			t.Errorf("Classes weren't stored correctly\nexpected: AX, B\nactual: %s%s, %s", gloves.ABCClass, gloves.XYZClass, boots.ABCClass)
+		}
+	})
+	t.Run("InvalidParameters", func(t *testing.T) {
+		invalid := parameters
+		invalid.AShare = 95
+		_, err1 := rep.FindClassification(invalid)
+		if err1 == nil {
+			t.Errorf("No error reported when the A share exceeds the B share")
+		}
+		err2 := rep.UpdateItemUnitCost(1, -3)
+		if err2 == nil {
+			t.Errorf("No error reported for a negative unit cost")
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"strconv"
+)
+
+// InconsistencyKind identifies the kind of problem found by the consistency checker
+type InconsistencyKind string
+
+const (
+	MismatchedTotal     InconsistencyKind = "mismatched total"
+	OrphanedAssociation InconsistencyKind = "orphaned association"
+	NegativeQuantity    InconsistencyKind = "negative quantity"
+	OverCapacity        InconsistencyKind = "over capacity"
+)
+
+// Inconsistency describes a problem found in a user database. Expected is the value implied by the association
+// table (or the capacity for over-capacity warehouses) while Actual is the value currently stored.
+type Inconsistency struct {
+	Kind        InconsistencyKind
+	ItemID      uint
+	WarehouseID uint
+	Expected    int
+	Actual      int
+	Description string
+	// Repairable is false for the problems which need a decision by the user, like over-capacity warehouses
+	Repairable bool
+}
+
+// validAssociations selects the warehouse_items rows whose item and warehouse both exist and aren't deleted
+const validAssociations = "JOIN items ON items.id = warehouse_items.item_id AND items.deleted_at IS NULL " +
+	"JOIN warehouses ON warehouses.id = warehouse_items.warehouse_id AND warehouses.deleted_at IS NULL"
+
+func (r *GORMSQLiteWarehouseRepository) CheckConsistency() ([]Inconsistency, error) {
+	res := make([]Inconsistency, 0)
+	checks := []func() ([]Inconsistency, error){
+		r.findOrphanedAssociations,
+		r.findNegativeQuantities,
+		r.findMismatchedTotals,
+		r.findOverCapacityWarehouses,
+	}
+	for _, check := range checks {
+		found, err := check()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, found...)
+	}
+	return res, nil
+}
+
+func (r *GORMSQLiteWarehouseRepository) RepairConsistency() ([]Inconsistency, error) {
+	found, err1 := r.CheckConsistency()
+	if err1 != nil {
+		return nil, err1
+	}
+	err2 := r.DB.Transaction(func(tx *gorm.DB) error {
+		orphans := tx.Table("warehouse_items").Select("warehouse_items.item_id, warehouse_items.warehouse_id").Joins(validAssociations)
+		err3 := tx.Where("(item_id, warehouse_id) NOT IN (?)", orphans).Delete(&WarehouseItem{}).Error
+		if err3 != nil {
+			return err3
+		}
+		err4 := tx.Model(&WarehouseItem{}).Where("quantity < 0").Update("quantity", 0).Error
+		if err4 != nil {
+			return err4
+		}
+		totals := tx.Table("warehouse_items").Select("COALESCE(SUM(warehouse_items.quantity), 0)").
+			Where("warehouse_items.item_id = items.id")
+		return tx.Model(&Item{}).Where("1 = 1").Update("quantity", totals).Error
+	})
+	if err2 != nil {
+		return nil, err2
+	}
+	return found, nil
+}
+
+// findOrphanedAssociations reports the warehouse_items rows pointing to missing or deleted items and warehouses
+func (r *GORMSQLiteWarehouseRepository) findOrphanedAssociations() ([]Inconsistency, error) {
+	var rows []WarehouseItem
+	err := r.DB.Table("warehouse_items").
+		Select("warehouse_items.item_id, warehouse_items.warehouse_id, warehouse_items.quantity").
+		Joins("LEFT JOIN items ON items.id = warehouse_items.item_id AND items.deleted_at IS NULL").
+		Joins("LEFT JOIN warehouses ON warehouses.id = warehouse_items.warehouse_id AND warehouses.deleted_at IS NULL").
+		Where("items.id IS NULL OR warehouses.id IS NULL").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Inconsistency, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, Inconsistency{
+			Kind:        OrphanedAssociation,
+			ItemID:      row.ItemID,
+			WarehouseID: row.WarehouseID,
+			Expected:    0,
+			Actual:      row.Quantity,
+			Description: "warehouse " + strconv.Itoa(int(row.WarehouseID)) + " stores " + strconv.Itoa(row.Quantity) +
+				" of item " + strconv.Itoa(int(row.ItemID)) + " but one of them is missing or deleted",
+			Repairable: true,
+		})
+	}
+	return res, nil
+}
+
+// findNegativeQuantities reports the associations holding a negative amount of items
+func (r *GORMSQLiteWarehouseRepository) findNegativeQuantities() ([]Inconsistency, error) {
+	var rows []WarehouseItem
+	err := r.DB.Table("warehouse_items").Select("warehouse_items.*").Joins(validAssociations).
+		Where("warehouse_items.quantity < 0").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Inconsistency, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, Inconsistency{
+			Kind:        NegativeQuantity,
+			ItemID:      row.ItemID,
+			WarehouseID: row.WarehouseID,
+			Expected:    0,
+			Actual:      row.Quantity,
+			Description: "warehouse " + strconv.Itoa(int(row.WarehouseID)) + " stores a negative quantity of item " + strconv.Itoa(int(row.ItemID)),
+			Repairable:  true,
+		})
+	}
+	return res, nil
+}
+
+// findMismatchedTotals reports the items whose quantity differs from the sum of the quantities stored in the warehouses.
+// Negative associations count as zero since the repair resets them.
+func (r *GORMSQLiteWarehouseRepository) findMismatchedTotals() ([]Inconsistency, error) {
+	var rows []struct {
+		ID       uint
+		Name     string
+		Quantity int
+		Total    int
+	}
+	totals := r.DB.Table("warehouse_items").Select("warehouse_items.item_id, SUM(MAX(warehouse_items.quantity, 0)) AS total").
+		Joins(validAssociations).Group("warehouse_items.item_id")
+	err := r.DB.Model(&Item{}).Select("items.id, items.name, items.quantity, COALESCE(totals.total, 0) AS total").
+		Joins("LEFT JOIN (?) AS totals ON totals.item_id = items.id", totals).
+		Where("items.quantity <> COALESCE(totals.total, 0)").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Inconsistency, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, Inconsistency{
+			Kind:     MismatchedTotal,
+			ItemID:   row.ID,
+			Expected: row.Total,
+			Actual:   row.Quantity,
+			Description: "item \"" + row.Name + "\" has a total of " + strconv.Itoa(row.Quantity) + " but its warehouses store " +
+				strconv.Itoa(row.Total),
+			Repairable: true,
+		})
+	}
+	return res, nil
+}
+
+// findOverCapacityWarehouses reports the warehouses storing more items than their capacity
+func (r *GORMSQLiteWarehouseRepository) findOverCapacityWarehouses() ([]Inconsistency, error) {
+	var rows []struct {
+		ID       uint
+		Name     string
+		Capacity int
+		Stored   int
+	}
+	err := r.DB.Table("warehouse_items").
+		Select("warehouses.id, warehouses.name, warehouses.capacity, SUM(warehouse_items.quantity) AS stored").
+		Joins(validAssociations).Group("warehouses.id").
+		Having("SUM(warehouse_items.quantity) > warehouses.capacity").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Inconsistency, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, Inconsistency{
+			Kind:        OverCapacity,
+			WarehouseID: row.ID,
+			Expected:    row.Capacity,
+			Actual:      row.Stored,
+			Description: "warehouse \"" + row.Name + "\" stores " + strconv.Itoa(row.Stored) + " items but its capacity is " + strconv.Itoa(row.Capacity),
+			Repairable:  false,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestConsistency(t *testing.T) {
+	rep := newTestRepository(t, "test_consistency.db")
+	_ = rep.CreateWarehouse("North", "Milan", 100)
+	_ = rep.CreateWarehouse("South", "Naples", 100)
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.CreateItem("helmets", "safety", "yellow helmets")
+	_ = rep.SupplyItems(1, 1, 40)
+	_ = rep.SupplyItems(2, 2, 30)
+	t.Run("CheckConsistent", func(t *testing.T) {
+		found, err := rep.CheckConsistency()
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(found) != 0 {
+			t.Errorf("Inconsistencies found in a consistent repository: %v", found)
+		}
+	})
+	rep.DB.Exec("UPDATE items SET quantity = 35 WHERE id = 1")
+	rep.DB.Exec("INSERT INTO warehouse_items (item_id, warehouse_id, quantity) VALUES (99, 1, 5)")
+	rep.DB.Exec("INSERT INTO warehouse_items (item_id, warehouse_id, quantity) VALUES (2, 1, -3)")
+	rep.DB.Exec("UPDATE warehouses SET capacity = 20 WHERE id = 2")
+	t.Run("CheckInconsistent", func(t *testing.T) {
+		found, err := rep.CheckConsistency()
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		expected := []InconsistencyKind{OrphanedAssociation, NegativeQuantity, MismatchedTotal, OverCapacity}
+		if len(found) != len(expected) {
+			t.Fatalf("Incorrect number of inconsistencies found.\nexpected number: %d actual number: %d\n%v", len(expected), len(found), found)
+		}
+		for i, v := range found {
+			if v.Kind != expected[i] {
+				t.Errorf("Incorrect inconsistency found\nexpected kind: %s actual kind: %s", expected[i], v.Kind)
+			}
+		}
+		if found[2].ItemID != 1 || found[2].Expected != 40 || found[2].Actual != 35 {
+			t.Errorf("Incorrect mismatched total reported\nexpected- item: 1 expected: 40 actual: 35\nactual- item: %d expected: %d actual: %d", found[2].ItemID, found[2].Expected, found[2].Actual)
+		}
+	})
+	t.Run("RepairConsistency", func(t *testing.T) {
+		_, err := rep.RepairConsistency()
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		found, err2 := rep.CheckConsistency()
+		if err2 != nil {
+			t.Fatalf("Reported error: %v", err2)
+		}
+		if len(found) != 1 || found[0].Kind != OverCapacity {
+			t.Errorf("Only the over capacity warehouse should be left after the repair, found: %v", found)
+		}
+		gloves, _ := rep.FindItemByID(1)
+		if gloves.Quantity != 40 {
+			t.Errorf("Item total wasn't recomputed\nexpected quantity: 40\nactual quantity: %d", gloves.Quantity)
+		}
+		var orphans int64
+		rep.DB.Model(&WarehouseItem{}).Where("item_id = ?", 99).Count(&orphans)
+		if orphans != 0 {
+			t.Errorf("Orphaned association wasn't removed")
+		}
+	})
+}
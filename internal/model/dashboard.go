@@ -0,0 +1,71 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// WarehouseUtilization is the number of items stored in a warehouse compared to its capacity
+type WarehouseUtilization struct {
+	WarehouseID uint
+	Name        string
+	Capacity    int
+	Stored      int
+}
+
+// ConsumedItem is the number of units of an item consumed during a period
+type ConsumedItem struct {
+	ItemID   uint
+	Name     string
+	Consumed int
+}
+
+// CategoryValue is the stock of the items of a category and its value according to their unit cost
+type CategoryValue struct {
+	Category string
+	Quantity int
+	Value    float64
+}
+
+// Dashboard collects the figures shown on the home page
+type Dashboard struct {
+	Utilization     []WarehouseUtilization
+	TopConsumed     []ConsumedItem
+	CategoryValues  []CategoryValue
+	RecentMovements []MovementEntry
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindDashboard(days int, limit int) (Dashboard, error) {
+	var dashboard Dashboard
+	if days <= 0 || limit <= 0 {
+		return dashboard, errors.New("days and limit must be positive")
+	}
+	err1 := r.DB.Model(&Warehouse{}).
+		Select("warehouses.id AS warehouse_id, warehouses.name, warehouses.capacity, COALESCE(SUM(warehouse_items.quantity), 0) AS stored").
+		Joins("LEFT JOIN warehouse_items ON warehouse_items.warehouse_id = warehouses.id").
+		Group("warehouses.id").Order("warehouses.name").Scan(&dashboard.Utilization).Error
+	if err1 != nil {
+		return dashboard, err1
+	}
+	since := time.Now().AddDate(0, 0, -days)
+	err2 := r.DB.Table("stock_movements").
+		Select("items.id AS item_id, items.name, -SUM(stock_movements.quantity) AS consumed").
+		Joins("JOIN items ON items.id = stock_movements.item_id AND items.deleted_at IS NULL").
+		Where("stock_movements.kind IN ? AND stock_movements.quantity < 0 AND stock_movements.created_at >= ?", demandMovements, since).
+		Group("items.id").Order("consumed DESC").Limit(limit).Scan(&dashboard.TopConsumed).Error
+	if err2 != nil {
+		return dashboard, err2
+	}
+	err3 := r.DB.Model(&Item{}).
+		Select("category, SUM(quantity) AS quantity, SUM(quantity * unit_cost) AS value").
+		Group("category").Order("value DESC, quantity DESC").Scan(&dashboard.CategoryValues).Error
+	if err3 != nil {
+		return dashboard, err3
+	}
+	recent, err4 := r.FindRecentMovements(limit)
+	if err4 != nil {
+		return dashboard, err4
+	}
+	dashboard.RecentMovements = recent
+	return dashboard, nil
+}
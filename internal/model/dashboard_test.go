@@ -0,0 +1,42 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestDashboard(t *testing.T) {
+	rep := newTestRepository(t, "test_dashboard.db")
+	_ = rep.CreateWarehouse("North", "Milan", 100)
+	_ = rep.CreateWarehouse("South", "Naples", 50)
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.CreateItem("helmets", "safety", "yellow helmets")
+	_ = rep.CreateItem("cables", "electronics", "network cables")
+	_ = rep.UpdateItemUnitCost(1, 2)
+	_ = rep.UpdateItemUnitCost(2, 10)
+	_ = rep.UpdateItemUnitCost(3, 1)
+	_ = rep.SupplyItems(1, 1, 60)
+	_ = rep.SupplyItems(2, 1, 10)
+	_ = rep.SupplyItems(3, 2, 40)
+	_ = rep.ConsumeItems(1, 1, 20)
+	_ = rep.ConsumeItems(3, 2, 5)
+	_ = rep.ConsumeItems(3, 2, 5)
+	t.Run("FindDashboard", func(t *testing.T) {
+		dashboard, err := rep.FindDashboard(30, 2)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(dashboard.Utilization) != 2 || dashboard.Utilization[0].Stored != 50 || dashboard.Utilization[1].Stored != 30 {
+			t.Errorf("Utilization wasn't computed correctly: %+v", dashboard.Utilization)
+		}
+		if len(dashboard.TopConsumed) != 2 || dashboard.TopConsumed[0].Name != "gloves" || dashboard.TopConsumed[1].Consumed != 10 {
+			t.Errorf("Top consumed items weren't computed correctly: %+v", dashboard.TopConsumed)
+		}
+		if len(dashboard.CategoryValues) != 2 || dashboard.CategoryValues[0].Category != "safety" || dashboard.CategoryValues[0].Value != 180 {
+			t.Errorf("Stock value by category wasn't computed correctly: %+v", dashboard.CategoryValues)
+		}
+		if len(dashboard.RecentMovements) != 2 || dashboard.RecentMovements[0].ItemName != "cables" ||
+			dashboard.RecentMovements[0].WarehouseName != "South" || dashboard.RecentMovements[0].Quantity != -5 {
+			t.Errorf("Recent movements weren't found correctly: %+v", dashboard.RecentMovements)
+		}
+	})
+}
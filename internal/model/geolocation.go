@@ -0,0 +1,100 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// WarehouseLocation holds the optional coordinates and the structured address of a warehouse
+type WarehouseLocation struct {
+	Latitude   *float64
+	Longitude  *float64
+	Street     string
+	City       string
+	PostalCode string
+	Country    string
+}
+
+// HasCoordinates reports whether both latitude and longitude are known
+func (l WarehouseLocation) HasCoordinates() bool {
+	return l.Latitude != nil && l.Longitude != nil
+}
+
+// NearbyStock is a LoadedItemPack of a warehouse with known coordinates, together with its distance from a point
+type NearbyStock struct {
+	LoadedItemPack
+	Latitude   float64
+	Longitude  float64
+	DistanceKm float64
+}
+
+// mean radius of the Earth used by the haversine formula
+const earthRadiusKm = 6371.0
+
+// haversineDistance returns the great-circle distance in kilometres between two points given in degrees
+func haversineDistance(latitude1 float64, longitude1 float64, latitude2 float64, longitude2 float64) float64 {
+	toRadians := func(degrees float64) float64 {
+		return degrees * math.Pi / 180
+	}
+	deltaLatitude := toRadians(latitude2 - latitude1)
+	deltaLongitude := toRadians(longitude2 - longitude1)
+	a := math.Sin(deltaLatitude/2)*math.Sin(deltaLatitude/2) +
+		math.Cos(toRadians(latitude1))*math.Cos(toRadians(latitude2))*math.Sin(deltaLongitude/2)*math.Sin(deltaLongitude/2)
+	return 2 * earthRadiusKm * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// validateCoordinates checks that a point lies within the valid ranges of latitude and longitude
+func validateCoordinates(latitude float64, longitude float64) error {
+	if latitude < -90 || latitude > 90 {
+		return errors.New("latitude must be between -90 and 90")
+	}
+	if longitude < -180 || longitude > 180 {
+		return errors.New("longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+func (r *GORMSQLiteWarehouseRepository) UpdateWarehouseLocation(warehouseID uint, location WarehouseLocation) error {
+	if (location.Latitude == nil) != (location.Longitude == nil) {
+		return errors.New("latitude and longitude must be given together")
+	}
+	if location.HasCoordinates() {
+		err1 := validateCoordinates(*location.Latitude, *location.Longitude)
+		if err1 != nil {
+			return err1
+		}
+	}
+	var warehouse Warehouse
+	err2 := r.DB.First(&warehouse, warehouseID).Error
+	if err2 != nil {
+		return err2
+	}
+	return r.DB.Model(&warehouse).Select("latitude", "longitude", "street", "city", "postal_code", "country").
+		Updates(Warehouse{Location: location}).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindNearestStock(itemID uint, minQuantity int, latitude float64, longitude float64) ([]NearbyStock, error) {
+	err1 := validateCoordinates(latitude, longitude)
+	if err1 != nil {
+		return nil, err1
+	}
+	var res []NearbyStock
+	err2 := r.DB.Table("warehouse_items").Select("warehouse_items.item_id, items.name AS item_name, "+
+		"warehouse_items.quantity AS item_quantity, warehouse_items.warehouse_id, warehouses.name AS warehouse_name, "+
+		"warehouses.position AS warehouse_position, warehouses.capacity AS warehouse_capacity, "+
+		"warehouses.latitude, warehouses.longitude").Joins(validAssociations).
+		Where("warehouse_items.item_id = ? AND warehouse_items.quantity >= ?", itemID, minQuantity).
+		Where("warehouses.latitude IS NOT NULL AND warehouses.longitude IS NOT NULL").
+		Scan(&res).Error
+	if err2 != nil {
+		return nil, err2
+	}
+	for i := range res {
+		res[i].DistanceKm = haversineDistance(latitude, longitude, res[i].Latitude, res[i].Longitude)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].DistanceKm < res[j].DistanceKm
+	})
+	return res, nil
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGeolocation(t *testing.T) {
+	rep := newTestRepository(t, "test_geolocation.db")
+	_ = rep.CreateWarehouse("Florence", "Tuscany", 100)
+	_ = rep.CreateWarehouse("Pisa", "Tuscany", 100)
+	_ = rep.CreateWarehouse("Rome", "Lazio", 100)
+	_ = rep.CreateWarehouse("Unknown", "nowhere", 100)
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	coordinates := func(latitude float64, longitude float64) WarehouseLocation {
+		return WarehouseLocation{Latitude: &latitude, Longitude: &longitude}
+	}
+	t.Run("haversineDistance", func(t *testing.T) {
+		distance := haversineDistance(43.7696, 11.2558, 43.7228, 10.4017)
+		if math.Abs(distance-68.7) > 1 {
+			t.Errorf("Incorrect distance between Florence and Pisa\nexpected distance: ~68.7 km\nactual distance: %f", distance)
+		}
+	})
+	t.Run("UpdateWarehouseLocation", func(t *testing.T) {
+		location := coordinates(43.7696, 11.2558)
+		location.City = "Florence"
+		location.Country = "Italy"
+		err := rep.UpdateWarehouseLocation(1, location)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		warehouse, _ := rep.FindWarehouseByID(1)
+		if !warehouse.Location.HasCoordinates() || *warehouse.Location.Latitude != 43.7696 || warehouse.Location.City != "Florence" {
+			t.Errorf("Location wasn't saved correctly: %+v", warehouse.Location)
+		}
+		_ = rep.UpdateWarehouseLocation(2, coordinates(43.7228, 10.4017))
+		_ = rep.UpdateWarehouseLocation(3, coordinates(41.9028, 12.4964))
+	})
+	t.Run("UpdateWarehouseLocationInvalid", func(t *testing.T) {
+		latitude := 45.0
+		err1 := rep.UpdateWarehouseLocation(4, WarehouseLocation{Latitude: &latitude})
+		if err1 == nil {
+			t.Errorf("No error reported when only the latitude is given")
+		}
+		err2 := rep.UpdateWarehouseLocation(4, coordinates(95, 10))
+		if err2 == nil {
+			t.Errorf("No error reported for an out of range latitude")
+		}
+	})
+	t.Run("FindNearestStock", func(t *testing.T) {
+		_ = rep.SupplyItems(1, 1, 10)
+		_ = rep.SupplyItems(1, 2, 30)
+		_ = rep.SupplyItems(1, 3, 50)
+		_ = rep.SupplyItems(1, 4, 90)
+		// a point in Livorno, closer to Pisa than to Florence
+		res, err := rep.FindNearestStock(1, 20, 43.5485, 10.3106)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(res) != 2 {
+			t.Fatalf("Incorrect number of warehouses.\nexpected number: 2 actual number: %d", len(res))
+		}
+		if res[0].WarehouseName != "Pisa" || res[1].WarehouseName != "Rome" {
+			t.Errorf("Warehouses aren't ordered by distance\nexpected order: Pisa, Rome\nactual order: %s, %s",
+				res[0].WarehouseName, res[1].WarehouseName)
+		}
+		if res[0].DistanceKm > res[1].DistanceKm || res[0].ItemQuantity != 30 {
+			t.Errorf("Incorrect result: %+v", res[0])
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"strconv"
+)
+
+// BOMLine is a line of the bill of materials of a kit: the quantity of a component needed to assemble one kit
+type BOMLine struct {
+	KitID       uint `gorm:"primaryKey"`
+	ComponentID uint `gorm:"primaryKey;index"`
+	Quantity    int  `gorm:"not null"`
+}
+
+// BOMComponent is a line of a bill of materials together with the name and the total stock of the component
+type BOMComponent struct {
+	ComponentID   uint
+	ComponentName string
+	Quantity      int
+	// Stock is the available quantity of the component, the one which can be used to assemble kits
+	Stock int
+}
+
+// BuildableStock tells how many kits can be assembled from the components in stock
+type BuildableStock struct {
+	PerWarehouse []WarehouseBuildable
+	// Total is the sum of the kits buildable in each warehouse without moving any component
+	Total int
+	// WithTransfers is the number of kits buildable after gathering all the components in a single warehouse
+	WithTransfers int
+}
+
+// WarehouseBuildable is the number of kits which can be assembled with the components stored in a warehouse
+type WarehouseBuildable struct {
+	WarehouseID   uint
+	WarehouseName string
+	Quantity      int
+}
+
+// kitComponents selects the items contained, directly or through other kits, in a kit
+const kitComponents = "WITH RECURSIVE tree(id) AS (SELECT component_id FROM bom_lines WHERE kit_id = ? " +
+	"UNION SELECT bom_lines.component_id FROM bom_lines JOIN tree ON bom_lines.kit_id = tree.id) SELECT id FROM tree"
+
+func (r *GORMSQLiteWarehouseRepository) SetBOMLine(kitID uint, componentID uint, quantity int) error {
+	if kitID == componentID {
+		return errors.New("a kit can't be a component of itself")
+	}
+	err1 := r.DB.First(&Item{}, kitID).Error
+	if err1 != nil {
+		return err1
+	}
+	err2 := r.DB.First(&Item{}, componentID).Error
+	if err2 != nil {
+		return err2
+	}
+	if quantity <= 0 {
+		return r.DB.Where("kit_id = ? AND component_id = ?", kitID, componentID).Delete(&BOMLine{}).Error
+	}
+	var contained []uint
+	err3 := r.DB.Raw(kitComponents, componentID).Scan(&contained).Error
+	if err3 != nil {
+		return err3
+	}
+	for _, id := range contained {
+		if id == kitID {
+			return errors.New("the component already contains the kit")
+		}
+	}
+	return r.DB.Save(&BOMLine{KitID: kitID, ComponentID: componentID, Quantity: quantity}).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindBOM(kitID uint) ([]BOMComponent, error) {
+	var res []BOMComponent
+	err := r.DB.Table("bom_lines").
+		Select("bom_lines.component_id, items.name AS component_name, bom_lines.quantity, items.quantity - "+
+			"COALESCE((SELECT SUM(quantity) FROM stock_status_buckets WHERE item_id = bom_lines.component_id), 0) AS stock").
+		Joins("JOIN items ON items.id = bom_lines.component_id AND items.deleted_at IS NULL").
+		Where("bom_lines.kit_id = ?", kitID).Order("items.name").Scan(&res).Error
+	return res, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindBuildableQuantity(kitID uint) (BuildableStock, error) {
+	var res BuildableStock
+	components, err1 := r.FindBOM(kitID)
+	if err1 != nil {
+		return res, err1
+	}
+	if len(components) == 0 {
+		return res, nil
+	}
+	warehouses, err2 := r.ListAllWarehouses()
+	if err2 != nil {
+		return res, err2
+	}
+	stock := make(map[uint]map[uint]int)
+	for _, component := range components {
+		packs, err3 := r.FindWarehousesForItem(component.ComponentID)
+		if err3 != nil {
+			return res, err3
+		}
+		stock[component.ComponentID] = make(map[uint]int)
+		for _, pack := range packs {
+			available, err4 := r.availableQuantity(component.ComponentID, pack.WarehouseID)
+			if err4 != nil {
+				return res, err4
+			}
+			stock[component.ComponentID][pack.WarehouseID] = available
+		}
+	}
+	for _, warehouse := range warehouses {
+		buildable := -1
+		for _, component := range components {
+			fromComponent := stock[component.ComponentID][warehouse.ID] / component.Quantity
+			if buildable < 0 || fromComponent < buildable {
+				buildable = fromComponent
+			}
+		}
+		if buildable > 0 {
+			res.PerWarehouse = append(res.PerWarehouse, WarehouseBuildable{WarehouseID: warehouse.ID, WarehouseName: warehouse.Name, Quantity: buildable})
+			res.Total += buildable
+		}
+	}
+	res.WithTransfers = -1
+	for _, component := range components {
+		fromComponent := component.Stock / component.Quantity
+		if res.WithTransfers < 0 || fromComponent < res.WithTransfers {
+			res.WithTransfers = fromComponent
+		}
+	}
+	return res, nil
+}
+
+func (r *GORMSQLiteWarehouseRepository) AssembleKits(kitID uint, warehouseID uint, count int) error {
+	if count <= 0 {
+		return errors.New("quantity must be greater than 0")
+	}
+	components, err1 := r.FindBOM(kitID)
+	if err1 != nil {
+		return err1
+	}
+	if len(components) == 0 {
+		return errors.New("item has no bill of materials")
+	}
+	// components are consumed first so that the space they free is available to the kits
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		for _, component := range components {
+			err2 := txRepository.consumeItems(component.ComponentID, warehouseID, component.Quantity*count, AssemblyMovement)
+			if err2 != nil {
+				return errors.New("component \"" + component.ComponentName + "\": " + err2.Error())
+			}
+		}
+		return txRepository.supplyItems(kitID, warehouseID, count, AssemblyMovement)
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) DisassembleKits(kitID uint, warehouseID uint, count int) error {
+	if count <= 0 {
+		return errors.New("quantity must be greater than 0")
+	}
+	components, err1 := r.FindBOM(kitID)
+	if err1 != nil {
+		return err1
+	}
+	if len(components) == 0 {
+		return errors.New("item has no bill of materials")
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		err2 := txRepository.consumeItems(kitID, warehouseID, count, DisassemblyMovement)
+		if err2 != nil {
+			return err2
+		}
+		for _, component := range components {
+			err3 := txRepository.supplyItems(component.ComponentID, warehouseID, component.Quantity*count, DisassemblyMovement)
+			if err3 != nil {
+				return errors.New("component \"" + component.ComponentName + "\" (" + strconv.Itoa(component.Quantity*count) + "): " + err3.Error())
+			}
+		}
+		return nil
+	})
+}
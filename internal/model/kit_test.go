@@ -0,0 +1,88 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestKit(t *testing.T) {
+	rep := newTestRepository(t, "test_kit.db")
+	_ = rep.CreateWarehouse("North", "Milan", 100)
+	_ = rep.CreateWarehouse("South", "Naples", 100)
+	_ = rep.CreateItem("first-aid kit", "safety", "complete kit")
+	_ = rep.CreateItem("bandage", "medical", "elastic bandage")
+	_ = rep.CreateItem("gauze", "medical", "sterile gauze")
+	_ = rep.SupplyItems(2, 1, 10)
+	_ = rep.SupplyItems(3, 1, 7)
+	_ = rep.SupplyItems(2, 2, 2)
+	_ = rep.SupplyItems(3, 2, 5)
+	t.Run("SetBOMLine", func(t *testing.T) {
+		err1 := rep.SetBOMLine(1, 2, 2)
+		err2 := rep.SetBOMLine(1, 3, 3)
+		if err1 != nil || err2 != nil {
+			t.Fatalf("Reported errors: %v, %v", err1, err2)
+		}
+		err3 := rep.SetBOMLine(2, 1, 1)
+		if err3 == nil {
+			t.Errorf("No error reported when a component would contain its kit")
+		}
+		components, _ := rep.FindBOM(1)
+		if len(components) != 2 || components[0].ComponentName != "bandage" || components[0].Stock != 12 {
+			t.Errorf("Incorrect bill of materials: %+v", components)
+		}
+	})
+	t.Run("FindBuildableQuantity", func(t *testing.T) {
+		buildable, err := rep.FindBuildableQuantity(1)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		// North: min(10/2, 7/3) = 2, South: min(2/2, 5/3) = 1, gathered: min(12/2, 12/3) = 4
+		if buildable.Total != 3 || buildable.WithTransfers != 4 || len(buildable.PerWarehouse) != 2 {
+			t.Errorf("Incorrect buildable quantity: %+v", buildable)
+		}
+	})
+	t.Run("AssembleKits", func(t *testing.T) {
+		err1 := rep.AssembleKits(1, 1, 3)
+		if err1 == nil {
+			t.Errorf("No error reported when the components aren't enough")
+		}
+		bandage, _ := rep.FindItemByID(2)
+		if bandage.Quantity != 12 {
+			t.Errorf("Components of a failed assembly were consumed\nexpected quantity: 12\nactual quantity: %d", bandage.Quantity)
+		}
+		err2 := rep.AssembleKits(1, 1, 2)
+		if err2 != nil {
+			t.Fatalf("Reported error: %v", err2)
+		}
+		kit, _ := rep.FindItemByID(1)
+		gauze, _ := rep.FindItemByID(3)
+		if kit.Quantity != 2 || gauze.Quantity != 6 {
+			t.Errorf("Kits weren't assembled correctly\nexpected quantities: 2, 6\nactual quantities: %d, %d", kit.Quantity, gauze.Quantity)
+		}
+	})
+	t.Run("DisassembleKits", func(t *testing.T) {
+		err := rep.DisassembleKits(1, 1, 1)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		kit, _ := rep.FindItemByID(1)
+		bandage, _ := rep.FindItemByID(2)
+		if kit.Quantity != 1 || bandage.Quantity != 10 {
+			t.Errorf("Kits weren't disassembled correctly\nexpected quantities: 1, 10\nactual quantities: %d, %d", kit.Quantity, bandage.Quantity)
+		}
+	})
+	t.Run("FindBuildableQuantityAvailableOnly", func(t *testing.T) {
+		_ = rep.ChangeStockStatus(3, 2, 3, AvailableStatus, QuarantinedStatus)
+		components, _ := rep.FindBOM(1)
+		if len(components) != 2 || components[1].ComponentName != "gauze" || components[1].Stock != 6 {
+			t.Errorf("Quarantined components were counted: %+v", components)
+		}
+		buildable, err := rep.FindBuildableQuantity(1)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		// North: min(8/2, 4/3) = 1, South: min(2/2, 2/3) = 0, gathered: min(10/2, 6/3) = 2
+		if buildable.Total != 1 || buildable.WithTransfers != 2 || len(buildable.PerWarehouse) != 1 {
+			t.Errorf("Incorrect buildable quantity: %+v", buildable)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoanedStatus is the status of the stock checked out to a person. It isn't part of StockStatuses since only
+// checkouts and check-ins move stock in and out of it.
+const LoanedStatus StockStatus = "on loan"
+
+// Loan is a quantity of an item, or a single serialized unit, checked out from a warehouse to a borrower. The loaned
+// stock still belongs to the warehouse it came from but isn't available until it is checked in.
+type Loan struct {
+	ID          uint `gorm:"primaryKey;<-:create;autoIncrement"`
+	CreatedAt   time.Time
+	ItemID      uint   `gorm:"not null;index"`
+	WarehouseID uint   `gorm:"not null"`
+	Quantity    int    `gorm:"not null"`
+	Borrower    string `gorm:"not null"`
+	// SerialNumber identifies the unit checked out, empty when the loan is a plain quantity
+	SerialNumber string
+	DueAt        time.Time `gorm:"not null;index"`
+	// ReturnedAt and ReturnWarehouseID are set when the loan is checked in
+	ReturnedAt        *time.Time `gorm:"index"`
+	ReturnWarehouseID uint
+}
+
+// Overdue reports whether the loan is still open after its due date
+func (l Loan) Overdue(now time.Time) bool {
+	return l.ReturnedAt == nil && now.After(l.DueAt)
+}
+
+// LoanEntry is a Loan together with the names of its item and warehouses
+type LoanEntry struct {
+	Loan
+	ItemName            string
+	WarehouseName       string
+	ReturnWarehouseName string
+}
+
+func (r *GORMSQLiteWarehouseRepository) CheckOutItems(itemID uint, warehouseID uint, quantity int, borrower string, serialNumber string, dueAt time.Time) (Loan, error) {
+	loan := Loan{ItemID: itemID, WarehouseID: warehouseID, Quantity: quantity, Borrower: strings.TrimSpace(borrower),
+		SerialNumber: strings.TrimSpace(serialNumber), DueAt: dueAt}
+	if loan.Borrower == "" {
+		return loan, errors.New("the borrower is required")
+	}
+	if quantity <= 0 {
+		return loan, errors.New("quantity must be greater than 0")
+	}
+	if loan.SerialNumber != "" && quantity != 1 {
+		return loan, errors.New("a serialized unit is checked out alone")
+	}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		if loan.SerialNumber != "" {
+			var open int64
+			err1 := tx.Model(&Loan{}).Where("item_id = ? AND serial_number = ? AND returned_at IS NULL", itemID, loan.SerialNumber).
+				Count(&open).Error
+			if err1 != nil {
+				return err1
+			}
+			if open > 0 {
+				return errors.New("unit " + loan.SerialNumber + " is already checked out")
+			}
+		}
+		available, err2 := txRepository.availableQuantity(itemID, warehouseID)
+		if err2 != nil {
+			return err2
+		}
+		if available < quantity {
+			return errors.New("not enough available items in specified warehouse: " + strconv.Itoa(available) + " < " + strconv.Itoa(quantity))
+		}
+		err3 := txRepository.addToBucket(itemID, warehouseID, LoanedStatus, quantity)
+		if err3 != nil {
+			return err3
+		}
+		return tx.Create(&loan).Error
+	})
+	return loan, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) CheckInLoan(loanID uint, warehouseID uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		var loan Loan
+		err1 := tx.First(&loan, loanID).Error
+		if err1 != nil {
+			return err1
+		}
+		if loan.ReturnedAt != nil {
+			return errors.New("loan " + strconv.Itoa(int(loanID)) + " was already checked in")
+		}
+		err2 := txRepository.addToBucket(loan.ItemID, loan.WarehouseID, LoanedStatus, -loan.Quantity)
+		if err2 != nil {
+			return err2
+		}
+		// a tool returned elsewhere moves to the warehouse receiving it
+		if warehouseID != loan.WarehouseID {
+			note := MovementNote{Note: "loan " + strconv.Itoa(int(loanID)) + " to " + loan.Borrower}
+			err3 := txRepository.withNote(note).consumeItems(loan.ItemID, loan.WarehouseID, loan.Quantity, CheckInMovement)
+			if err3 != nil {
+				return err3
+			}
+			err4 := txRepository.withNote(note).supplyItems(loan.ItemID, warehouseID, loan.Quantity, CheckInMovement)
+			if err4 != nil {
+				return err4
+			}
+		}
+		now := time.Now()
+		return tx.Model(&loan).Updates(map[string]interface{}{"returned_at": &now, "return_warehouse_id": warehouseID}).Error
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListLoans(open bool) ([]LoanEntry, error) {
+	var res []LoanEntry
+	query := r.DB.Table("loans").
+		Select("loans.*, items.name AS item_name, warehouses.name AS warehouse_name, returns.name AS return_warehouse_name").
+		Joins("LEFT JOIN items ON items.id = loans.item_id").
+		Joins("LEFT JOIN warehouses ON warehouses.id = loans.warehouse_id").
+		Joins("LEFT JOIN warehouses AS returns ON returns.id = loans.return_warehouse_id")
+	if open {
+		query = query.Where("loans.returned_at IS NULL").Order("loans.due_at")
+	} else {
+		query = query.Where("loans.returned_at IS NOT NULL").Order("loans.returned_at DESC")
+	}
+	err := query.Scan(&res).Error
+	return res, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindOverdueLoans(now time.Time) ([]LoanEntry, error) {
+	loans, err := r.ListLoans(true)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]LoanEntry, 0)
+	for _, loan := range loans {
+		if loan.Overdue(now) {
+			res = append(res, loan)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoans(t *testing.T) {
+	rep := newTestRepository(t, "test_loans.db")
+	_ = rep.CreateWarehouse("North", "Milan", 1000)
+	_ = rep.CreateWarehouse("South", "Rome", 1000)
+	_ = rep.CreateItem("drills", "tools", "cordless drills")
+	_ = rep.SupplyItems(1, 1, 5)
+	now := time.Now()
+	t.Run("CheckOutItems", func(t *testing.T) {
+		_, err1 := rep.CheckOutItems(1, 1, 2, "Alice", "", now.AddDate(0, 0, 7))
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		_, err2 := rep.CheckOutItems(1, 1, 1, "Bob", "DR-42", now.AddDate(0, 0, -1))
+		if err2 != nil {
+			t.Fatalf("Reported error: %v", err2)
+		}
+		_, err3 := rep.CheckOutItems(1, 1, 1, "Carol", "DR-42", now.AddDate(0, 0, 7))
+		if err3 == nil || err3.Error() != "unit DR-42 is already checked out" {
+			t.Errorf("unexpected error: %v", err3)
+		}
+		_, err4 := rep.CheckOutItems(1, 1, 2, "Carol", "DR-43", now.AddDate(0, 0, 7))
+		if err4 == nil || err4.Error() != "a serialized unit is checked out alone" {
+			t.Errorf("unexpected error: %v", err4)
+		}
+		_, err5 := rep.CheckOutItems(1, 1, 3, "Carol", "", now.AddDate(0, 0, 7))
+		if err5 == nil || err5.Error() != "not enough available items in specified warehouse: 2 < 3" {
+			t.Errorf("unexpected error: %v", err5)
+		}
+		// loaned items are still owned but can't be consumed
+		err6 := rep.ConsumeItems(1, 1, 3)
+		if err6 == nil {
+			t.Errorf("Loaned items were consumed")
+		}
+		statuses, _ := rep.FindStockStatuses(1, 1)
+		if len(statuses) != 1 || statuses[0].Total != 5 || statuses[0].OnLoan != 3 || statuses[0].Available != 2 {
+			t.Errorf("Loaned stock wasn't tracked: %+v", statuses)
+		}
+	})
+	t.Run("FindOverdueLoans", func(t *testing.T) {
+		loans, err := rep.FindOverdueLoans(now)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(loans) != 1 || loans[0].Borrower != "Bob" || loans[0].ItemName != "drills" {
+			t.Errorf("Overdue loans weren't found: %+v", loans)
+		}
+	})
+	t.Run("CheckInLoan", func(t *testing.T) {
+		err1 := rep.CheckInLoan(2, 2)
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		err2 := rep.CheckInLoan(2, 2)
+		if err2 == nil || err2.Error() != "loan 2 was already checked in" {
+			t.Errorf("unexpected error: %v", err2)
+		}
+		north, _ := rep.FindStockStatuses(1, 1)
+		south, _ := rep.FindStockStatuses(1, 2)
+		if len(north) != 1 || north[0].Total != 4 || north[0].OnLoan != 2 || len(south) != 1 || south[0].Available != 1 {
+			t.Errorf("Checked in unit wasn't moved: %+v %+v", north, south)
+		}
+		open, _ := rep.ListLoans(true)
+		returned, _ := rep.ListLoans(false)
+		if len(open) != 1 || len(returned) != 1 || returned[0].ReturnWarehouseName != "South" {
+			t.Errorf("Loans weren't listed: %+v %+v", open, returned)
+		}
+		// a loan of the serialized unit can be opened again once returned
+		_, err3 := rep.CheckOutItems(1, 2, 1, "Carol", "DR-42", now.AddDate(0, 0, 7))
+		if err3 != nil {
+			t.Errorf("Reported error: %v", err3)
+		}
+	})
+}
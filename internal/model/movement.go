@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+)
+
+// MovementKind identifies the operation which moved some stock
+type MovementKind string
+
+const (
+	SupplyMovement         MovementKind = "supply"
+	ConsumeMovement        MovementKind = "consume"
+	TransferMovement       MovementKind = "transfer"
+	AssemblyMovement       MovementKind = "assembly"
+	DisassemblyMovement    MovementKind = "disassembly"
+	DispatchMovement       MovementKind = "dispatch"
+	ReceiptMovement        MovementKind = "receipt"
+	CustomerReturnMovement MovementKind = "customer return"
+	SupplierReturnMovement MovementKind = "supplier return"
+	ScrapMovement          MovementKind = "scrap"
+	WriteOffMovement       MovementKind = "write-off"
+	CheckInMovement        MovementKind = "check-in"
+)
+
+// StockMovement is an entry of the stock history: the quantity of an item entering (positive) or leaving (negative)
+// a warehouse. Transfers are recorded as two movements, one per warehouse.
+type StockMovement struct {
+	ID          uint         `gorm:"primaryKey;<-:create;autoIncrement"`
+	CreatedAt   time.Time    `gorm:"index"`
+	Kind        MovementKind `gorm:"not null"`
+	ItemID      uint         `gorm:"not null;index"`
+	WarehouseID uint         `gorm:"not null;index"`
+	Quantity    int          `gorm:"not null"`
+	// Reason is a reason code and Note a free text explaining the movement, both optional
+	Reason string `gorm:"index"`
+	Note   string
+	// ProjectID is the project charged by a consumption
+	ProjectID *uint `gorm:"index"`
+	// UnitCost is the cost of a unit of the item when the movement was recorded, nil for the older movements
+	UnitCost *float64
+}
+
+// recordMovement appends a movement to the stock history with the note of the repository, taking a snapshot of the
+// stock every snapshotInterval movements. It must be called after the movement has been applied to the warehouse.
+func (r *GORMSQLiteWarehouseRepository) recordMovement(kind MovementKind, itemID uint, warehouseID uint, quantity int) error {
+	var unitCost float64
+	err1 := r.DB.Unscoped().Model(&Item{}).Select("unit_cost").Where("id = ?", itemID).Scan(&unitCost).Error
+	if err1 != nil {
+		return err1
+	}
+	movement := StockMovement{Kind: kind, ItemID: itemID, WarehouseID: warehouseID, Quantity: quantity,
+		Reason: r.note.Reason, Note: r.note.Note, ProjectID: r.note.ProjectID, UnitCost: &unitCost}
+	err2 := r.DB.Create(&movement).Error
+	if err2 != nil {
+		return err2
+	}
+	if movement.ID%snapshotInterval != 0 {
+		return nil
+	}
+	return r.takeInventorySnapshot(&InventorySnapshot{})
+}
+
+// MovementEntry is a StockMovement together with the names of its item and warehouse
+type MovementEntry struct {
+	StockMovement
+	ItemName      string
+	WarehouseName string
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindRecentMovements(limit int) ([]MovementEntry, error) {
+	var res []MovementEntry
+	err := r.DB.Table("stock_movements").
+		Select("stock_movements.*, items.name AS item_name, warehouses.name AS warehouse_name").
+		Joins("LEFT JOIN items ON items.id = stock_movements.item_id").
+		Joins("LEFT JOIN warehouses ON warehouses.id = stock_movements.warehouse_id").
+		Order("stock_movements.id DESC").Limit(limit).Scan(&res).Error
+	return res, err
+}
@@ -0,0 +1,279 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Product groups the items which are variants of the same article, like gloves in different sizes.
+// Dimensions lists the names of the properties distinguishing the variants separated by commas.
+type Product struct {
+	ID          uint `gorm:"primaryKey;<-:create;autoIncrement"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string `gorm:"unique;not null"`
+	Description string
+	Dimensions  string `gorm:"not null"`
+}
+
+// DimensionList returns the names of the dimensions of the product
+func (p Product) DimensionList() []string {
+	res := make([]string, 0)
+	for _, dimension := range strings.Split(p.Dimensions, ",") {
+		if strings.TrimSpace(dimension) != "" {
+			res = append(res, strings.TrimSpace(dimension))
+		}
+	}
+	return res
+}
+
+// VariantOption is the value of a dimension for an item which is a variant of a product
+type VariantOption struct {
+	ItemID    uint   `gorm:"primaryKey"`
+	Dimension string `gorm:"primaryKey"`
+	Value     string `gorm:"not null"`
+}
+
+// ProductSummary is a product together with the number of its variants and their total stock
+type ProductSummary struct {
+	Product
+	VariantCount  int
+	TotalQuantity int
+}
+
+// VariantMatrix shows the stock of every variant of a product in every warehouse storing at least one of them
+type VariantMatrix struct {
+	Product    Product
+	Dimensions []string
+	Warehouses []Warehouse
+	Rows       []VariantRow
+	// stock of all the variants in each warehouse, in the order of Warehouses
+	WarehouseTotals []int
+	Total           int
+	// Pivot crosses the values of the first two dimensions when the product has exactly two of them
+	Pivot *VariantPivot
+}
+
+// VariantRow is a variant with its dimension values and its stock in each warehouse of the matrix
+type VariantRow struct {
+	Item       Item
+	Values     []string
+	Quantities []int
+}
+
+// VariantPivot holds the total stock for each combination of the values of two dimensions, like size and colour
+type VariantPivot struct {
+	RowDimension    string
+	ColumnDimension string
+	RowValues       []string
+	ColumnValues    []string
+	Quantities      [][]int
+}
+
+// parseDimensions normalizes a list of dimension names, rejecting empty and repeated ones
+func parseDimensions(dimensions []string) ([]string, error) {
+	res := make([]string, 0, len(dimensions))
+	seen := make(map[string]bool)
+	for _, dimension := range dimensions {
+		dimension = strings.TrimSpace(dimension)
+		if dimension == "" {
+			continue
+		}
+		if strings.Contains(dimension, ",") {
+			return nil, errors.New("dimension names can't contain commas: " + dimension)
+		}
+		if seen[strings.ToLower(dimension)] {
+			return nil, errors.New("repeated dimension: " + dimension)
+		}
+		seen[strings.ToLower(dimension)] = true
+		res = append(res, dimension)
+	}
+	if len(res) == 0 {
+		return nil, errors.New("a product needs at least one dimension")
+	}
+	return res, nil
+}
+
+func (r *GORMSQLiteWarehouseRepository) CreateProduct(name string, description string, dimensions []string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("product name can't be empty")
+	}
+	parsed, err := parseDimensions(dimensions)
+	if err != nil {
+		return err
+	}
+	return r.DB.Create(&Product{Name: strings.TrimSpace(name), Description: description, Dimensions: strings.Join(parsed, ",")}).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindProductByID(productID uint) (Product, error) {
+	var product Product
+	err := r.DB.First(&product, productID).Error
+	return product, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListProducts() ([]ProductSummary, error) {
+	var res []ProductSummary
+	err := r.DB.Model(&Product{}).
+		Select("products.*, COUNT(items.id) AS variant_count, COALESCE(SUM(items.quantity), 0) AS total_quantity").
+		Joins("LEFT JOIN items ON items.product_id = products.id AND items.deleted_at IS NULL").
+		Group("products.id").Order("products.name").Scan(&res).Error
+	return res, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) AddVariant(productID uint, itemID uint, values map[string]string) error {
+	product, err1 := r.FindProductByID(productID)
+	if err1 != nil {
+		return err1
+	}
+	var item Item
+	err2 := r.DB.First(&item, itemID).Error
+	if err2 != nil {
+		return err2
+	}
+	if item.ProductID != nil {
+		return errors.New("item \"" + item.Name + "\" is already a variant of a product")
+	}
+	dimensions := product.DimensionList()
+	options := make([]VariantOption, 0, len(dimensions))
+	for _, dimension := range dimensions {
+		value := strings.TrimSpace(values[dimension])
+		if value == "" {
+			return errors.New("missing value for dimension " + dimension)
+		}
+		options = append(options, VariantOption{ItemID: itemID, Dimension: dimension, Value: value})
+	}
+	if len(values) != len(dimensions) {
+		return errors.New("variants need exactly the dimensions " + product.Dimensions)
+	}
+	matrix, err3 := r.FindVariantMatrix(productID)
+	if err3 != nil {
+		return err3
+	}
+	for _, row := range matrix.Rows {
+		same := true
+		for i, option := range options {
+			same = same && strings.EqualFold(row.Values[i], option.Value)
+		}
+		if same {
+			return errors.New("item \"" + row.Item.Name + "\" is already the variant " + strings.Join(row.Values, ", "))
+		}
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err4 := tx.Model(&item).UpdateColumn("product_id", productID).Error
+		if err4 != nil {
+			return err4
+		}
+		return tx.Create(&options).Error
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) RemoveVariant(itemID uint) error {
+	var item Item
+	err1 := r.DB.First(&item, itemID).Error
+	if err1 != nil {
+		return err1
+	}
+	if item.ProductID == nil {
+		return errors.New("item \"" + item.Name + "\" isn't a variant")
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err2 := tx.Where("item_id = ?", itemID).Delete(&VariantOption{}).Error
+		if err2 != nil {
+			return err2
+		}
+		return tx.Model(&item).UpdateColumn("product_id", nil).Error
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindVariantMatrix(productID uint) (VariantMatrix, error) {
+	product, err1 := r.FindProductByID(productID)
+	if err1 != nil {
+		return VariantMatrix{}, err1
+	}
+	matrix := VariantMatrix{Product: product, Dimensions: product.DimensionList()}
+	var items []Item
+	err2 := r.DB.Where("product_id = ?", productID).Order("name").Find(&items).Error
+	if err2 != nil {
+		return matrix, err2
+	}
+	itemIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		itemIDs = append(itemIDs, item.ID)
+	}
+	var options []VariantOption
+	err3 := r.DB.Where("item_id IN ?", itemIDs).Find(&options).Error
+	if err3 != nil {
+		return matrix, err3
+	}
+	var packs []LoadedItemPack
+	err4 := r.loadedItemPacks().Where("warehouse_items.item_id IN ?", itemIDs).
+		Order("warehouses.name").Scan(&packs).Error
+	if err4 != nil {
+		return matrix, err4
+	}
+	optionValues := make(map[uint]map[string]string)
+	for _, option := range options {
+		if optionValues[option.ItemID] == nil {
+			optionValues[option.ItemID] = make(map[string]string)
+		}
+		optionValues[option.ItemID][option.Dimension] = option.Value
+	}
+	columns := make(map[uint]int)
+	for _, pack := range packs {
+		if _, ok := columns[pack.WarehouseID]; !ok {
+			columns[pack.WarehouseID] = len(matrix.Warehouses)
+			matrix.Warehouses = append(matrix.Warehouses, Warehouse{ID: pack.WarehouseID, Name: pack.WarehouseName,
+				Position: pack.WarehousePosition, Capacity: pack.WarehouseCapacity})
+		}
+	}
+	rows := make(map[uint]int)
+	for _, item := range items {
+		row := VariantRow{Item: item, Quantities: make([]int, len(matrix.Warehouses))}
+		for _, dimension := range matrix.Dimensions {
+			row.Values = append(row.Values, optionValues[item.ID][dimension])
+		}
+		rows[item.ID] = len(matrix.Rows)
+		matrix.Rows = append(matrix.Rows, row)
+	}
+	matrix.WarehouseTotals = make([]int, len(matrix.Warehouses))
+	for _, pack := range packs {
+		matrix.Rows[rows[pack.ItemID]].Quantities[columns[pack.WarehouseID]] = pack.ItemQuantity
+		matrix.WarehouseTotals[columns[pack.WarehouseID]] += pack.ItemQuantity
+		matrix.Total += pack.ItemQuantity
+	}
+	sort.SliceStable(matrix.Rows, func(i, j int) bool {
+		return strings.Join(matrix.Rows[i].Values, "\x00") < strings.Join(matrix.Rows[j].Values, "\x00")
+	})
+	if len(matrix.Dimensions) == 2 {
+		matrix.Pivot = buildVariantPivot(matrix)
+	}
+	return matrix, nil
+}
+
+// buildVariantPivot sums the stock of the variants by the values of the two dimensions of the matrix
+func buildVariantPivot(matrix VariantMatrix) *VariantPivot {
+	pivot := &VariantPivot{RowDimension: matrix.Dimensions[0], ColumnDimension: matrix.Dimensions[1]}
+	rowIndex := make(map[string]int)
+	columnIndex := make(map[string]int)
+	for _, row := range matrix.Rows {
+		if _, ok := rowIndex[row.Values[0]]; !ok {
+			rowIndex[row.Values[0]] = len(pivot.RowValues)
+			pivot.RowValues = append(pivot.RowValues, row.Values[0])
+		}
+		if _, ok := columnIndex[row.Values[1]]; !ok {
+			columnIndex[row.Values[1]] = len(pivot.ColumnValues)
+			pivot.ColumnValues = append(pivot.ColumnValues, row.Values[1])
+		}
+	}
+	pivot.Quantities = make([][]int, len(pivot.RowValues))
+	for i := range pivot.Quantities {
+		pivot.Quantities[i] = make([]int, len(pivot.ColumnValues))
+	}
+	for _, row := range matrix.Rows {
+		pivot.Quantities[rowIndex[row.Values[0]]][columnIndex[row.Values[1]]] += row.Item.Quantity
+	}
+	return pivot
+}
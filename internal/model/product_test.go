@@ -0,0 +1,97 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestProduct(t *testing.T) {
+	rep := newTestRepository(t, "test_product.db")
+	_ = rep.CreateWarehouse("North", "Milan", 100)
+	_ = rep.CreateWarehouse("South", "Naples", 100)
+	names := []string{"gloves S red", "gloves M red", "gloves S blue", "gloves M blue", "helmet"}
+	for _, name := range names {
+		_ = rep.CreateItem(name, "safety", name)
+	}
+	_ = rep.SupplyItems(1, 1, 5)
+	_ = rep.SupplyItems(2, 1, 7)
+	_ = rep.SupplyItems(2, 2, 3)
+	_ = rep.SupplyItems(4, 2, 11)
+	_ = rep.SupplyItems(5, 1, 50)
+	t.Run("CreateProduct", func(t *testing.T) {
+		err1 := rep.CreateProduct("gloves", "work gloves", []string{"size", " colour ", ""})
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		product, _ := rep.FindProductByID(1)
+		if product.Dimensions != "size,colour" {
+			t.Errorf("Dimensions weren't normalized: %q", product.Dimensions)
+		}
+		err2 := rep.CreateProduct("helmets", "", []string{"size", "Size"})
+		if err2 == nil {
+			t.Errorf("No error reported for repeated dimensions")
+		}
+	})
+	t.Run("AddVariant", func(t *testing.T) {
+		values := []map[string]string{
+			{"size": "S", "colour": "red"},
+			{"size": "M", "colour": "red"},
+			{"size": "S", "colour": "blue"},
+			{"size": "M", "colour": "blue"},
+		}
+		for i, value := range values {
+			err := rep.AddVariant(1, uint(i+1), value)
+			if err != nil {
+				t.Fatalf("Reported error: %v", err)
+			}
+		}
+		_ = rep.CreateItem("gloves S red bis", "safety", "copy")
+		err1 := rep.AddVariant(1, 6, map[string]string{"size": "s", "colour": "Red"})
+		if err1 == nil {
+			t.Errorf("No error reported for a repeated combination of values")
+		}
+		err2 := rep.AddVariant(1, 6, map[string]string{"size": "L"})
+		if err2 == nil {
+			t.Errorf("No error reported for a missing dimension")
+		}
+	})
+	t.Run("ListProducts", func(t *testing.T) {
+		products, err := rep.ListProducts()
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(products) != 1 || products[0].VariantCount != 4 || products[0].TotalQuantity != 26 {
+			t.Errorf("Incorrect product summary: %+v", products)
+		}
+	})
+	t.Run("FindVariantMatrix", func(t *testing.T) {
+		matrix, err := rep.FindVariantMatrix(1)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(matrix.Warehouses) != 2 || len(matrix.Rows) != 4 || matrix.Total != 26 {
+			t.Fatalf("Incorrect matrix: %+v", matrix)
+		}
+		// rows are sorted by the values of the dimensions: M blue, M red, S blue, S red
+		if matrix.Rows[1].Item.Name != "gloves M red" || matrix.Rows[1].Quantities[0] != 7 || matrix.Rows[1].Quantities[1] != 3 {
+			t.Errorf("Incorrect row: %+v", matrix.Rows[1])
+		}
+		if matrix.WarehouseTotals[0] != 12 || matrix.WarehouseTotals[1] != 14 {
+			t.Errorf("Incorrect warehouse totals: %v", matrix.WarehouseTotals)
+		}
+		if matrix.Pivot == nil || matrix.Pivot.RowValues[0] != "M" || matrix.Pivot.ColumnValues[0] != "blue" ||
+			matrix.Pivot.Quantities[0][0] != 11 || matrix.Pivot.Quantities[0][1] != 10 {
+			t.Errorf("Incorrect pivot: %+v", matrix.Pivot)
+		}
+	})
+	t.Run("RemoveVariant", func(t *testing.T) {
+		err := rep.RemoveVariant(4)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		item, _ := rep.FindItemByID(4)
+		products, _ := rep.ListProducts()
+		if item.ProductID != nil || products[0].VariantCount != 3 || products[0].TotalQuantity != 15 {
+			t.Errorf("Variant wasn't removed: %+v", products[0])
+		}
+	})
+}
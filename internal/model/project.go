@@ -0,0 +1,127 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Project is a project or cost centre which consumptions are charged to. Closed projects can't be charged anymore
+// but keep their history.
+type Project struct {
+	ID        uint `gorm:"primaryKey;<-:create;autoIncrement"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Code      string `gorm:"not null;uniqueIndex"`
+	Name      string
+	Closed    bool `gorm:"not null;default:false"`
+}
+
+// ProjectConsumptionLine is the quantity and value of an item consumed by a project, or of every item when ItemID is 0
+type ProjectConsumptionLine struct {
+	ProjectID   uint
+	ProjectCode string
+	ProjectName string
+	ItemID      uint
+	ItemName    string
+	Quantity    int
+	Value       float64
+}
+
+// ProjectReport groups the consumptions charged to projects between From and To by project and item
+type ProjectReport struct {
+	From          time.Time
+	To            time.Time
+	Lines         []ProjectConsumptionLine
+	Projects      []ProjectConsumptionLine
+	TotalQuantity int
+	TotalValue    float64
+}
+
+func (r *GORMSQLiteWarehouseRepository) CreateProject(code string, name string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return errors.New("project code can't be empty")
+	}
+	var count int64
+	err1 := r.DB.Model(&Project{}).Where("code = ?", code).Count(&count).Error
+	if err1 != nil {
+		return err1
+	}
+	if count > 0 {
+		return errors.New("project already exists: " + code)
+	}
+	return r.DB.Create(&Project{Code: code, Name: strings.TrimSpace(name)}).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListProjects() ([]Project, error) {
+	var res []Project
+	err := r.DB.Order("closed, code").Find(&res).Error
+	return res, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) SetProjectClosed(projectID uint, closed bool) error {
+	var project Project
+	err := r.DB.First(&project, projectID).Error
+	if err != nil {
+		return err
+	}
+	return r.DB.Model(&project).Update("closed", closed).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindProjectConsumption(from time.Time, to time.Time) (ProjectReport, error) {
+	report := ProjectReport{From: from, To: to}
+	err := r.DB.Table("stock_movements").
+		Select("stock_movements.project_id, projects.code AS project_code, projects.name AS project_name, "+
+			"stock_movements.item_id, items.name AS item_name, -SUM(stock_movements.quantity) AS quantity, "+
+			"-SUM(stock_movements.quantity * COALESCE(stock_movements.unit_cost, items.unit_cost, 0)) AS value").
+		Joins("JOIN projects ON projects.id = stock_movements.project_id").
+		Joins("LEFT JOIN items ON items.id = stock_movements.item_id").
+		Where("stock_movements.kind = ? AND stock_movements.created_at BETWEEN ? AND ?", ConsumeMovement, from, to).
+		Group("stock_movements.project_id, stock_movements.item_id").
+		Order("projects.code, items.name").Scan(&report.Lines).Error
+	if err != nil {
+		return report, err
+	}
+	totals := make(map[uint]*ProjectConsumptionLine)
+	for _, line := range report.Lines {
+		total, ok := totals[line.ProjectID]
+		if !ok {
+			total = &ProjectConsumptionLine{ProjectID: line.ProjectID, ProjectCode: line.ProjectCode, ProjectName: line.ProjectName}
+			totals[line.ProjectID] = total
+		}
+		total.Quantity += line.Quantity
+		total.Value += line.Value
+		report.TotalQuantity += line.Quantity
+		report.TotalValue += line.Value
+	}
+	for _, total := range totals {
+		report.Projects = append(report.Projects, *total)
+	}
+	sort.Slice(report.Projects, func(i, j int) bool {
+		return report.Projects[i].Value > report.Projects[j].Value ||
+			report.Projects[i].Value == report.Projects[j].Value && report.Projects[i].ProjectCode < report.Projects[j].ProjectCode
+	})
+	return report, nil
+}
+
+// checkProject verifies that the project, if any, exists and is still open
+func (r *GORMSQLiteWarehouseRepository) checkProject(projectID *uint) error {
+	if projectID == nil {
+		return nil
+	}
+	var project Project
+	err := r.DB.Limit(1).Find(&project, *projectID).Error
+	if err != nil {
+		return err
+	}
+	if project.ID == 0 {
+		return errors.New("unknown project: " + strconv.Itoa(int(*projectID)))
+	}
+	if project.Closed {
+		return errors.New("project " + project.Code + " is closed")
+	}
+	return nil
+}
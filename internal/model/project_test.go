@@ -0,0 +1,62 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProjects(t *testing.T) {
+	rep := newTestRepository(t, "test_projects.db")
+	_ = rep.CreateWarehouse("North", "Milan", 1000)
+	_ = rep.CreateItem("cables", "electrical", "copper cables")
+	rep.DB.Model(&Item{}).Where("id = ?", 1).Update("unit_cost", 2.5)
+	_ = rep.SupplyItems(1, 1, 50)
+	t.Run("CreateProject", func(t *testing.T) {
+		err1 := rep.CreateProject("PRJ-1", "new office")
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		_ = rep.CreateProject("PRJ-2", "old office")
+		err2 := rep.CreateProject("PRJ-1", "again")
+		if err2 == nil || err2.Error() != "project already exists: PRJ-1" {
+			t.Errorf("unexpected error: %v", err2)
+		}
+	})
+	t.Run("ChargeConsumption", func(t *testing.T) {
+		project1, project2, unknown := uint(1), uint(2), uint(9)
+		err1 := rep.ConsumeItemsFromStatus(1, 1, 4, AvailableStatus, MovementNote{ProjectID: &project1})
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		_ = rep.ConsumeItemsFromStatus(1, 1, 6, AvailableStatus, MovementNote{ProjectID: &project1})
+		_ = rep.ConsumeItemsFromStatus(1, 1, 2, AvailableStatus, MovementNote{})
+		err2 := rep.ConsumeItemsFromStatus(1, 1, 1, AvailableStatus, MovementNote{ProjectID: &unknown})
+		if err2 == nil || err2.Error() != "unknown project: 9" {
+			t.Errorf("unexpected error: %v", err2)
+		}
+		_ = rep.SetProjectClosed(2, true)
+		err3 := rep.ConsumeItemsFromStatus(1, 1, 1, AvailableStatus, MovementNote{ProjectID: &project2})
+		if err3 == nil || err3.Error() != "project PRJ-2 is closed" {
+			t.Errorf("unexpected error: %v", err3)
+		}
+		projects, _ := rep.ListProjects()
+		if len(projects) != 2 || projects[0].Code != "PRJ-1" || !projects[1].Closed {
+			t.Errorf("Projects weren't listed: %+v", projects)
+		}
+	})
+	t.Run("FindProjectConsumption", func(t *testing.T) {
+		// the consumption already charged keeps the cost it had when it was recorded
+		project1 := uint(1)
+		_ = rep.UpdateItemUnitCost(1, 10)
+		_ = rep.ConsumeItemsFromStatus(1, 1, 1, AvailableStatus, MovementNote{ProjectID: &project1})
+		now := time.Now()
+		report, err := rep.FindProjectConsumption(now.AddDate(0, 0, -1), now.AddDate(0, 0, 1))
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(report.Projects) != 1 || report.Projects[0].ProjectCode != "PRJ-1" || report.TotalQuantity != 11 ||
+			report.TotalValue != 35 || len(report.Lines) != 1 || report.Lines[0].ItemName != "cables" {
+			t.Errorf("Wrong project report: %+v", report)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"sort"
+	"strconv"
+)
+
+// PutawayStrategy chooses the order in which the warehouses receive a supply split by the putaway
+type PutawayStrategy string
+
+const (
+	// ConsolidateStrategy fills first the warehouses already storing the item, keeping its stock together
+	ConsolidateStrategy PutawayStrategy = "consolidate"
+	// PriorityStrategy follows the putaway priority of the warehouses
+	PriorityStrategy PutawayStrategy = "priority"
+	// EmptiestStrategy fills first the warehouses with the most free capacity, spreading the stock
+	EmptiestStrategy PutawayStrategy = "emptiest"
+)
+
+// PutawayAllocation is the part of a supply assigned to a warehouse
+type PutawayAllocation struct {
+	WarehouseID   uint
+	WarehouseName string
+	Priority      int
+	// Existing is the quantity of the item already stored, FreeCapacity the room left before the allocation
+	Existing     int
+	FreeCapacity int
+	Quantity     int
+}
+
+func (r *GORMSQLiteWarehouseRepository) UpdateWarehousePutawayPriority(warehouseID uint, priority int) error {
+	var warehouse Warehouse
+	err := r.DB.First(&warehouse, warehouseID).Error
+	if err != nil {
+		return err
+	}
+	return r.DB.Model(&warehouse).Update("putaway_priority", priority).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) PlanPutaway(itemID uint, quantity int, strategy PutawayStrategy) ([]PutawayAllocation, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be greater than 0")
+	}
+	var item Item
+	err1 := r.DB.First(&item, itemID).Error
+	if err1 != nil {
+		return nil, err1
+	}
+	candidates, err2 := r.putawayCandidates(itemID)
+	if err2 != nil {
+		return nil, err2
+	}
+	err3 := sortPutawayCandidates(candidates, strategy)
+	if err3 != nil {
+		return nil, err3
+	}
+	res := make([]PutawayAllocation, 0)
+	left := quantity
+	for _, candidate := range candidates {
+		if left == 0 {
+			break
+		}
+		if candidate.FreeCapacity <= 0 {
+			continue
+		}
+		candidate.Quantity = min(left, candidate.FreeCapacity)
+		left -= candidate.Quantity
+		res = append(res, candidate)
+	}
+	if left > 0 {
+		return nil, errors.New("not enough free capacity: " + strconv.Itoa(quantity-left) + " < " + strconv.Itoa(quantity))
+	}
+	return res, nil
+}
+
+func (r *GORMSQLiteWarehouseRepository) ApplyPutaway(itemID uint, quantity int, strategy PutawayStrategy) ([]PutawayAllocation, error) {
+	var res []PutawayAllocation
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		allocations, err1 := txRepository.PlanPutaway(itemID, quantity, strategy)
+		if err1 != nil {
+			return err1
+		}
+		for _, allocation := range allocations {
+			err2 := txRepository.supplyItems(itemID, allocation.WarehouseID, allocation.Quantity, SupplyMovement)
+			if err2 != nil {
+				return err2
+			}
+		}
+		res = allocations
+		return nil
+	})
+	return res, err
+}
+
+// putawayCandidates returns every warehouse with the quantity of the item it stores and the room it has for it.
+// The room excludes the items in transit to the warehouse and is limited by the maximum stock level of the item.
+func (r *GORMSQLiteWarehouseRepository) putawayCandidates(itemID uint) ([]PutawayAllocation, error) {
+	var rows []struct {
+		ID              uint
+		Name            string
+		Capacity        int
+		PutawayPriority int
+		Stored          int
+		Existing        int
+		Incoming        int
+		MaxLevel        int
+	}
+	stored := r.DB.Table("warehouse_items").Select("COALESCE(SUM(quantity), 0)").
+		Where("warehouse_items.warehouse_id = warehouses.id")
+	existing := r.DB.Table("warehouse_items").Select("COALESCE(SUM(quantity), 0)").
+		Where("warehouse_items.warehouse_id = warehouses.id AND warehouse_items.item_id = ?", itemID)
+	incoming := r.DB.Table("shipments").Select("COALESCE(SUM(quantity), 0)").
+		Where("shipments.destination_warehouse_id = warehouses.id AND shipments.status = ?", InTransitShipment)
+	maxLevel := r.DB.Table("stock_levels").Select("COALESCE(MAX(max), 0)").
+		Where("stock_levels.warehouse_id = warehouses.id AND stock_levels.item_id = ?", itemID)
+	err := r.DB.Model(&Warehouse{}).
+		Select("warehouses.id, warehouses.name, warehouses.capacity, warehouses.putaway_priority, (?) AS stored, "+
+			"(?) AS existing, (?) AS incoming, (?) AS max_level", stored, existing, incoming, maxLevel).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make([]PutawayAllocation, 0, len(rows))
+	for _, row := range rows {
+		free := row.Capacity - row.Stored - row.Incoming
+		if row.MaxLevel > 0 {
+			free = min(free, row.MaxLevel-row.Existing)
+		}
+		res = append(res, PutawayAllocation{WarehouseID: row.ID, WarehouseName: row.Name, Priority: row.PutawayPriority,
+			Existing: row.Existing, FreeCapacity: max(free, 0)})
+	}
+	return res, nil
+}
+
+// sortPutawayCandidates orders the warehouses according to the strategy, using the other rules to break the ties
+func sortPutawayCandidates(candidates []PutawayAllocation, strategy PutawayStrategy) error {
+	byExisting := func(a PutawayAllocation, b PutawayAllocation) int { return b.Existing - a.Existing }
+	byPriority := func(a PutawayAllocation, b PutawayAllocation) int { return b.Priority - a.Priority }
+	byFree := func(a PutawayAllocation, b PutawayAllocation) int { return b.FreeCapacity - a.FreeCapacity }
+	var rules []func(PutawayAllocation, PutawayAllocation) int
+	switch strategy {
+	case ConsolidateStrategy:
+		rules = append(rules, byExisting, byPriority, byFree)
+	case PriorityStrategy:
+		rules = append(rules, byPriority, byExisting, byFree)
+	case EmptiestStrategy:
+		rules = append(rules, byFree, byPriority)
+	default:
+		return errors.New("unknown putaway strategy: " + string(strategy))
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		for _, rule := range rules {
+			difference := rule(candidates[i], candidates[j])
+			if difference != 0 {
+				return difference < 0
+			}
+		}
+		return candidates[i].WarehouseID < candidates[j].WarehouseID
+	})
+	return nil
+}
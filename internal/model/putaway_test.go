@@ -0,0 +1,73 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestPutaway(t *testing.T) {
+	rep := newTestRepository(t, "test_putaway.db")
+	_ = rep.CreateWarehouse("North", "Milan", 50)
+	_ = rep.CreateWarehouse("South", "Naples", 100)
+	_ = rep.CreateWarehouse("East", "Venice", 30)
+	_ = rep.UpdateWarehousePutawayPriority(2, 5)
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.SupplyItems(1, 1, 40)
+	_ = rep.SupplyItems(1, 2, 20)
+	_, _ = rep.DispatchShipment(1, 2, 10, 3)
+	type allocation struct {
+		warehouseID uint
+		quantity    int
+	}
+	check := func(t *testing.T, allocations []PutawayAllocation, expected []allocation) {
+		if len(allocations) != len(expected) {
+			t.Fatalf("Incorrect number of allocations.\nexpected number: %d actual number: %d", len(expected), len(allocations))
+		}
+		for i, v := range expected {
+			if allocations[i].WarehouseID != v.warehouseID || allocations[i].Quantity != v.quantity {
+				t.Errorf("Supply wasn't allocated correctly\nexpected: %d in warehouse %d\nactual: %d in warehouse %d",
+					v.quantity, v.warehouseID, allocations[i].Quantity, allocations[i].WarehouseID)
+			}
+		}
+	}
+	t.Run("PlanPutawayStrategies", func(t *testing.T) {
+		consolidated, err1 := rep.PlanPutaway(1, 50, ConsolidateStrategy)
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		check(t, consolidated, []allocation{{1, 10}, {2, 40}})
+		prioritized, _ := rep.PlanPutaway(1, 50, PriorityStrategy)
+		check(t, prioritized, []allocation{{2, 50}})
+	})
+	t.Run("PlanPutawayLimits", func(t *testing.T) {
+		// the maximum level leaves room for 20 gloves in South, the shipment in transit leaves room for 20 in East
+		_ = rep.SetStockLevel(1, 2, 0, 30)
+		allocations, err1 := rep.PlanPutaway(1, 50, EmptiestStrategy)
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		check(t, allocations, []allocation{{2, 20}, {3, 20}, {1, 10}})
+		_, err2 := rep.PlanPutaway(1, 100, EmptiestStrategy)
+		if err2 == nil || err2.Error() != "not enough free capacity: 50 < 100" {
+			t.Errorf("unexpected error message: %v", err2)
+		}
+		_, err3 := rep.PlanPutaway(1, 10, "random")
+		if err3 == nil {
+			t.Errorf("No error reported for an unknown strategy")
+		}
+	})
+	t.Run("ApplyPutaway", func(t *testing.T) {
+		_, err := rep.ApplyPutaway(1, 15, ConsolidateStrategy)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		gloves, _ := rep.FindItemByID(1)
+		if gloves.Quantity != 65 {
+			t.Errorf("Putaway wasn't applied correctly\nexpected quantity: 65\nactual quantity: %d", gloves.Quantity)
+		}
+		var pack WarehouseItem
+		_ = rep.DB.First(&pack, "item_id = ? AND warehouse_id = ?", 1, 1).Error
+		if pack.Quantity != 50 {
+			t.Errorf("Putaway didn't fill North first\nexpected quantity: 50\nactual quantity: %d", pack.Quantity)
+		}
+	})
+}
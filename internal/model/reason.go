@@ -0,0 +1,174 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReasonCode explains why stock was adjusted. The codes flagged as WriteOff can only be used by write-offs, the
+// others only by the ordinary supplies, consumptions and transfers.
+type ReasonCode struct {
+	ID          uint   `gorm:"primaryKey;<-:create;autoIncrement"`
+	Code        string `gorm:"not null;uniqueIndex"`
+	Description string
+	WriteOff    bool `gorm:"not null;default:false"`
+}
+
+// MovementNote is the reason code and the free-text note attached to the movements of a stock operation, together
+// with the project charged when the operation is a consumption
+type MovementNote struct {
+	Reason    string
+	Note      string
+	ProjectID *uint
+}
+
+// defaultReasonCodes are created together with the reason_codes table
+var defaultReasonCodes = []ReasonCode{
+	{Code: "usage", Description: "consumed by normal operations"},
+	{Code: "scrap", Description: "thrown away because broken or defective", WriteOff: true},
+	{Code: "theft", Description: "stolen or lost", WriteOff: true},
+	{Code: "expiry", Description: "past its expiry date", WriteOff: true},
+	{Code: "correction", Description: "count correction"},
+	{Code: "sample", Description: "given away as a sample"},
+}
+
+// ShrinkageLine is the stock written off for a reason in a warehouse, or in every warehouse when WarehouseID is 0
+type ShrinkageLine struct {
+	Reason        string
+	WarehouseID   uint
+	WarehouseName string
+	Quantity      int
+	Value         float64
+}
+
+// ShrinkageReport groups the stock written off between From and To by reason and warehouse
+type ShrinkageReport struct {
+	From          time.Time
+	To            time.Time
+	Lines         []ShrinkageLine
+	Reasons       []ShrinkageLine
+	TotalQuantity int
+	TotalValue    float64
+}
+
+// seedReasonCodes creates the default reason codes in a database which had none
+func (r *GORMSQLiteWarehouseRepository) seedReasonCodes() error {
+	return r.DB.Create(&defaultReasonCodes).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListReasonCodes() ([]ReasonCode, error) {
+	var res []ReasonCode
+	err := r.DB.Order("write_off, code").Find(&res).Error
+	return res, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) CreateReasonCode(code string, description string, writeOff bool) error {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if code == "" {
+		return errors.New("reason code can't be empty")
+	}
+	var count int64
+	err1 := r.DB.Model(&ReasonCode{}).Where("code = ?", code).Count(&count).Error
+	if err1 != nil {
+		return err1
+	}
+	if count > 0 {
+		return errors.New("reason code already exists: " + code)
+	}
+	return r.DB.Create(&ReasonCode{Code: code, Description: description, WriteOff: writeOff}).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) DeleteReasonCode(reasonID uint) error {
+	// the movements keep the text of the code
+	return r.DB.Delete(&ReasonCode{}, reasonID).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) WriteOffItems(itemID uint, warehouseID uint, quantity int, status StockStatus, note MovementNote) error {
+	if note.Reason == "" {
+		return errors.New("write-offs need a reason")
+	}
+	err1 := validateStockStatus(status)
+	if err1 != nil {
+		return err1
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		err2 := txRepository.checkReasonCode(note.Reason, true)
+		if err2 != nil {
+			return err2
+		}
+		err3 := txRepository.addToBucket(itemID, warehouseID, status, -quantity)
+		if err3 != nil {
+			return err3
+		}
+		return txRepository.withNote(note).consumeItems(itemID, warehouseID, quantity, WriteOffMovement)
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindShrinkage(from time.Time, to time.Time) (ShrinkageReport, error) {
+	report := ShrinkageReport{From: from, To: to}
+	err := r.DB.Table("stock_movements").
+		Select("stock_movements.reason, stock_movements.warehouse_id, warehouses.name AS warehouse_name, "+
+			"-SUM(stock_movements.quantity) AS quantity, -SUM(stock_movements.quantity * COALESCE(items.unit_cost, 0)) AS value").
+		Joins("LEFT JOIN items ON items.id = stock_movements.item_id").
+		Joins("LEFT JOIN warehouses ON warehouses.id = stock_movements.warehouse_id").
+		Where("stock_movements.kind IN ? AND stock_movements.created_at BETWEEN ? AND ?",
+			[]MovementKind{WriteOffMovement, ScrapMovement}, from, to).
+		Group("stock_movements.reason, stock_movements.warehouse_id").
+		Order("stock_movements.reason, warehouses.name").Scan(&report.Lines).Error
+	if err != nil {
+		return report, err
+	}
+	totals := make(map[string]*ShrinkageLine)
+	for _, line := range report.Lines {
+		total, ok := totals[line.Reason]
+		if !ok {
+			total = &ShrinkageLine{Reason: line.Reason}
+			totals[line.Reason] = total
+		}
+		total.Quantity += line.Quantity
+		total.Value += line.Value
+		report.TotalQuantity += line.Quantity
+		report.TotalValue += line.Value
+	}
+	for _, total := range totals {
+		report.Reasons = append(report.Reasons, *total)
+	}
+	sort.Slice(report.Reasons, func(i, j int) bool {
+		return report.Reasons[i].Value > report.Reasons[j].Value ||
+			report.Reasons[i].Value == report.Reasons[j].Value && report.Reasons[i].Reason < report.Reasons[j].Reason
+	})
+	return report, nil
+}
+
+// withNote returns a copy of the repository which attaches the note to the movements it records
+func (r *GORMSQLiteWarehouseRepository) withNote(note MovementNote) *GORMSQLiteWarehouseRepository {
+	noteRepository := *r
+	noteRepository.note = note
+	return &noteRepository
+}
+
+// checkReasonCode verifies that the reason, if any, is a known code usable by write-offs or by ordinary operations
+func (r *GORMSQLiteWarehouseRepository) checkReasonCode(reason string, writeOff bool) error {
+	if reason == "" {
+		return nil
+	}
+	var code ReasonCode
+	err := r.DB.Where("code = ?", reason).Limit(1).Find(&code).Error
+	if err != nil {
+		return err
+	}
+	if code.ID == 0 {
+		return errors.New("unknown reason code: " + reason)
+	}
+	if code.WriteOff && !writeOff {
+		return errors.New("reason " + reason + " is only allowed for write-offs")
+	}
+	if !code.WriteOff && writeOff {
+		return errors.New("reason " + reason + " isn't allowed for write-offs")
+	}
+	return nil
+}
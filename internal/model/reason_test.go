@@ -0,0 +1,90 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReasonCodes(t *testing.T) {
+	rep := newTestRepository(t, "test_reasons.db")
+	_ = rep.CreateWarehouse("North", "Milan", 100)
+	_ = rep.CreateWarehouse("South", "Naples", 100)
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.UpdateItemUnitCost(1, 2.5)
+	_ = rep.SupplyItems(1, 1, 50)
+	t.Run("ReasonCodes", func(t *testing.T) {
+		reasons, err1 := rep.ListReasonCodes()
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		if len(reasons) != 6 || reasons[0].Code != "correction" || !reasons[5].WriteOff {
+			t.Errorf("Default reason codes weren't created correctly: %+v", reasons)
+		}
+		err2 := rep.CreateReasonCode(" Damage ", "damaged in the warehouse", true)
+		if err2 != nil {
+			t.Fatalf("Reported error: %v", err2)
+		}
+		err3 := rep.CreateReasonCode("damage", "", false)
+		if err3 == nil || err3.Error() != "reason code already exists: damage" {
+			t.Errorf("unexpected error: %v", err3)
+		}
+	})
+	t.Run("MovementNotes", func(t *testing.T) {
+		err1 := rep.ConsumeItemsFromStatus(1, 1, 5, AvailableStatus, MovementNote{Reason: "sample", Note: "trade fair"})
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		err2 := rep.TransferItemsWithNote(1, 1, 5, 2, MovementNote{Reason: "correction"})
+		if err2 != nil {
+			t.Fatalf("Reported error: %v", err2)
+		}
+		err3 := rep.ConsumeItemsFromStatus(1, 1, 5, AvailableStatus, MovementNote{Reason: "theft"})
+		if err3 == nil || err3.Error() != "reason theft is only allowed for write-offs" {
+			t.Errorf("unexpected error: %v", err3)
+		}
+		err4 := rep.SupplyItemsWithStatus(1, 1, 5, AvailableStatus, MovementNote{Reason: "gift"})
+		if err4 == nil || err4.Error() != "unknown reason code: gift" {
+			t.Errorf("unexpected error: %v", err4)
+		}
+		movements, _ := rep.FindRecentMovements(3)
+		if len(movements) != 3 || movements[0].Reason != "correction" || movements[2].Note != "trade fair" {
+			t.Errorf("Notes weren't recorded with the movements: %+v", movements)
+		}
+	})
+	t.Run("WriteOffItems", func(t *testing.T) {
+		err1 := rep.WriteOffItems(1, 1, 4, AvailableStatus, MovementNote{})
+		if err1 == nil || err1.Error() != "write-offs need a reason" {
+			t.Errorf("unexpected error: %v", err1)
+		}
+		err2 := rep.WriteOffItems(1, 1, 4, AvailableStatus, MovementNote{Reason: "usage"})
+		if err2 == nil || err2.Error() != "reason usage isn't allowed for write-offs" {
+			t.Errorf("unexpected error: %v", err2)
+		}
+		_ = rep.ChangeStockStatus(1, 2, 3, AvailableStatus, DamagedStatus)
+		err3 := rep.WriteOffItems(1, 2, 3, DamagedStatus, MovementNote{Reason: "damage"})
+		if err3 != nil {
+			t.Fatalf("Reported error: %v", err3)
+		}
+		_ = rep.WriteOffItems(1, 1, 4, AvailableStatus, MovementNote{Reason: "theft", Note: "night shift"})
+		_ = rep.WriteOffItems(1, 2, 1, AvailableStatus, MovementNote{Reason: "theft"})
+		gloves, _ := rep.FindItemByID(1)
+		if gloves.Quantity != 37 {
+			t.Errorf("Items weren't written off\nexpected quantity: 37\nactual quantity: %d", gloves.Quantity)
+		}
+	})
+	t.Run("FindShrinkage", func(t *testing.T) {
+		report, err := rep.FindShrinkage(time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(report.Lines) != 3 || report.Lines[1].Reason != "theft" || report.Lines[1].WarehouseName != "North" {
+			t.Errorf("Shrinkage wasn't grouped correctly: %+v", report.Lines)
+		}
+		if len(report.Reasons) != 2 || report.Reasons[0].Reason != "theft" || report.Reasons[0].Quantity != 5 {
+			t.Errorf("Shrinkage wasn't totalled by reason: %+v", report.Reasons)
+		}
+		if report.TotalQuantity != 8 || report.TotalValue != 20 {
+			t.Errorf("Incorrect totals\nexpected: 8 items worth 20\nactual: %d items worth %.2f", report.TotalQuantity, report.TotalValue)
+		}
+	})
+}
@@ -0,0 +1,318 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm/clause"
+	"sort"
+)
+
+// StockLevel is the range of quantities of an item a warehouse should hold. A Max of 0 means no maximum.
+type StockLevel struct {
+	ItemID      uint `gorm:"primaryKey"`
+	WarehouseID uint `gorm:"primaryKey"`
+	Min         int  `gorm:"not null;default:0"`
+	Max         int  `gorm:"not null;default:0"`
+}
+
+// StockLevelEntry is a StockLevel together with the names of its item and warehouse and the quantity stored
+type StockLevelEntry struct {
+	StockLevel
+	ItemName      string
+	WarehouseName string
+	Quantity      int
+}
+
+// RebalancingMove is a transfer proposed by the rebalancing planner
+type RebalancingMove struct {
+	ItemID                 uint
+	ItemName               string
+	SourceWarehouseID      uint
+	SourceName             string
+	DestinationWarehouseID uint
+	DestinationName        string
+	Quantity               int
+	// DistanceKm is negative when the coordinates of either warehouse are unknown
+	DistanceKm float64
+}
+
+func (r *GORMSQLiteWarehouseRepository) SetStockLevel(itemID uint, warehouseID uint, min int, max int) error {
+	if min < 0 || max < 0 {
+		return errors.New("stock levels can't be negative")
+	}
+	if max != 0 && max < min {
+		return errors.New("the maximum stock level can't be lower than the minimum")
+	}
+	var item Item
+	err1 := r.DB.First(&item, itemID).Error
+	if err1 != nil {
+		return err1
+	}
+	var warehouse Warehouse
+	err2 := r.DB.First(&warehouse, warehouseID).Error
+	if err2 != nil {
+		return err2
+	}
+	if min == 0 && max == 0 {
+		return r.DB.Delete(&StockLevel{}, "item_id = ? AND warehouse_id = ?", itemID, warehouseID).Error
+	}
+	return r.DB.Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&StockLevel{ItemID: itemID, WarehouseID: warehouseID, Min: min, Max: max}).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListStockLevels() ([]StockLevelEntry, error) {
+	var res []StockLevelEntry
+	err := r.DB.Table("stock_levels").
+		Select("stock_levels.*, items.name AS item_name, warehouses.name AS warehouse_name, COALESCE(warehouse_items.quantity, 0) AS quantity").
+		Joins("JOIN items ON items.id = stock_levels.item_id AND items.deleted_at IS NULL").
+		Joins("JOIN warehouses ON warehouses.id = stock_levels.warehouse_id AND warehouses.deleted_at IS NULL").
+		Joins("LEFT JOIN warehouse_items ON warehouse_items.item_id = stock_levels.item_id AND warehouse_items.warehouse_id = stock_levels.warehouse_id").
+		Order("items.name, warehouses.name").Scan(&res).Error
+	return res, err
+}
+
+// rebalancingSource is a warehouse which can give away some of an item. Excess stock, above the maximum level,
+// is moved before spare stock, above the minimum level or in warehouses without levels.
+type rebalancingSource struct {
+	warehouseID uint
+	amount      int
+	excess      bool
+}
+
+// rebalancingPlanner holds the state shared by the planning of every item
+type rebalancingPlanner struct {
+	warehouses map[uint]Warehouse
+	// free is the capacity left in each warehouse, updated as moves are planned
+	free  map[uint]int
+	moves []RebalancingMove
+}
+
+func (r *GORMSQLiteWarehouseRepository) PlanRebalancing() ([]RebalancingMove, error) {
+	levels, err1 := r.ListStockLevels()
+	if err1 != nil {
+		return nil, err1
+	}
+	warehouses, err2 := r.ListAllWarehouses()
+	if err2 != nil {
+		return nil, err2
+	}
+	var stock []WarehouseItem
+	err3 := r.DB.Table("warehouse_items").Select("warehouse_items.*").Joins(validAssociations).
+		Where("warehouse_items.quantity > 0").Scan(&stock).Error
+	if err3 != nil {
+		return nil, err3
+	}
+	var buckets []StockStatusBucket
+	err4 := r.DB.Model(&StockStatusBucket{}).Select("item_id, warehouse_id, SUM(quantity) AS quantity").
+		Group("item_id, warehouse_id").Scan(&buckets).Error
+	if err4 != nil {
+		return nil, err4
+	}
+	planner := rebalancingPlanner{warehouses: make(map[uint]Warehouse), free: make(map[uint]int)}
+	for _, warehouse := range warehouses {
+		// the shipments in transit already reserve part of the room of their destination
+		incoming, err5 := r.FindIncomingQuantity(warehouse.ID)
+		if err5 != nil {
+			return nil, err5
+		}
+		planner.warehouses[warehouse.ID] = warehouse
+		planner.free[warehouse.ID] = warehouse.Capacity - incoming
+	}
+	quantities := make(map[uint]map[uint]int)
+	for _, pack := range stock {
+		planner.free[pack.WarehouseID] -= pack.Quantity
+		if quantities[pack.ItemID] == nil {
+			quantities[pack.ItemID] = make(map[uint]int)
+		}
+		quantities[pack.ItemID][pack.WarehouseID] = pack.Quantity
+	}
+	// only the available stock can be moved, the stock in the other statuses still takes up room
+	for _, bucket := range buckets {
+		if quantities[bucket.ItemID] != nil {
+			quantities[bucket.ItemID][bucket.WarehouseID] -= bucket.Quantity
+		}
+	}
+	itemLevels := make(map[uint][]StockLevelEntry)
+	itemIDs := make([]uint, 0)
+	for _, level := range levels {
+		if itemLevels[level.ItemID] == nil {
+			itemIDs = append(itemIDs, level.ItemID)
+		}
+		itemLevels[level.ItemID] = append(itemLevels[level.ItemID], level)
+	}
+	for _, itemID := range itemIDs {
+		planner.planItem(itemLevels[itemID], quantities[itemID])
+	}
+	return planner.moves, nil
+}
+
+// planItem proposes the moves of a single item: first the warehouses below their minimum are refilled, then the
+// excess left in the warehouses above their maximum is sent to the warehouses with levels and room below the maximum.
+// The planner is greedy: it prefers a single source covering the whole need, then the nearest one.
+func (p *rebalancingPlanner) planItem(levels []StockLevelEntry, quantities map[uint]int) {
+	limits := make(map[uint]StockLevelEntry)
+	for _, level := range levels {
+		limits[level.WarehouseID] = level
+	}
+	sources := make([]*rebalancingSource, 0)
+	for warehouseID, quantity := range quantities {
+		level, ok := limits[warehouseID]
+		if !ok {
+			sources = append(sources, &rebalancingSource{warehouseID: warehouseID, amount: quantity})
+			continue
+		}
+		if level.Max > 0 && quantity > level.Max {
+			sources = append(sources, &rebalancingSource{warehouseID: warehouseID, amount: quantity - level.Max, excess: true})
+		}
+		if quantity > level.Min {
+			spare := quantity - level.Min
+			if level.Max > 0 && quantity > level.Max {
+				spare = level.Max - level.Min
+			}
+			if spare > 0 {
+				sources = append(sources, &rebalancingSource{warehouseID: warehouseID, amount: spare})
+			}
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].warehouseID != sources[j].warehouseID {
+			return sources[i].warehouseID < sources[j].warehouseID
+		}
+		return sources[i].excess
+	})
+	// neediest warehouses first, ties broken by ID to keep the plan stable
+	sort.Slice(levels, func(i, j int) bool {
+		needI := levels[i].Min - quantities[levels[i].WarehouseID]
+		needJ := levels[j].Min - quantities[levels[j].WarehouseID]
+		if needI != needJ {
+			return needI > needJ
+		}
+		return levels[i].WarehouseID < levels[j].WarehouseID
+	})
+	for _, level := range levels {
+		need := level.Min - quantities[level.WarehouseID]
+		for need > 0 {
+			moved := p.moveFrom(level, sources, need, false)
+			if moved == 0 {
+				break
+			}
+			need -= moved
+			quantities[level.WarehouseID] += moved
+		}
+	}
+	for _, source := range sources {
+		for source.excess && source.amount > 0 {
+			if p.placeExcess(source, levels, quantities) == 0 {
+				break
+			}
+		}
+	}
+}
+
+// placeExcess plans a move of the excess of a source towards the warehouse with room below its maximum which can take
+// it all, or else the nearest one. It returns the number of items moved.
+func (p *rebalancingPlanner) placeExcess(source *rebalancingSource, levels []StockLevelEntry, quantities map[uint]int) int {
+	var best StockLevelEntry
+	bestRoom := 0
+	bestDistance := -1.0
+	for _, level := range levels {
+		room := p.free[level.WarehouseID]
+		if level.Max > 0 {
+			room = min(room, level.Max-quantities[level.WarehouseID])
+		}
+		if room <= 0 || level.WarehouseID == source.warehouseID {
+			continue
+		}
+		distance := p.distance(source.warehouseID, level.WarehouseID)
+		candidate := &rebalancingSource{warehouseID: level.WarehouseID, amount: room}
+		if bestRoom == 0 || betterSource(candidate, distance, &rebalancingSource{warehouseID: best.WarehouseID, amount: bestRoom}, bestDistance, source.amount) {
+			best = level
+			bestRoom = room
+			bestDistance = distance
+		}
+	}
+	if bestRoom == 0 {
+		return 0
+	}
+	moved := p.moveFrom(best, []*rebalancingSource{source}, min(source.amount, bestRoom), true)
+	quantities[best.WarehouseID] += moved
+	return moved
+}
+
+// moveFrom plans a move of at most amount items towards the warehouse of the level, choosing the best source.
+// When onlyExcess is set only the excess stock is considered. It returns the number of items moved.
+func (p *rebalancingPlanner) moveFrom(level StockLevelEntry, sources []*rebalancingSource, amount int, onlyExcess bool) int {
+	amount = min(amount, p.free[level.WarehouseID])
+	if amount <= 0 {
+		return 0
+	}
+	var best *rebalancingSource
+	bestDistance := -1.0
+	for _, source := range sources {
+		if source.amount <= 0 || source.warehouseID == level.WarehouseID || (onlyExcess && !source.excess) {
+			continue
+		}
+		distance := p.distance(source.warehouseID, level.WarehouseID)
+		if best == nil || betterSource(source, distance, best, bestDistance, amount) {
+			best = source
+			bestDistance = distance
+		}
+	}
+	if best == nil {
+		return 0
+	}
+	moved := min(amount, best.amount)
+	best.amount -= moved
+	p.free[best.warehouseID] += moved
+	p.free[level.WarehouseID] -= moved
+	for i := range p.moves {
+		move := &p.moves[i]
+		if move.ItemID == level.ItemID && move.SourceWarehouseID == best.warehouseID && move.DestinationWarehouseID == level.WarehouseID {
+			move.Quantity += moved
+			return moved
+		}
+	}
+	p.moves = append(p.moves, RebalancingMove{
+		ItemID:                 level.ItemID,
+		ItemName:               level.ItemName,
+		SourceWarehouseID:      best.warehouseID,
+		SourceName:             p.warehouses[best.warehouseID].Name,
+		DestinationWarehouseID: level.WarehouseID,
+		DestinationName:        level.WarehouseName,
+		Quantity:               moved,
+		DistanceKm:             bestDistance,
+	})
+	return moved
+}
+
+// betterSource reports whether source a is preferable to source b for moving amount items: a source covering the
+// whole amount saves moves, excess stock should leave first, then the nearest and the largest source win.
+// It compares the destinations of some excess in the same way, their amount being the room they have.
+func betterSource(a *rebalancingSource, distanceA float64, b *rebalancingSource, distanceB float64, amount int) bool {
+	if (a.amount >= amount) != (b.amount >= amount) {
+		return a.amount >= amount
+	}
+	if a.excess != b.excess {
+		return a.excess
+	}
+	if distanceA != distanceB {
+		// unknown distances are negative and come after the known ones
+		if distanceA < 0 || distanceB < 0 {
+			return distanceB < 0
+		}
+		return distanceA < distanceB
+	}
+	if a.amount != b.amount {
+		return a.amount > b.amount
+	}
+	return a.warehouseID < b.warehouseID
+}
+
+// distance returns the distance between two warehouses, or -1 when the coordinates of either are unknown
+func (p *rebalancingPlanner) distance(warehouseID1 uint, warehouseID2 uint) float64 {
+	location1 := p.warehouses[warehouseID1].Location
+	location2 := p.warehouses[warehouseID2].Location
+	if !location1.HasCoordinates() || !location2.HasCoordinates() {
+		return -1
+	}
+	return haversineDistance(*location1.Latitude, *location1.Longitude, *location2.Latitude, *location2.Longitude)
+}
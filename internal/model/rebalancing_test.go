@@ -0,0 +1,105 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestRebalancing(t *testing.T) {
+	rep := newTestRepository(t, "test_rebalancing.db")
+	cities := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+	}{{"Milan", 45.46, 9.19}, {"Naples", 40.85, 14.27}, {"Turin", 45.07, 7.69}, {"Rome", 41.9, 12.5}}
+	for i, city := range cities {
+		_ = rep.CreateWarehouse(city.name, city.name, 100)
+		_ = rep.UpdateWarehouseLocation(uint(i+1), WarehouseLocation{Latitude: &city.latitude, Longitude: &city.longitude})
+	}
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.SupplyItems(1, 1, 60)
+	_ = rep.SupplyItems(1, 2, 60)
+	_ = rep.SupplyItems(1, 4, 5)
+	t.Run("SetStockLevel", func(t *testing.T) {
+		levels := []StockLevel{{1, 1, 0, 30}, {1, 2, 0, 30}, {1, 3, 20, 0}, {1, 4, 15, 40}}
+		for _, level := range levels {
+			err := rep.SetStockLevel(level.ItemID, level.WarehouseID, level.Min, level.Max)
+			if err != nil {
+				t.Fatalf("Reported error: %v", err)
+			}
+		}
+		err := rep.SetStockLevel(1, 1, 40, 30)
+		if err == nil {
+			t.Errorf("No error reported when the maximum is lower than the minimum")
+		}
+		entries, _ := rep.ListStockLevels()
+		if len(entries) != 4 || entries[0].WarehouseName != "Milan" || entries[0].Quantity != 60 {
+			t.Errorf("Stock levels weren't listed correctly: %+v", entries)
+		}
+	})
+	t.Run("PlanRebalancing", func(t *testing.T) {
+		moves, err := rep.PlanRebalancing()
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		// Turin is refilled from Milan and Rome from Naples, the nearest warehouses with excess stock
+		expected := []struct {
+			source      uint
+			destination uint
+			quantity    int
+		}{{1, 3, 30}, {2, 4, 30}}
+		if len(moves) != len(expected) {
+			t.Fatalf("Incorrect number of moves.\nexpected number: %d actual number: %d", len(expected), len(moves))
+		}
+		for i, v := range expected {
+			if moves[i].SourceWarehouseID != v.source || moves[i].DestinationWarehouseID != v.destination || moves[i].Quantity != v.quantity {
+				t.Errorf("Move wasn't planned correctly\nexpected: %d -> %d, %d\nactual: %+v", v.source, v.destination, v.quantity, moves[i])
+			}
+		}
+		if moves[0].DistanceKm < 100 || moves[0].DistanceKm > 150 {
+			t.Errorf("Distance between Milan and Turin isn't correct: %f", moves[0].DistanceKm)
+		}
+		lines := make([]StockLine, 0, len(moves))
+		for _, move := range moves {
+			lines = append(lines, StockLine{Operation: TransferOperation, ItemID: move.ItemID, WarehouseID: move.SourceWarehouseID,
+				DestinationWarehouseID: move.DestinationWarehouseID, Quantity: move.Quantity})
+		}
+		_ = rep.ApplyStockBatch(lines)
+		moves, _ = rep.PlanRebalancing()
+		if len(moves) != 0 {
+			t.Errorf("Executed plan didn't balance the stock: %+v", moves)
+		}
+	})
+	t.Run("RemoveStockLevel", func(t *testing.T) {
+		_ = rep.SetStockLevel(1, 4, 0, 0)
+		entries, _ := rep.ListStockLevels()
+		if len(entries) != 3 {
+			t.Errorf("Stock level wasn't removed\nexpected number: 3 actual number: %d", len(entries))
+		}
+	})
+	t.Run("PlanRebalancingAvailableOnly", func(t *testing.T) {
+		_ = rep.CreateItem("helmets", "safety", "hard hats")
+		_ = rep.SupplyItems(2, 1, 10)
+		_ = rep.SupplyItemsWithStatus(2, 1, 40, QuarantinedStatus, MovementNote{})
+		_ = rep.SetStockLevel(2, 3, 20, 0)
+		moves, err := rep.PlanRebalancing()
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		// the quarantined helmets can't be moved, so Turin only gets the available ones
+		if len(moves) != 1 || moves[0].ItemID != 2 || moves[0].SourceWarehouseID != 1 || moves[0].Quantity != 10 {
+			t.Errorf("Move wasn't planned from the available stock only: %+v", moves)
+		}
+	})
+	t.Run("PlanRebalancingIncomingShipments", func(t *testing.T) {
+		_, _ = rep.DispatchShipment(1, 2, 30, 3)
+		_, _ = rep.DispatchShipment(1, 4, 35, 3)
+		moves, err := rep.PlanRebalancing()
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		// the shipments in transit leave room for only 5 helmets in Turin
+		if len(moves) != 1 || moves[0].DestinationWarehouseID != 3 || moves[0].Quantity != 5 {
+			t.Errorf("Move didn't account for the shipments in transit: %+v", moves)
+		}
+	})
+}
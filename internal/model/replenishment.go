@@ -0,0 +1,186 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// ReplenishmentParameters configures the demand forecast and the reorder suggestions
+type ReplenishmentParameters struct {
+	// days of consumption history used by the forecast
+	HistoryDays int
+	// days averaged by the moving average
+	MovingAverageDays int
+	// weight of the most recent day in the exponential smoothing, between 0 and 1
+	SmoothingFactor float64
+	// days between placing an order and receiving it, for the items without a supplier
+	LeadTimeDays int
+	// days of demand kept as safety stock
+	SafetyStockDays int
+	// days of demand an order should cover once received
+	ReviewPeriodDays int
+}
+
+// DefaultReplenishmentParameters returns the parameters used when the user doesn't choose them
+func DefaultReplenishmentParameters() ReplenishmentParameters {
+	return ReplenishmentParameters{
+		HistoryDays:       90,
+		MovingAverageDays: 28,
+		SmoothingFactor:   0.3,
+		LeadTimeDays:      7,
+		SafetyStockDays:   7,
+		ReviewPeriodDays:  14,
+	}
+}
+
+// ReplenishmentSuggestion is the demand forecast of an item together with the quantity to reorder, if any
+type ReplenishmentSuggestion struct {
+	ItemID   uint
+	ItemName string
+	Quantity int
+	// units consumed during the history period
+	Consumed      int
+	MovingAverage float64
+	Smoothed      float64
+	// DailyDemand is the forecast used for the suggestion, the exponentially smoothed demand
+	DailyDemand float64
+	// DaysOfCover is how long the current stock lasts, negative when there is no demand
+	DaysOfCover  float64
+	SafetyStock  int
+	ReorderPoint int
+	// SuggestedQuantity is 0 unless the stock is at or below the reorder point, otherwise it is at least the minimum
+	// order quantity of the supplier
+	SuggestedQuantity int
+	// the supplier the item is reordered from, SupplierID is 0 when the item has none
+	SupplierID   uint
+	SupplierName string
+	PartNumber   string
+	LeadTimeDays int
+	UnitPrice    float64
+}
+
+// validate checks that the parameters describe a meaningful forecast
+func (p ReplenishmentParameters) validate() error {
+	if p.HistoryDays <= 0 || p.MovingAverageDays <= 0 || p.MovingAverageDays > p.HistoryDays {
+		return errors.New("the moving average needs between 1 and the history days")
+	}
+	if p.SmoothingFactor <= 0 || p.SmoothingFactor > 1 {
+		return errors.New("the smoothing factor must be greater than 0 and at most 1")
+	}
+	if p.LeadTimeDays < 0 || p.SafetyStockDays < 0 || p.ReviewPeriodDays < 0 {
+		return errors.New("lead time, safety stock and review period can't be negative")
+	}
+	return nil
+}
+
+// demandMovements are the movements representing consumption: items used up directly or as components of kits
+var demandMovements = []MovementKind{ConsumeMovement, AssemblyMovement}
+
+func (r *GORMSQLiteWarehouseRepository) FindReplenishment(parameters ReplenishmentParameters) ([]ReplenishmentSuggestion, error) {
+	err1 := parameters.validate()
+	if err1 != nil {
+		return nil, err1
+	}
+	items, err2 := r.ListAllItems()
+	if err2 != nil {
+		return nil, err2
+	}
+	series, err3 := r.dailyDemand(parameters.HistoryDays)
+	if err3 != nil {
+		return nil, err3
+	}
+	suppliers, err4 := r.reorderSuppliers()
+	if err4 != nil {
+		return nil, err4
+	}
+	res := make([]ReplenishmentSuggestion, 0, len(items))
+	for _, item := range items {
+		supplier, ok := suppliers[item.ID]
+		if !ok {
+			res = append(res, suggestReplenishment(item, series[item.ID], parameters))
+			continue
+		}
+		itemParameters := parameters
+		itemParameters.LeadTimeDays = supplier.LeadTimeDays
+		suggestion := suggestReplenishment(item, series[item.ID], itemParameters)
+		if suggestion.SuggestedQuantity > 0 {
+			suggestion.SuggestedQuantity = max(suggestion.SuggestedQuantity, supplier.MinimumOrderQuantity)
+		}
+		suggestion.SupplierID = supplier.SupplierID
+		suggestion.SupplierName = supplier.SupplierName
+		suggestion.PartNumber = supplier.PartNumber
+		suggestion.UnitPrice = supplier.UnitPrice
+		res = append(res, suggestion)
+	}
+	// items running out first come first, items without demand last
+	sort.SliceStable(res, func(i, j int) bool {
+		if (res[i].DaysOfCover < 0) != (res[j].DaysOfCover < 0) {
+			return res[j].DaysOfCover < 0
+		}
+		return res[i].DaysOfCover < res[j].DaysOfCover
+	})
+	return res, nil
+}
+
+// dailyDemand returns the units consumed by each item on each of the last days, oldest day first and today last.
+// Items without any consumption in the period are missing from the map.
+func (r *GORMSQLiteWarehouseRepository) dailyDemand(days int) (map[uint][]float64, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := today.AddDate(0, 0, -days+1)
+	var movements []StockMovement
+	err := r.DB.Where("kind IN ? AND quantity < 0 AND created_at >= ?", demandMovements, start).Find(&movements).Error
+	if err != nil {
+		return nil, err
+	}
+	series := make(map[uint][]float64)
+	for _, movement := range movements {
+		if series[movement.ItemID] == nil {
+			series[movement.ItemID] = make([]float64, days)
+		}
+		created := movement.CreatedAt.In(now.Location())
+		day := int(time.Date(created.Year(), created.Month(), created.Day(), 0, 0, 0, 0, now.Location()).Sub(start).Hours()/24 + 0.5)
+		if day >= 0 && day < days {
+			series[movement.ItemID][day] -= float64(movement.Quantity)
+		}
+	}
+	return series, nil
+}
+
+// suggestReplenishment forecasts the daily demand of an item from its daily consumption, oldest day first,
+// and computes the quantity to reorder
+func suggestReplenishment(item Item, daily []float64, parameters ReplenishmentParameters) ReplenishmentSuggestion {
+	suggestion := ReplenishmentSuggestion{ItemID: item.ID, ItemName: item.Name, Quantity: item.Quantity, DaysOfCover: -1,
+		LeadTimeDays: parameters.LeadTimeDays}
+	if daily == nil {
+		return suggestion
+	}
+	total := 0.0
+	for _, consumed := range daily {
+		total += consumed
+	}
+	suggestion.Consumed = int(total)
+	recent := 0.0
+	for _, consumed := range daily[len(daily)-parameters.MovingAverageDays:] {
+		recent += consumed
+	}
+	suggestion.MovingAverage = recent / float64(parameters.MovingAverageDays)
+	suggestion.Smoothed = daily[0]
+	for _, consumed := range daily[1:] {
+		suggestion.Smoothed = parameters.SmoothingFactor*consumed + (1-parameters.SmoothingFactor)*suggestion.Smoothed
+	}
+	suggestion.DailyDemand = suggestion.Smoothed
+	if suggestion.DailyDemand <= 0 {
+		return suggestion
+	}
+	suggestion.DaysOfCover = float64(item.Quantity) / suggestion.DailyDemand
+	suggestion.SafetyStock = int(math.Ceil(suggestion.DailyDemand * float64(parameters.SafetyStockDays)))
+	suggestion.ReorderPoint = int(math.Ceil(suggestion.DailyDemand*float64(parameters.LeadTimeDays))) + suggestion.SafetyStock
+	if item.Quantity <= suggestion.ReorderPoint {
+		target := int(math.Ceil(suggestion.DailyDemand*float64(parameters.LeadTimeDays+parameters.ReviewPeriodDays))) + suggestion.SafetyStock
+		suggestion.SuggestedQuantity = max(target-item.Quantity, 0)
+	}
+	return suggestion
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReplenishment(t *testing.T) {
+	rep := newTestRepository(t, "test_replenishment.db")
+	_ = rep.CreateWarehouse("North", "Milan", 1000)
+	_ = rep.CreateWarehouse("South", "Naples", 1000)
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.CreateItem("helmets", "safety", "yellow helmets")
+	_ = rep.SupplyItems(1, 1, 20)
+	_ = rep.SupplyItems(2, 1, 500)
+	// 10 gloves consumed on each of the last 10 days, helmets only transferred
+	now := time.Now()
+	for day := 0; day < 10; day++ {
+		rep.DB.Create(&StockMovement{CreatedAt: now.AddDate(0, 0, -day), Kind: ConsumeMovement, ItemID: 1, WarehouseID: 1, Quantity: -10})
+	}
+	_ = rep.TransferItems(2, 1, 100, 2)
+	parameters := ReplenishmentParameters{HistoryDays: 10, MovingAverageDays: 5, SmoothingFactor: 0.5,
+		LeadTimeDays: 3, SafetyStockDays: 2, ReviewPeriodDays: 5}
+	t.Run("FindReplenishment", func(t *testing.T) {
+		suggestions, err := rep.FindReplenishment(parameters)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(suggestions) != 2 {
+			t.Fatalf("Incorrect number of suggestions.\nexpected number: 2 actual number: %d", len(suggestions))
+		}
+		gloves := suggestions[0]
+		if gloves.ItemID != 1 || gloves.Consumed != 100 {
+			t.Fatalf("Consumption wasn't computed correctly\nexpected: item 1, 100 consumed\nactual: item %d, %d consumed", gloves.ItemID, gloves.Consumed)
+		}
+		if math.Abs(gloves.MovingAverage-10) > 1e-9 || math.Abs(gloves.Smoothed-10) > 1e-9 {
+			t.Errorf("Forecast wasn't computed correctly\nexpected: 10, 10\nactual: %f, %f", gloves.MovingAverage, gloves.Smoothed)
+		}
+		if math.Abs(gloves.DaysOfCover-2) > 1e-9 {
+			t.Errorf("Days of cover weren't computed correctly\nexpected: 2\nactual: %f", gloves.DaysOfCover)
+		}
+		if gloves.SafetyStock != 20 || gloves.ReorderPoint != 50 || gloves.SuggestedQuantity != 80 {
+			t.Errorf("Suggestion wasn't computed correctly\nexpected: 20, 50, 80\nactual: %d, %d, %d", gloves.SafetyStock, gloves.ReorderPoint, gloves.SuggestedQuantity)
+		}
+		helmets := suggestions[1]
+		if helmets.Consumed != 0 || helmets.DaysOfCover >= 0 || helmets.SuggestedQuantity != 0 {
+			t.Errorf("Transfers were counted as demand: %+v", helmets)
+		}
+	})
+	t.Run("FindReplenishmentInvalidParameters", func(t *testing.T) {
+		invalid := parameters
+		invalid.SmoothingFactor = 1.5
+		_, err := rep.FindReplenishment(invalid)
+		if err == nil {
+			t.Errorf("No error reported for an invalid smoothing factor")
+		}
+	})
+	t.Run("ConsumeRecordsMovement", func(t *testing.T) {
+		_ = rep.ConsumeItems(2, 1, 40)
+		var movement StockMovement
+		_ = rep.DB.Last(&movement).Error
+		if movement.Kind != ConsumeMovement || movement.ItemID != 2 || movement.Quantity != -40 {
+			t.Errorf("Consumption wasn't recorded correctly: %+v", movement)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"strconv"
+	"time"
+)
+
+// ReturnKind tells whether goods come back from a customer or go back to a supplier
+type ReturnKind string
+
+const (
+	CustomerReturn ReturnKind = "customer"
+	SupplierReturn ReturnKind = "supplier"
+)
+
+// ReturnDisposition decides what happens to the goods returned by a customer
+type ReturnDisposition string
+
+const (
+	// RestockDisposition makes the returned goods available again
+	RestockDisposition ReturnDisposition = "restock"
+	// QuarantineDisposition keeps the returned goods in quarantine until they are inspected
+	QuarantineDisposition ReturnDisposition = "quarantine"
+	// ScrapDisposition records the receipt of the returned goods and throws them away
+	ScrapDisposition ReturnDisposition = "scrap"
+)
+
+// ReturnStatus tells whether the goods of a return authorisation have been received or sent yet
+type ReturnStatus string
+
+const (
+	AuthorizedReturn ReturnStatus = "authorized"
+	CompletedReturn  ReturnStatus = "completed"
+)
+
+// ReturnAuthorization (RMA) allows goods to come back from a customer or to be sent back to a supplier
+type ReturnAuthorization struct {
+	ID        uint `gorm:"primaryKey;<-:create;autoIncrement"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Kind      ReturnKind   `gorm:"not null"`
+	ItemID    uint         `gorm:"not null;index"`
+	Quantity  int          `gorm:"not null"`
+	Status    ReturnStatus `gorm:"not null;index"`
+	Reason    string
+	// OrderReference identifies the customer order or the supplier document of the goods, if any
+	OrderReference string
+	// MovementID is the stock movement which originally delivered or received the goods, if known
+	MovementID *uint
+	// WarehouseID, Disposition and CompletedQuantity are filled when the return is completed. Supplier returns have
+	// no disposition.
+	WarehouseID       uint
+	Disposition       ReturnDisposition
+	CompletedQuantity int `gorm:"not null;default:0"`
+	CompletedAt       *time.Time
+}
+
+// ReturnEntry is a ReturnAuthorization together with the names of its item and warehouse
+type ReturnEntry struct {
+	ReturnAuthorization
+	ItemName      string
+	WarehouseName string
+}
+
+func (r *GORMSQLiteWarehouseRepository) AuthorizeReturn(kind ReturnKind, itemID uint, quantity int, reason string, orderReference string, movementID *uint) (ReturnAuthorization, error) {
+	authorization := ReturnAuthorization{Kind: kind, ItemID: itemID, Quantity: quantity, Status: AuthorizedReturn,
+		Reason: reason, OrderReference: orderReference, MovementID: movementID}
+	if kind != CustomerReturn && kind != SupplierReturn {
+		return authorization, errors.New("unknown return kind: " + string(kind))
+	}
+	if quantity <= 0 {
+		return authorization, errors.New("quantity must be greater than 0")
+	}
+	var item Item
+	err1 := r.DB.First(&item, itemID).Error
+	if err1 != nil {
+		return authorization, err1
+	}
+	if movementID != nil {
+		var movement StockMovement
+		err2 := r.DB.First(&movement, *movementID).Error
+		if err2 != nil {
+			return authorization, errors.New("movement " + strconv.Itoa(int(*movementID)) + " not found")
+		}
+		if movement.ItemID != itemID {
+			return authorization, errors.New("movement " + strconv.Itoa(int(*movementID)) + " doesn't concern item \"" + item.Name + "\"")
+		}
+	}
+	err3 := r.DB.Create(&authorization).Error
+	return authorization, err3
+}
+
+func (r *GORMSQLiteWarehouseRepository) ReceiveReturn(returnID uint, warehouseID uint, quantity int, disposition ReturnDisposition) error {
+	if quantity <= 0 {
+		return errors.New("quantity must be greater than 0")
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		authorization, err1 := txRepository.findOpenReturn(returnID, CustomerReturn)
+		if err1 != nil {
+			return err1
+		}
+		if quantity > authorization.Quantity {
+			return errors.New("only " + strconv.Itoa(authorization.Quantity) + " items were authorized for return")
+		}
+		receipt := MovementNote{Note: "customer return " + strconv.Itoa(int(returnID))}
+		err2 := txRepository.withNote(receipt).supplyItems(authorization.ItemID, warehouseID, quantity, CustomerReturnMovement)
+		if err2 != nil {
+			return err2
+		}
+		var err3 error
+		switch disposition {
+		case RestockDisposition:
+		case QuarantineDisposition:
+			err3 = txRepository.addToBucket(authorization.ItemID, warehouseID, QuarantinedStatus, quantity)
+		case ScrapDisposition:
+			scrap := MovementNote{Reason: "scrap", Note: "customer return " + strconv.Itoa(int(returnID))}
+			err3 = txRepository.withNote(scrap).consumeItems(authorization.ItemID, warehouseID, quantity, ScrapMovement)
+		default:
+			err3 = errors.New("unknown return disposition: " + string(disposition))
+		}
+		if err3 != nil {
+			return err3
+		}
+		return txRepository.completeReturn(authorization, warehouseID, quantity, disposition)
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) ShipSupplierReturn(returnID uint, warehouseID uint, status StockStatus) error {
+	err1 := validateStockStatus(status)
+	if err1 != nil {
+		return err1
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		authorization, err2 := txRepository.findOpenReturn(returnID, SupplierReturn)
+		if err2 != nil {
+			return err2
+		}
+		// defective goods are usually quarantined or damaged, their bucket is emptied before consuming them
+		err3 := txRepository.addToBucket(authorization.ItemID, warehouseID, status, -authorization.Quantity)
+		if err3 != nil {
+			return err3
+		}
+		shipment := MovementNote{Note: "supplier return " + strconv.Itoa(int(returnID))}
+		err4 := txRepository.withNote(shipment).consumeItems(authorization.ItemID, warehouseID, authorization.Quantity, SupplierReturnMovement)
+		if err4 != nil {
+			return err4
+		}
+		return txRepository.completeReturn(authorization, warehouseID, authorization.Quantity, "")
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListReturns(status ReturnStatus) ([]ReturnEntry, error) {
+	var res []ReturnEntry
+	err := r.DB.Table("return_authorizations").
+		Select("return_authorizations.*, items.name AS item_name, warehouses.name AS warehouse_name").
+		Joins("LEFT JOIN items ON items.id = return_authorizations.item_id").
+		Joins("LEFT JOIN warehouses ON warehouses.id = return_authorizations.warehouse_id").
+		Where("return_authorizations.status = ?", status).Order("return_authorizations.id DESC").Scan(&res).Error
+	return res, err
+}
+
+// findOpenReturn loads a return authorisation of the given kind which hasn't been completed yet
+func (r *GORMSQLiteWarehouseRepository) findOpenReturn(returnID uint, kind ReturnKind) (ReturnAuthorization, error) {
+	var authorization ReturnAuthorization
+	err := r.DB.First(&authorization, returnID).Error
+	if err != nil {
+		return authorization, err
+	}
+	if authorization.Kind != kind {
+		return authorization, errors.New("return " + strconv.Itoa(int(returnID)) + " is a " + string(authorization.Kind) + " return")
+	}
+	if authorization.Status != AuthorizedReturn {
+		return authorization, errors.New("return " + strconv.Itoa(int(returnID)) + " was already completed")
+	}
+	return authorization, nil
+}
+
+// completeReturn records where and how many goods of a return authorisation were received or sent
+func (r *GORMSQLiteWarehouseRepository) completeReturn(authorization ReturnAuthorization, warehouseID uint, quantity int, disposition ReturnDisposition) error {
+	now := time.Now()
+	return r.DB.Model(&authorization).Updates(map[string]interface{}{"status": CompletedReturn, "warehouse_id": warehouseID,
+		"completed_quantity": quantity, "disposition": disposition, "completed_at": &now}).Error
+}
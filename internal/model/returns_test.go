@@ -0,0 +1,78 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestReturns(t *testing.T) {
+	rep := newTestRepository(t, "test_returns.db")
+	_ = rep.CreateWarehouse("North", "Milan", 100)
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.CreateItem("helmets", "safety", "yellow helmets")
+	_ = rep.SupplyItems(1, 1, 20)
+	_ = rep.ConsumeItems(1, 1, 10)
+	t.Run("AuthorizeReturn", func(t *testing.T) {
+		movementID := uint(2)
+		authorization, err1 := rep.AuthorizeReturn(CustomerReturn, 1, 6, "wrong size", "SO-12", &movementID)
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		if authorization.Status != AuthorizedReturn {
+			t.Errorf("Return wasn't authorized, status: %s", authorization.Status)
+		}
+		_, err2 := rep.AuthorizeReturn(CustomerReturn, 2, 1, "", "", &movementID)
+		if err2 == nil || err2.Error() != "movement 2 doesn't concern item \"helmets\"" {
+			t.Errorf("unexpected error: %v", err2)
+		}
+		_, err3 := rep.AuthorizeReturn("gift", 1, 1, "", "", nil)
+		if err3 == nil || err3.Error() != "unknown return kind: gift" {
+			t.Errorf("unexpected error: %v", err3)
+		}
+	})
+	t.Run("ReceiveReturn", func(t *testing.T) {
+		err1 := rep.ReceiveReturn(1, 1, 8, RestockDisposition)
+		if err1 == nil || err1.Error() != "only 6 items were authorized for return" {
+			t.Errorf("unexpected error: %v", err1)
+		}
+		err2 := rep.ReceiveReturn(1, 1, 5, QuarantineDisposition)
+		if err2 != nil {
+			t.Fatalf("Reported error: %v", err2)
+		}
+		lines, _ := rep.FindStockStatuses(1, 1)
+		if len(lines) != 1 || lines[0].Total != 15 || lines[0].Quarantined != 5 {
+			t.Errorf("Returned goods weren't quarantined: %+v", lines)
+		}
+		err3 := rep.ReceiveReturn(1, 1, 5, QuarantineDisposition)
+		if err3 == nil || err3.Error() != "return 1 was already completed" {
+			t.Errorf("unexpected error: %v", err3)
+		}
+		_, _ = rep.AuthorizeReturn(CustomerReturn, 1, 3, "broken", "", nil)
+		err4 := rep.ReceiveReturn(2, 1, 3, ScrapDisposition)
+		if err4 != nil {
+			t.Fatalf("Reported error: %v", err4)
+		}
+		gloves, _ := rep.FindItemByID(1)
+		if gloves.Quantity != 15 {
+			t.Errorf("Scrapped goods were kept\nexpected quantity: 15\nactual quantity: %d", gloves.Quantity)
+		}
+	})
+	t.Run("ShipSupplierReturn", func(t *testing.T) {
+		_, _ = rep.AuthorizeReturn(SupplierReturn, 1, 5, "defective", "PO-3", nil)
+		err1 := rep.ReceiveReturn(3, 1, 5, RestockDisposition)
+		if err1 == nil || err1.Error() != "return 3 is a supplier return" {
+			t.Errorf("unexpected error: %v", err1)
+		}
+		err2 := rep.ShipSupplierReturn(3, 1, QuarantinedStatus)
+		if err2 != nil {
+			t.Fatalf("Reported error: %v", err2)
+		}
+		lines, _ := rep.FindStockStatuses(1, 1)
+		if len(lines) != 1 || lines[0].Total != 10 || lines[0].Quarantined != 0 {
+			t.Errorf("Supplier return wasn't consumed correctly: %+v", lines)
+		}
+		completed, _ := rep.ListReturns(CompletedReturn)
+		if len(completed) != 3 || completed[0].WarehouseName != "North" || completed[2].OrderReference != "SO-12" {
+			t.Errorf("Completed returns weren't listed correctly: %+v", completed)
+		}
+	})
+}
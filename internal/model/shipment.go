@@ -0,0 +1,119 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"strconv"
+	"time"
+)
+
+// ShipmentStatus tells whether a shipment is still travelling
+type ShipmentStatus string
+
+const (
+	InTransitShipment ShipmentStatus = "in transit"
+	ReceivedShipment  ShipmentStatus = "received"
+)
+
+// Shipment is a transfer of stock between two warehouses which takes time: the dispatched quantity leaves the source
+// warehouse and stays in transit until the destination warehouse receives it
+type Shipment struct {
+	ID                     uint `gorm:"primaryKey;<-:create;autoIncrement"`
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	ItemID                 uint           `gorm:"not null;index"`
+	SourceWarehouseID      uint           `gorm:"not null"`
+	DestinationWarehouseID uint           `gorm:"not null;index"`
+	Quantity               int            `gorm:"not null"`
+	Status                 ShipmentStatus `gorm:"not null;index"`
+	// ReceivedQuantity may differ from Quantity when goods are lost or found on the way
+	ReceivedQuantity int `gorm:"not null;default:0"`
+	ReceivedAt       *time.Time
+}
+
+// Discrepancy is the quantity received in excess, or in defect when negative, of the dispatched one
+func (s Shipment) Discrepancy() int {
+	if s.Status != ReceivedShipment {
+		return 0
+	}
+	return s.ReceivedQuantity - s.Quantity
+}
+
+// ShipmentEntry is a Shipment together with the names of its item and warehouses
+type ShipmentEntry struct {
+	Shipment
+	ItemName        string
+	SourceName      string
+	DestinationName string
+}
+
+func (r *GORMSQLiteWarehouseRepository) DispatchShipment(itemID uint, sourceWarehouseID uint, quantity int, destinationWarehouseID uint) (Shipment, error) {
+	shipment := Shipment{ItemID: itemID, SourceWarehouseID: sourceWarehouseID, DestinationWarehouseID: destinationWarehouseID,
+		Quantity: quantity, Status: InTransitShipment}
+	if sourceWarehouseID == destinationWarehouseID {
+		return shipment, errors.New("source and destination warehouses must differ")
+	}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		var destination Warehouse
+		err1 := tx.First(&destination, destinationWarehouseID).Error
+		if err1 != nil {
+			return err1
+		}
+		err2 := txRepository.checkIfEnoughCapacity(destinationWarehouseID, nil, 0, quantity, destination)
+		if err2 != nil {
+			return err2
+		}
+		err3 := txRepository.consumeItems(itemID, sourceWarehouseID, quantity, DispatchMovement)
+		if err3 != nil {
+			return err3
+		}
+		return tx.Create(&shipment).Error
+	})
+	return shipment, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) ReceiveShipment(shipmentID uint, receivedQuantity int) error {
+	if receivedQuantity < 0 {
+		return errors.New("received quantity can't be negative")
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var shipment Shipment
+		err1 := tx.First(&shipment, shipmentID).Error
+		if err1 != nil {
+			return err1
+		}
+		if shipment.Status != InTransitShipment {
+			return errors.New("shipment " + strconv.Itoa(int(shipmentID)) + " was already received")
+		}
+		// the shipment stops being incoming before its items take up the room reserved for them
+		now := time.Now()
+		err2 := tx.Model(&shipment).Updates(map[string]interface{}{"status": ReceivedShipment,
+			"received_quantity": receivedQuantity, "received_at": &now}).Error
+		if err2 != nil {
+			return err2
+		}
+		if receivedQuantity == 0 {
+			return nil
+		}
+		return r.withTransaction(tx).supplyItems(shipment.ItemID, shipment.DestinationWarehouseID, receivedQuantity, ReceiptMovement)
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListShipments(status ShipmentStatus) ([]ShipmentEntry, error) {
+	var res []ShipmentEntry
+	err := r.DB.Table("shipments").
+		Select("shipments.*, items.name AS item_name, sources.name AS source_name, destinations.name AS destination_name").
+		Joins("LEFT JOIN items ON items.id = shipments.item_id").
+		Joins("LEFT JOIN warehouses AS sources ON sources.id = shipments.source_warehouse_id").
+		Joins("LEFT JOIN warehouses AS destinations ON destinations.id = shipments.destination_warehouse_id").
+		Where("shipments.status = ?", status).Order("shipments.id DESC").Scan(&res).Error
+	return res, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindIncomingQuantity(warehouseID uint) (int, error) {
+	var incoming int
+	err := r.DB.Model(&Shipment{}).Select("COALESCE(SUM(quantity), 0)").
+		Where("destination_warehouse_id = ? AND status = ?", warehouseID, InTransitShipment).Scan(&incoming).Error
+	return incoming, err
+}
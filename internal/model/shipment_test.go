@@ -0,0 +1,78 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestShipments(t *testing.T) {
+	rep := newTestRepository(t, "test_shipment.db")
+	_ = rep.CreateWarehouse("North", "Milan", 100)
+	_ = rep.CreateWarehouse("South", "Naples", 30)
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.SupplyItems(1, 1, 80)
+	t.Run("DispatchShipment", func(t *testing.T) {
+		shipment, err := rep.DispatchShipment(1, 1, 20, 2)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if shipment.ID != 1 || shipment.Status != InTransitShipment {
+			t.Errorf("Shipment wasn't created correctly: %+v", shipment)
+		}
+		var pack WarehouseItem
+		_ = rep.DB.First(&pack, "item_id = ? AND warehouse_id = ?", 1, 1).Error
+		if pack.Quantity != 60 {
+			t.Errorf("Dispatched items weren't removed from the source\nexpected quantity: 60\nactual quantity: %d", pack.Quantity)
+		}
+		incoming, _ := rep.FindIncomingQuantity(2)
+		if incoming != 20 {
+			t.Errorf("Incoming quantity isn't correct\nexpected quantity: 20\nactual quantity: %d", incoming)
+		}
+	})
+	t.Run("DispatchShipmentCountsIncoming", func(t *testing.T) {
+		_, err := rep.DispatchShipment(1, 1, 15, 2)
+		if err == nil || err.Error() != "warehouse is full: 35 > 30" {
+			t.Errorf("Capacity of the destination didn't account for the incoming items: %v", err)
+		}
+		_, err2 := rep.DispatchShipment(1, 1, 5, 1)
+		if err2 == nil {
+			t.Errorf("No error reported when source and destination are the same")
+		}
+	})
+	t.Run("IncomingReservesCapacity", func(t *testing.T) {
+		err1 := rep.SupplyItems(1, 2, 15)
+		if err1 == nil || err1.Error() != "warehouse is full: 35 > 30" {
+			t.Errorf("Supply took the room of the items in transit: %v", err1)
+		}
+		err2 := rep.DeleteWarehouse(2)
+		if err2 == nil || err2.Error() != "warehouse has shipments in transit to it" {
+			t.Errorf("unexpected error: %v", err2)
+		}
+		_ = rep.SupplyItems(1, 2, 10)
+	})
+	t.Run("ReceiveShipment", func(t *testing.T) {
+		err := rep.ReceiveShipment(1, 18)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		shipments, _ := rep.ListShipments(ReceivedShipment)
+		if len(shipments) != 1 || shipments[0].Discrepancy() != -2 || shipments[0].DestinationName != "South" {
+			t.Errorf("Receipt wasn't recorded correctly: %+v", shipments)
+		}
+		gloves, _ := rep.FindItemByID(1)
+		if gloves.Quantity != 88 {
+			t.Errorf("Received items weren't added\nexpected quantity: 88\nactual quantity: %d", gloves.Quantity)
+		}
+		incoming, _ := rep.FindIncomingQuantity(2)
+		if incoming != 0 {
+			t.Errorf("Received shipment is still incoming: %d", incoming)
+		}
+		err2 := rep.ReceiveShipment(1, 18)
+		if err2 == nil {
+			t.Errorf("No error reported when a shipment is received twice")
+		}
+		inconsistencies, _ := rep.CheckConsistency()
+		if len(inconsistencies) != 0 {
+			t.Errorf("Shipments left the repository inconsistent: %+v", inconsistencies)
+		}
+	})
+}
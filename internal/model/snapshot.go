@@ -0,0 +1,242 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+// snapshotInterval is the number of movements after which a snapshot of the stock is taken automatically
+const snapshotInterval = 500
+
+// InventorySnapshot records the content of every warehouse right after the movement LastMovementID, so that past
+// quantities can be rebuilt from the snapshot instead of from the whole stock history
+type InventorySnapshot struct {
+	ID             uint      `gorm:"primaryKey;<-:create;autoIncrement"`
+	CreatedAt      time.Time `gorm:"index"`
+	LastMovementID uint      `gorm:"not null"`
+}
+
+// SnapshotLine is the quantity of an item stored in a warehouse when a snapshot was taken
+type SnapshotLine struct {
+	SnapshotID  uint `gorm:"primaryKey"`
+	ItemID      uint `gorm:"primaryKey"`
+	WarehouseID uint `gorm:"primaryKey"`
+	Quantity    int  `gorm:"not null"`
+}
+
+// InventoryLine is the quantity of an item stored in a warehouse at some point in time
+type InventoryLine struct {
+	ItemID        uint
+	ItemName      string
+	WarehouseID   uint
+	WarehouseName string
+	Quantity      int
+}
+
+// InventoryDifference compares the quantity of an item stored in a warehouse at two points in time
+type InventoryDifference struct {
+	ItemID        uint
+	ItemName      string
+	WarehouseID   uint
+	WarehouseName string
+	Before        int
+	After         int
+	Change        int
+}
+
+// migrateSnapshots takes the first snapshot of the databases which had stock before the movements were recorded,
+// so that the stock they started with is part of the rebuilt quantities
+func (r *GORMSQLiteWarehouseRepository) migrateSnapshots() error {
+	var snapshots, movements, stock int64
+	err1 := r.DB.Model(&InventorySnapshot{}).Count(&snapshots).Error
+	if err1 != nil {
+		return err1
+	}
+	err2 := r.DB.Model(&StockMovement{}).Count(&movements).Error
+	if err2 != nil {
+		return err2
+	}
+	err3 := r.DB.Model(&WarehouseItem{}).Where("quantity <> 0").Count(&stock).Error
+	if err3 != nil {
+		return err3
+	}
+	if snapshots > 0 || movements > 0 || stock == 0 {
+		return nil
+	}
+	_, err4 := r.TakeInventorySnapshot()
+	return err4
+}
+
+func (r *GORMSQLiteWarehouseRepository) TakeInventorySnapshot() (InventorySnapshot, error) {
+	var snapshot InventorySnapshot
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		return r.withTransaction(tx).takeInventorySnapshot(&snapshot)
+	})
+	return snapshot, err
+}
+
+// takeInventorySnapshot copies the current content of the warehouses into a new snapshot
+func (r *GORMSQLiteWarehouseRepository) takeInventorySnapshot(snapshot *InventorySnapshot) error {
+	var last StockMovement
+	err1 := r.DB.Order("id DESC").Limit(1).Find(&last).Error
+	if err1 != nil {
+		return err1
+	}
+	snapshot.LastMovementID = last.ID
+	err2 := r.DB.Create(snapshot).Error
+	if err2 != nil {
+		return err2
+	}
+	return r.DB.Exec("INSERT INTO snapshot_lines (snapshot_id, item_id, warehouse_id, quantity) "+
+		"SELECT ?, item_id, warehouse_id, quantity FROM warehouse_items WHERE quantity <> 0", snapshot.ID).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListInventorySnapshots() ([]InventorySnapshot, error) {
+	var snapshots []InventorySnapshot
+	err := r.DB.Order("id DESC").Find(&snapshots).Error
+	return snapshots, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindInventoryAsOf(at time.Time, warehouseID uint) ([]InventoryLine, error) {
+	quantities, err1 := r.rebuildInventory(at, warehouseID)
+	if err1 != nil {
+		return nil, err1
+	}
+	names, err2 := r.inventoryNames()
+	if err2 != nil {
+		return nil, err2
+	}
+	res := make([]InventoryLine, 0, len(quantities))
+	for key, quantity := range quantities {
+		if quantity == 0 {
+			continue
+		}
+		res = append(res, InventoryLine{ItemID: key.ItemID, ItemName: names.items[key.ItemID], WarehouseID: key.WarehouseID,
+			WarehouseName: names.warehouses[key.WarehouseID], Quantity: quantity})
+	}
+	sortInventory(res, func(i int) (string, string) { return res[i].WarehouseName, res[i].ItemName })
+	return res, nil
+}
+
+func (r *GORMSQLiteWarehouseRepository) CompareInventory(from time.Time, to time.Time, warehouseID uint) ([]InventoryDifference, error) {
+	if to.Before(from) {
+		return nil, errors.New("the second date must follow the first one")
+	}
+	before, err1 := r.rebuildInventory(from, warehouseID)
+	if err1 != nil {
+		return nil, err1
+	}
+	after, err2 := r.rebuildInventory(to, warehouseID)
+	if err2 != nil {
+		return nil, err2
+	}
+	names, err3 := r.inventoryNames()
+	if err3 != nil {
+		return nil, err3
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			after[key] = 0
+		}
+	}
+	res := make([]InventoryDifference, 0, len(after))
+	for key, quantity := range after {
+		if quantity == before[key] {
+			continue
+		}
+		res = append(res, InventoryDifference{ItemID: key.ItemID, ItemName: names.items[key.ItemID], WarehouseID: key.WarehouseID,
+			WarehouseName: names.warehouses[key.WarehouseID], Before: before[key], After: quantity, Change: quantity - before[key]})
+	}
+	sortInventory(res, func(i int) (string, string) { return res[i].WarehouseName, res[i].ItemName })
+	return res, nil
+}
+
+// inventoryKey identifies the stock of an item in a warehouse
+type inventoryKey struct {
+	ItemID      uint
+	WarehouseID uint
+}
+
+// rebuildInventory computes the quantities stored at the given time starting from the last snapshot taken before it
+// and adding the movements which followed the snapshot. A warehouseID of 0 selects every warehouse.
+func (r *GORMSQLiteWarehouseRepository) rebuildInventory(at time.Time, warehouseID uint) (map[inventoryKey]int, error) {
+	res := make(map[inventoryKey]int)
+	var snapshot InventorySnapshot
+	err1 := r.DB.Where("created_at <= ?", at).Order("id DESC").Limit(1).Find(&snapshot).Error
+	if err1 != nil {
+		return nil, err1
+	}
+	if snapshot.ID != 0 {
+		var lines []SnapshotLine
+		query := r.DB.Where("snapshot_id = ?", snapshot.ID)
+		if warehouseID != 0 {
+			query = query.Where("warehouse_id = ?", warehouseID)
+		}
+		err2 := query.Find(&lines).Error
+		if err2 != nil {
+			return nil, err2
+		}
+		for _, line := range lines {
+			res[inventoryKey{line.ItemID, line.WarehouseID}] = line.Quantity
+		}
+	}
+	var movements []struct {
+		ItemID      uint
+		WarehouseID uint
+		Quantity    int
+	}
+	query := r.DB.Model(&StockMovement{}).Select("item_id, warehouse_id, SUM(quantity) AS quantity").
+		Where("id > ? AND created_at <= ?", snapshot.LastMovementID, at)
+	if warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	err3 := query.Group("item_id, warehouse_id").Scan(&movements).Error
+	if err3 != nil {
+		return nil, err3
+	}
+	for _, movement := range movements {
+		res[inventoryKey{movement.ItemID, movement.WarehouseID}] += movement.Quantity
+	}
+	return res, nil
+}
+
+// inventoryNameIndex maps the IDs of items and warehouses, including the deleted ones, to their names
+type inventoryNameIndex struct {
+	items      map[uint]string
+	warehouses map[uint]string
+}
+
+func (r *GORMSQLiteWarehouseRepository) inventoryNames() (inventoryNameIndex, error) {
+	index := inventoryNameIndex{items: make(map[uint]string), warehouses: make(map[uint]string)}
+	var items []Item
+	err1 := r.DB.Unscoped().Select("id, name").Find(&items).Error
+	if err1 != nil {
+		return index, err1
+	}
+	var warehouses []Warehouse
+	err2 := r.DB.Unscoped().Select("id, name").Find(&warehouses).Error
+	if err2 != nil {
+		return index, err2
+	}
+	for _, item := range items {
+		index.items[item.ID] = item.Name
+	}
+	for _, warehouse := range warehouses {
+		index.warehouses[warehouse.ID] = warehouse.Name
+	}
+	return index, nil
+}
+
+// sortInventory orders the rows by warehouse name and then by item name
+func sortInventory[T any](rows []T, names func(i int) (string, string)) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		warehouse1, item1 := names(i)
+		warehouse2, item2 := names(j)
+		if warehouse1 != warehouse2 {
+			return warehouse1 < warehouse2
+		}
+		return item1 < item2
+	})
+}
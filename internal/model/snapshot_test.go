@@ -0,0 +1,83 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInventorySnapshots(t *testing.T) {
+	rep := newTestRepository(t, "test_snapshot.db")
+	_ = rep.CreateWarehouse("North", "Milan", 100)
+	_ = rep.CreateWarehouse("South", "Naples", 100)
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.CreateItem("helmets", "safety", "yellow helmets")
+	now := time.Now()
+	// backdate moves the movements recorded by an operation to the given number of days ago
+	backdate := func(days int) {
+		rep.DB.Model(&StockMovement{}).Where("created_at > ?", now).Update("created_at", now.AddDate(0, 0, -days))
+	}
+	_ = rep.SupplyItems(1, 1, 50)
+	_ = rep.SupplyItems(2, 2, 20)
+	backdate(10)
+	_ = rep.ConsumeItems(1, 1, 10)
+	backdate(5)
+	_ = rep.TransferItems(1, 1, 5, 2)
+	backdate(2)
+	_, err := rep.TakeInventorySnapshot()
+	if err != nil {
+		t.Fatalf("Reported error: %v", err)
+	}
+	_ = rep.ConsumeItems(2, 2, 3)
+	t.Run("FindInventoryAsOf", func(t *testing.T) {
+		lines, err := rep.FindInventoryAsOf(now.AddDate(0, 0, -7), 0)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(lines) != 2 || lines[0].ItemName != "gloves" || lines[0].Quantity != 50 || lines[1].Quantity != 20 {
+			t.Errorf("Inventory wasn't rebuilt correctly: %+v", lines)
+		}
+		lines, _ = rep.FindInventoryAsOf(now.AddDate(0, 0, -3), 1)
+		if len(lines) != 1 || lines[0].Quantity != 40 {
+			t.Errorf("Inventory of a single warehouse wasn't rebuilt correctly: %+v", lines)
+		}
+	})
+	t.Run("FindInventoryAsOfAfterSnapshot", func(t *testing.T) {
+		lines, err := rep.FindInventoryAsOf(time.Now(), 0)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		var current []WarehouseItem
+		_ = rep.DB.Order("warehouse_id, item_id").Find(&current).Error
+		if len(lines) != len(current) {
+			t.Fatalf("Incorrect number of lines.\nexpected number: %d actual number: %d", len(current), len(lines))
+		}
+		for i, line := range lines {
+			if line.ItemID != current[i].ItemID || line.WarehouseID != current[i].WarehouseID || line.Quantity != current[i].Quantity {
+				t.Errorf("Current inventory wasn't rebuilt correctly\nexpected: %+v\nactual: %+v", current[i], line)
+			}
+		}
+	})
+	t.Run("CompareInventory", func(t *testing.T) {
+		differences, err := rep.CompareInventory(now.AddDate(0, 0, -7), time.Now(), 0)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		expected := []InventoryDifference{
+			{ItemID: 1, ItemName: "gloves", WarehouseID: 1, WarehouseName: "North", Before: 50, After: 35, Change: -15},
+			{ItemID: 1, ItemName: "gloves", WarehouseID: 2, WarehouseName: "South", Before: 0, After: 5, Change: 5},
+			{ItemID: 2, ItemName: "helmets", WarehouseID: 2, WarehouseName: "South", Before: 20, After: 17, Change: -3},
+		}
+		if len(differences) != len(expected) {
+			t.Fatalf("Incorrect number of differences.\nexpected number: %d actual number: %d", len(expected), len(differences))
+		}
+		for i := range expected {
+			if differences[i] != expected[i] {
+				t.Errorf("Difference wasn't computed correctly\nexpected: %+v\nactual: %+v", expected[i], differences[i])
+			}
+		}
+		_, err2 := rep.CompareInventory(time.Now(), now.AddDate(0, 0, -7), 0)
+		if err2 == nil {
+			t.Errorf("No error reported when the dates are reversed")
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"strconv"
+)
+
+// StockOperation identifies the kind of movement requested by a StockLine
+type StockOperation string
+
+const (
+	SupplyOperation   StockOperation = "supply"
+	ConsumeOperation  StockOperation = "consume"
+	TransferOperation StockOperation = "transfer"
+)
+
+// StockLine is a single supply, consume or transfer request belonging to a batch
+type StockLine struct {
+	Operation              StockOperation
+	ItemID                 uint
+	WarehouseID            uint
+	DestinationWarehouseID uint
+	Quantity               int
+}
+
+// StockLineResult reports the outcome of a StockLine when a batch is previewed. Error is empty when the line is valid.
+type StockLineResult struct {
+	Line  StockLine
+	Error string
+}
+
+// errPreviewRollback is returned inside a preview transaction to discard the changes made while validating
+var errPreviewRollback = errors.New("preview rollback")
+
+func (r *GORMSQLiteWarehouseRepository) ApplyStockBatch(lines []StockLine) error {
+	if len(lines) == 0 {
+		return errors.New("stock batch is empty")
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		for i, line := range lines {
+			err := txRepository.applyStockLine(line)
+			if err != nil {
+				return errors.New("line " + strconv.Itoa(i+1) + ": " + err.Error())
+			}
+		}
+		return nil
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) PreviewStockBatch(lines []StockLine) ([]StockLineResult, error) {
+	results := make([]StockLineResult, 0, len(lines))
+	err1 := r.DB.Transaction(func(tx *gorm.DB) error {
+		for _, line := range lines {
+			result := StockLineResult{Line: line}
+			// every line runs in its own savepoint so that a failing line doesn't leave partial changes behind
+			err2 := tx.Transaction(func(lineTx *gorm.DB) error {
+				return r.withTransaction(lineTx).applyStockLine(line)
+			})
+			if err2 != nil {
+				result.Error = err2.Error()
+			}
+			results = append(results, result)
+		}
+		return errPreviewRollback
+	})
+	if !errors.Is(err1, errPreviewRollback) {
+		return nil, err1
+	}
+	return results, nil
+}
+
+// applyStockLine forwards a StockLine to the corresponding stock operation
+func (r *GORMSQLiteWarehouseRepository) applyStockLine(line StockLine) error {
+	switch line.Operation {
+	case SupplyOperation:
+		return r.SupplyItems(line.ItemID, line.WarehouseID, line.Quantity)
+	case ConsumeOperation:
+		return r.ConsumeItems(line.ItemID, line.WarehouseID, line.Quantity)
+	case TransferOperation:
+		if line.WarehouseID == line.DestinationWarehouseID {
+			return errors.New("source and destination warehouses must be different")
+		}
+		return r.TransferItems(line.ItemID, line.WarehouseID, line.Quantity, line.DestinationWarehouseID)
+	default:
+		return errors.New("unknown stock operation: " + string(line.Operation))
+	}
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"os"
+	"testing"
+)
+
+// newTestRepository creates a repository on a fresh database file which is removed when the test ends
+func newTestRepository(t *testing.T, name string) *GORMSQLiteWarehouseRepository {
+	_ = os.Remove(name)
+	rep, err := NewGORMSQLiteWarehouseRepository(name)
+	if err != nil {
+		t.Fatalf("Reported error: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = rep.Close()
+		_ = os.Remove(name)
+	})
+	return rep
+}
+
+func TestStockBatch(t *testing.T) {
+	rep := newTestRepository(t, "test_batch.db")
+	_ = rep.CreateWarehouse("North", "Milan", 100)
+	_ = rep.CreateWarehouse("South", "Naples", 50)
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.CreateItem("helmets", "safety", "yellow helmets")
+	t.Run("ApplyStockBatch", func(t *testing.T) {
+		err := rep.ApplyStockBatch([]StockLine{
+			{Operation: SupplyOperation, ItemID: 1, WarehouseID: 1, Quantity: 60},
+			{Operation: SupplyOperation, ItemID: 2, WarehouseID: 1, Quantity: 30},
+			{Operation: TransferOperation, ItemID: 1, WarehouseID: 1, Quantity: 20, DestinationWarehouseID: 2},
+			{Operation: ConsumeOperation, ItemID: 2, WarehouseID: 1, Quantity: 5},
+		})
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		gloves, _ := rep.FindItemByID(1)
+		helmets, _ := rep.FindItemByID(2)
+		if gloves.Quantity != 60 || helmets.Quantity != 25 {
+			t.Errorf("Batch wasn't applied correctly\nexpected quantities: 60, 25\nactual quantities: %d, %d", gloves.Quantity, helmets.Quantity)
+		}
+		var pack WarehouseItem
+		_ = rep.DB.First(&pack, "item_id = ? AND warehouse_id = ?", 1, 2).Error
+		if pack.Quantity != 20 {
+			t.Errorf("Transfer line wasn't applied correctly\nexpected quantity: 20\nactual quantity: %d", pack.Quantity)
+		}
+	})
+	t.Run("ApplyStockBatchAllOrNothing", func(t *testing.T) {
+		err := rep.ApplyStockBatch([]StockLine{
+			{Operation: SupplyOperation, ItemID: 2, WarehouseID: 2, Quantity: 20},
+			{Operation: SupplyOperation, ItemID: 1, WarehouseID: 2, Quantity: 20},
+		})
+		if err == nil {
+			t.Fatalf("No error reported when the batch exceeds the capacity of a warehouse")
+		}
+		if err.Error() != "line 2: warehouse is full: 60 > 50" {
+			t.Errorf("unexpected error message: %s", err.Error())
+		}
+		helmets, _ := rep.FindItemByID(2)
+		if helmets.Quantity != 25 {
+			t.Errorf("Valid lines of a failed batch were applied\nexpected quantity: 25\nactual quantity: %d", helmets.Quantity)
+		}
+	})
+	t.Run("PreviewStockBatch", func(t *testing.T) {
+		results, err := rep.PreviewStockBatch([]StockLine{
+			{Operation: ConsumeOperation, ItemID: 1, WarehouseID: 2, Quantity: 15},
+			{Operation: ConsumeOperation, ItemID: 1, WarehouseID: 2, Quantity: 15},
+			{Operation: "sell", ItemID: 1, WarehouseID: 2, Quantity: 1},
+		})
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("Incorrect number of results.\nexpected number: 3 actual number: %d", len(results))
+		}
+		if results[0].Error != "" {
+			t.Errorf("Valid line reported as invalid: %s", results[0].Error)
+		}
+		if results[1].Error != "not enough items in specified warehouse: 5 < 15" {
+			t.Errorf("unexpected error message: %s", results[1].Error)
+		}
+		if results[2].Error != "unknown stock operation: sell" {
+			t.Errorf("unexpected error message: %s", results[2].Error)
+		}
+		gloves, _ := rep.FindItemByID(1)
+		if gloves.Quantity != 60 {
+			t.Errorf("Preview changed the stock\nexpected quantity: 60\nactual quantity: %d", gloves.Quantity)
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
+)
+
+// StockStatus tells whether some stock can be used. Only available stock can be consumed and transferred.
+type StockStatus string
+
+const (
+	AvailableStatus   StockStatus = "available"
+	QuarantinedStatus StockStatus = "quarantined"
+	DamagedStatus     StockStatus = "damaged"
+	OnHoldStatus      StockStatus = "on hold"
+)
+
+// StockStatuses lists every status, available first
+var StockStatuses = []StockStatus{AvailableStatus, QuarantinedStatus, DamagedStatus, OnHoldStatus}
+
+// StockStatusBucket is the part of a WarehouseItem which isn't available. The available quantity is the one of the
+// WarehouseItem minus the quantities of its buckets.
+type StockStatusBucket struct {
+	ItemID      uint        `gorm:"primaryKey"`
+	WarehouseID uint        `gorm:"primaryKey"`
+	Status      StockStatus `gorm:"primaryKey"`
+	Quantity    int         `gorm:"not null"`
+}
+
+// StockStatusLine is the breakdown by status of the stock of an item in a warehouse
+type StockStatusLine struct {
+	ItemID        uint
+	ItemName      string
+	WarehouseID   uint
+	WarehouseName string
+	Total         int
+	Available     int
+	Quarantined   int
+	Damaged       int
+	OnHold        int
+	OnLoan        int
+}
+
+// validateStockStatus checks that the status is one of the known ones
+func validateStockStatus(status StockStatus) error {
+	for _, known := range StockStatuses {
+		if status == known {
+			return nil
+		}
+	}
+	return errors.New("unknown stock status: " + string(status))
+}
+
+func (r *GORMSQLiteWarehouseRepository) SupplyItemsWithStatus(itemID uint, warehouseID uint, quantity int, status StockStatus, note MovementNote) error {
+	err1 := validateStockStatus(status)
+	if err1 != nil {
+		return err1
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx).withNote(note)
+		err2 := txRepository.checkReasonCode(note.Reason, false)
+		if err2 != nil {
+			return err2
+		}
+		err2 = txRepository.supplyItems(itemID, warehouseID, quantity, SupplyMovement)
+		if err2 != nil {
+			return err2
+		}
+		return txRepository.addToBucket(itemID, warehouseID, status, quantity)
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) ConsumeItemsFromStatus(itemID uint, warehouseID uint, quantity int, status StockStatus, note MovementNote) error {
+	err1 := validateStockStatus(status)
+	if err1 != nil {
+		return err1
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx).withNote(note)
+		err2 := errors.Join(txRepository.checkReasonCode(note.Reason, false), txRepository.checkProject(note.ProjectID))
+		if err2 != nil {
+			return err2
+		}
+		// emptying the bucket first makes the quantity available to consumeItems
+		err2 = txRepository.addToBucket(itemID, warehouseID, status, -quantity)
+		if err2 != nil {
+			return err2
+		}
+		return txRepository.consumeItems(itemID, warehouseID, quantity, ConsumeMovement)
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) ChangeStockStatus(itemID uint, warehouseID uint, quantity int, from StockStatus, to StockStatus) error {
+	if quantity <= 0 {
+		return errors.New("quantity must be greater than 0")
+	}
+	err1 := errors.Join(validateStockStatus(from), validateStockStatus(to))
+	if err1 != nil {
+		return err1
+	}
+	if from == to {
+		return errors.New("the stock is already " + string(to))
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		if from == AvailableStatus {
+			available, err2 := txRepository.availableQuantity(itemID, warehouseID)
+			if err2 != nil {
+				return err2
+			}
+			if available < quantity {
+				return errors.New("not enough available items in specified warehouse: " + strconv.Itoa(available) + " < " + strconv.Itoa(quantity))
+			}
+		}
+		err3 := txRepository.addToBucket(itemID, warehouseID, from, -quantity)
+		if err3 != nil {
+			return err3
+		}
+		return txRepository.addToBucket(itemID, warehouseID, to, quantity)
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindStockStatuses(itemID uint, warehouseID uint) ([]StockStatusLine, error) {
+	var res []StockStatusLine
+	bucket := func(status StockStatus) string {
+		return "COALESCE((SELECT quantity FROM stock_status_buckets AS buckets WHERE buckets.item_id = warehouse_items.item_id " +
+			"AND buckets.warehouse_id = warehouse_items.warehouse_id AND buckets.status = '" + string(status) + "'), 0)"
+	}
+	query := r.DB.Table("warehouse_items").
+		Select("warehouse_items.item_id, items.name AS item_name, warehouse_items.warehouse_id, warehouses.name AS warehouse_name, " +
+			"warehouse_items.quantity AS total, " + bucket(QuarantinedStatus) + " AS quarantined, " + bucket(DamagedStatus) +
+			" AS damaged, " + bucket(OnHoldStatus) + " AS on_hold, " + bucket(LoanedStatus) + " AS on_loan").
+		Joins(validAssociations).Where("warehouse_items.quantity <> 0")
+	if itemID != 0 {
+		query = query.Where("warehouse_items.item_id = ?", itemID)
+	}
+	if warehouseID != 0 {
+		query = query.Where("warehouse_items.warehouse_id = ?", warehouseID)
+	}
+	err := query.Order("warehouses.name, items.name").Scan(&res).Error
+	for i := range res {
+		res[i].Available = res[i].Total - res[i].Quarantined - res[i].Damaged - res[i].OnHold - res[i].OnLoan
+	}
+	return res, err
+}
+
+// availableQuantity returns the quantity of an item stored in a warehouse which isn't in any bucket
+func (r *GORMSQLiteWarehouseRepository) availableQuantity(itemID uint, warehouseID uint) (int, error) {
+	var total, held int
+	err1 := r.DB.Model(&WarehouseItem{}).Select("COALESCE(SUM(quantity), 0)").
+		Where("item_id = ? AND warehouse_id = ?", itemID, warehouseID).Scan(&total).Error
+	if err1 != nil {
+		return 0, err1
+	}
+	err2 := r.DB.Model(&StockStatusBucket{}).Select("COALESCE(SUM(quantity), 0)").
+		Where("item_id = ? AND warehouse_id = ?", itemID, warehouseID).Scan(&held).Error
+	if err2 != nil {
+		return 0, err2
+	}
+	return total - held, nil
+}
+
+// addToBucket adds quantity, which may be negative, to the bucket of a status. Available stock has no bucket.
+func (r *GORMSQLiteWarehouseRepository) addToBucket(itemID uint, warehouseID uint, status StockStatus, quantity int) error {
+	if status == AvailableStatus {
+		return nil
+	}
+	var bucket StockStatusBucket
+	err1 := r.DB.Where("item_id = ? AND warehouse_id = ? AND status = ?", itemID, warehouseID, status).Limit(1).Find(&bucket).Error
+	if err1 != nil {
+		return err1
+	}
+	if bucket.Quantity+quantity < 0 {
+		return errors.New("not enough " + string(status) + " items in specified warehouse: " + strconv.Itoa(bucket.Quantity) +
+			" < " + strconv.Itoa(-quantity))
+	}
+	if bucket.Quantity+quantity == 0 {
+		return r.DB.Delete(&StockStatusBucket{}, "item_id = ? AND warehouse_id = ? AND status = ?", itemID, warehouseID, status).Error
+	}
+	return r.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&StockStatusBucket{ItemID: itemID, WarehouseID: warehouseID,
+		Status: status, Quantity: bucket.Quantity + quantity}).Error
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestStockStatus(t *testing.T) {
+	rep := newTestRepository(t, "test_status.db")
+	_ = rep.CreateWarehouse("North", "Milan", 100)
+	_ = rep.CreateWarehouse("South", "Naples", 100)
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.SupplyItems(1, 1, 20)
+	t.Run("SupplyItemsWithStatus", func(t *testing.T) {
+		err := rep.SupplyItemsWithStatus(1, 1, 10, QuarantinedStatus, MovementNote{})
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		lines, _ := rep.FindStockStatuses(1, 1)
+		if len(lines) != 1 || lines[0].Total != 30 || lines[0].Available != 20 || lines[0].Quarantined != 10 {
+			t.Fatalf("Quarantined supply wasn't recorded correctly: %+v", lines)
+		}
+		err2 := rep.SupplyItemsWithStatus(1, 1, 10, "lost", MovementNote{})
+		if err2 == nil || err2.Error() != "unknown stock status: lost" {
+			t.Errorf("unexpected error: %v", err2)
+		}
+	})
+	t.Run("ConsumeAvailableOnly", func(t *testing.T) {
+		err1 := rep.ConsumeItems(1, 1, 25)
+		if err1 == nil || err1.Error() != "not enough available items in specified warehouse: 20 < 25" {
+			t.Errorf("unexpected error: %v", err1)
+		}
+		err2 := rep.TransferItems(1, 1, 25, 2)
+		if err2 == nil {
+			t.Errorf("No error reported when transferring quarantined items")
+		}
+		gloves, _ := rep.FindItemByID(1)
+		if gloves.Quantity != 30 {
+			t.Errorf("Failed consumption changed the stock\nexpected quantity: 30\nactual quantity: %d", gloves.Quantity)
+		}
+	})
+	t.Run("ChangeStockStatus", func(t *testing.T) {
+		err1 := rep.ChangeStockStatus(1, 1, 6, QuarantinedStatus, AvailableStatus)
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		err2 := rep.ChangeStockStatus(1, 1, 4, QuarantinedStatus, DamagedStatus)
+		if err2 != nil {
+			t.Fatalf("Reported error: %v", err2)
+		}
+		err3 := rep.ChangeStockStatus(1, 1, 1, QuarantinedStatus, OnHoldStatus)
+		if err3 == nil || err3.Error() != "not enough quarantined items in specified warehouse: 0 < 1" {
+			t.Errorf("unexpected error: %v", err3)
+		}
+		err4 := rep.ChangeStockStatus(1, 1, 30, AvailableStatus, OnHoldStatus)
+		if err4 == nil || err4.Error() != "not enough available items in specified warehouse: 26 < 30" {
+			t.Errorf("unexpected error: %v", err4)
+		}
+		lines, _ := rep.FindStockStatuses(0, 1)
+		if len(lines) != 1 || lines[0].Available != 26 || lines[0].Quarantined != 0 || lines[0].Damaged != 4 {
+			t.Errorf("Statuses weren't changed correctly: %+v", lines)
+		}
+	})
+	t.Run("ConsumeItemsFromStatus", func(t *testing.T) {
+		err1 := rep.ConsumeItemsFromStatus(1, 1, 4, DamagedStatus, MovementNote{})
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		err2 := rep.TransferItems(1, 1, 26, 2)
+		if err2 != nil {
+			t.Fatalf("Reported error: %v", err2)
+		}
+		lines, _ := rep.FindStockStatuses(1, 0)
+		if len(lines) != 1 || lines[0].WarehouseID != 2 || lines[0].Available != 26 || lines[0].Damaged != 0 {
+			t.Errorf("Stock wasn't consumed correctly: %+v", lines)
+		}
+	})
+}
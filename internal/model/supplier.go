@@ -0,0 +1,139 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+)
+
+// Supplier is a company the items are purchased from
+type Supplier struct {
+	ID        uint `gorm:"primaryKey;<-:create;autoIncrement"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string `gorm:"unique;not null"`
+	Contact   string
+}
+
+// SupplierItem is an entry of the catalog of a supplier: the conditions at which the supplier sells an item
+type SupplierItem struct {
+	SupplierID uint `gorm:"primaryKey"`
+	ItemID     uint `gorm:"primaryKey;index"`
+	// PartNumber is the code of the item in the catalog of the supplier
+	PartNumber           string  `gorm:"index"`
+	UnitPrice            float64 `gorm:"not null;default:0"`
+	MinimumOrderQuantity int     `gorm:"not null;default:1"`
+	LeadTimeDays         int     `gorm:"not null;default:0"`
+	// Preferred marks the supplier the item is reordered from, at most one per item
+	Preferred bool `gorm:"not null;default:false"`
+}
+
+// SupplierItemEntry is a SupplierItem together with the names of its supplier and item
+type SupplierItemEntry struct {
+	SupplierItem
+	SupplierName string
+	ItemName     string
+}
+
+func (r *GORMSQLiteWarehouseRepository) CreateSupplier(name string, contact string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("supplier name can't be empty")
+	}
+	return r.DB.Create(&Supplier{Name: name, Contact: contact}).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListSuppliers() ([]Supplier, error) {
+	var suppliers []Supplier
+	err := r.DB.Order("name").Find(&suppliers).Error
+	return suppliers, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) DeleteSupplier(supplierID uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var supplier Supplier
+		err1 := tx.First(&supplier, supplierID).Error
+		if err1 != nil {
+			return err1
+		}
+		err2 := tx.Where("supplier_id = ?", supplierID).Delete(&SupplierItem{}).Error
+		if err2 != nil {
+			return err2
+		}
+		return tx.Delete(&supplier).Error
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) SetSupplierItem(entry SupplierItem) error {
+	if entry.UnitPrice < 0 || entry.LeadTimeDays < 0 {
+		return errors.New("unit price and lead time can't be negative")
+	}
+	if entry.MinimumOrderQuantity < 1 {
+		return errors.New("minimum order quantity must be at least 1")
+	}
+	entry.PartNumber = strings.TrimSpace(entry.PartNumber)
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err1 := tx.First(&Supplier{}, entry.SupplierID).Error
+		if err1 != nil {
+			return err1
+		}
+		err2 := tx.First(&Item{}, entry.ItemID).Error
+		if err2 != nil {
+			return err2
+		}
+		if entry.Preferred {
+			err3 := tx.Model(&SupplierItem{}).Where("item_id = ? AND supplier_id <> ?", entry.ItemID, entry.SupplierID).
+				Update("preferred", false).Error
+			if err3 != nil {
+				return err3
+			}
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&entry).Error
+	})
+}
+
+func (r *GORMSQLiteWarehouseRepository) RemoveSupplierItem(supplierID uint, itemID uint) error {
+	return r.DB.Where("supplier_id = ? AND item_id = ?", supplierID, itemID).Delete(&SupplierItem{}).Error
+}
+
+func (r *GORMSQLiteWarehouseRepository) ListSupplierItems(itemID uint, supplierID uint) ([]SupplierItemEntry, error) {
+	var res []SupplierItemEntry
+	query := r.DB.Table("supplier_items").
+		Select("supplier_items.*, suppliers.name AS supplier_name, items.name AS item_name").
+		Joins("JOIN suppliers ON suppliers.id = supplier_items.supplier_id").
+		Joins("JOIN items ON items.id = supplier_items.item_id AND items.deleted_at IS NULL")
+	if itemID != 0 {
+		query = query.Where("supplier_items.item_id = ?", itemID)
+	}
+	if supplierID != 0 {
+		query = query.Where("supplier_items.supplier_id = ?", supplierID)
+	}
+	err := query.Order("supplier_items.preferred DESC, suppliers.name, items.name").Scan(&res).Error
+	return res, err
+}
+
+func (r *GORMSQLiteWarehouseRepository) FindItemsBySupplierPartNumber(partNumber string) ([]Item, error) {
+	var items []Item
+	partNumbers := r.DB.Model(&SupplierItem{}).Select("item_id").Where("part_number LIKE ?", "%"+strings.TrimSpace(partNumber)+"%")
+	err := r.DB.Where("id IN (?) AND archived = ?", partNumbers, false).Find(&items).Error
+	return items, err
+}
+
+// reorderSuppliers returns for each item the supplier it is reordered from: the preferred one or, when none is
+// preferred, the one with the shortest lead time
+func (r *GORMSQLiteWarehouseRepository) reorderSuppliers() (map[uint]SupplierItemEntry, error) {
+	entries, err := r.ListSupplierItems(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[uint]SupplierItemEntry)
+	for _, entry := range entries {
+		current, ok := res[entry.ItemID]
+		if !ok || !current.Preferred && (entry.Preferred || entry.LeadTimeDays < current.LeadTimeDays) {
+			res[entry.ItemID] = entry
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSuppliers(t *testing.T) {
+	rep := newTestRepository(t, "test_suppliers.db")
+	_ = rep.CreateWarehouse("North", "Milan", 1000)
+	_ = rep.CreateItem("gloves", "safety", "work gloves")
+	_ = rep.CreateItem("helmets", "safety", "yellow helmets")
+	_ = rep.SupplyItems(1, 1, 20)
+	now := time.Now()
+	for day := 0; day < 10; day++ {
+		rep.DB.Create(&StockMovement{CreatedAt: now.AddDate(0, 0, -day), Kind: ConsumeMovement, ItemID: 1, WarehouseID: 1, Quantity: -10})
+	}
+	_ = rep.CreateSupplier("Acme", "orders@acme.example")
+	_ = rep.CreateSupplier("Quick Supplies", "")
+	t.Run("SetSupplierItem", func(t *testing.T) {
+		err1 := rep.SetSupplierItem(SupplierItem{SupplierID: 1, ItemID: 1, PartNumber: "AC-GL-01", UnitPrice: 1.5,
+			MinimumOrderQuantity: 200, LeadTimeDays: 5, Preferred: true})
+		if err1 != nil {
+			t.Fatalf("Reported error: %v", err1)
+		}
+		err2 := rep.SetSupplierItem(SupplierItem{SupplierID: 2, ItemID: 1, PartNumber: "QS-77", UnitPrice: 2,
+			MinimumOrderQuantity: 1, LeadTimeDays: 1, Preferred: true})
+		if err2 != nil {
+			t.Fatalf("Reported error: %v", err2)
+		}
+		err3 := rep.SetSupplierItem(SupplierItem{SupplierID: 2, ItemID: 2, MinimumOrderQuantity: 0})
+		if err3 == nil || err3.Error() != "minimum order quantity must be at least 1" {
+			t.Errorf("unexpected error: %v", err3)
+		}
+		// only the last preferred supplier stays preferred
+		_ = rep.SetSupplierItem(SupplierItem{SupplierID: 1, ItemID: 1, PartNumber: "AC-GL-01", UnitPrice: 1.5,
+			MinimumOrderQuantity: 200, LeadTimeDays: 5, Preferred: true})
+		entries, _ := rep.ListSupplierItems(1, 0)
+		if len(entries) != 2 || entries[0].SupplierName != "Acme" || !entries[0].Preferred || entries[1].Preferred {
+			t.Errorf("Preferred supplier wasn't changed: %+v", entries)
+		}
+	})
+	t.Run("FindItemsBySupplierPartNumber", func(t *testing.T) {
+		items, err := rep.FindItemsBySupplierPartNumber("gl-01")
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		if len(items) != 1 || items[0].Name != "gloves" {
+			t.Errorf("Item wasn't found by part number: %+v", items)
+		}
+	})
+	t.Run("FindReplenishmentWithSupplier", func(t *testing.T) {
+		parameters := ReplenishmentParameters{HistoryDays: 10, MovingAverageDays: 5, SmoothingFactor: 0.5,
+			LeadTimeDays: 3, SafetyStockDays: 2, ReviewPeriodDays: 5}
+		suggestions, err := rep.FindReplenishment(parameters)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		gloves := suggestions[0]
+		if gloves.SupplierName != "Acme" || gloves.LeadTimeDays != 5 || gloves.PartNumber != "AC-GL-01" {
+			t.Errorf("Preferred supplier wasn't used: %+v", gloves)
+		}
+		// the lead time of 5 days moves the reorder point from 50 to 70 and the order of 100 up to the minimum of 200
+		if gloves.ReorderPoint != 70 || gloves.SuggestedQuantity != 200 {
+			t.Errorf("Suggestion wasn't computed correctly\nexpected: 70, 200\nactual: %d, %d", gloves.ReorderPoint, gloves.SuggestedQuantity)
+		}
+		if suggestions[1].SupplierID != 0 || suggestions[1].LeadTimeDays != 3 {
+			t.Errorf("Item without supplier didn't use the default lead time: %+v", suggestions[1])
+		}
+	})
+	t.Run("DeleteSupplier", func(t *testing.T) {
+		err := rep.DeleteSupplier(1)
+		if err != nil {
+			t.Fatalf("Reported error: %v", err)
+		}
+		entries, _ := rep.ListSupplierItems(0, 0)
+		if len(entries) != 1 || entries[0].SupplierID != 2 {
+			t.Errorf("Catalog of the deleted supplier wasn't removed: %+v", entries)
+		}
+	})
+}
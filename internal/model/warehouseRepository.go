@@ -5,6 +5,7 @@ import (
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,10 +14,14 @@ type Warehouse struct {
 	ID        uint `gorm:"primaryKey;<-:create;autoIncrement"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	DeletedAt gorm.DeletedAt `gorm:"index"`
-	Name      string         `gorm:"unique;not null"`
-	Position  string         `gorm:"not null"`
-	Capacity  int            `gorm:"not null"`
+	DeletedAt gorm.DeletedAt    `gorm:"index"`
+	Name      string            `gorm:"unique;not null"`
+	Position  string            `gorm:"not null"`
+	Capacity  int               `gorm:"not null"`
+	Version   uint              `gorm:"not null;default:1"`
+	Location  WarehouseLocation `gorm:"embedded"`
+	// PutawayPriority orders the warehouses receiving a supply split by the putaway, highest first
+	PutawayPriority int `gorm:"not null;default:0"`
 }
 
 // Item is struct representing a model used to store information about registered items for users
@@ -27,8 +32,21 @@ type Item struct {
 	DeletedAt   gorm.DeletedAt `gorm:"index"`
 	Name        string         `gorm:"unique;not null"`
 	Description string         `gorm:"default:'No description'"`
-	Category    string         `gorm:"default:'No category'"`
-	Quantity    int            `gorm:"not null;default:0"`
+	// Category is the name of the category the item belongs to, kept for display and updated with the Category itself
+	Category   string `gorm:"default:'No category'"`
+	CategoryID *uint  `gorm:"index"`
+	// ProductID is set when the item is a variant of a Product
+	ProductID *uint   `gorm:"index"`
+	Quantity  int     `gorm:"not null;default:0"`
+	Version   uint    `gorm:"not null;default:1"`
+	UnitCost  float64 `gorm:"not null;default:0"`
+	// ABCClass and XYZClass are the classes assigned by the last ClassifyItems, empty if it never ran
+	ABCClass string
+	XYZClass string
+	// Archived items are hidden from the lists and can't be supplied anymore, but keep their stock and history
+	Archived bool `gorm:"not null;default:false;index"`
+	// ApprovalThreshold is the quantity above which consuming or transferring the item needs an approval, 0 for none
+	ApprovalThreshold int `gorm:"not null;default:0"`
 }
 
 // WarehouseItem is a struct used to create a model with GORM representing the many-to-many association between Items and AllWarehouses
@@ -51,6 +69,20 @@ type LoadedItemPack struct {
 	WarehouseCapacity int
 }
 
+// VersionConflictError is returned when a record is updated starting from a version which is no longer the current one,
+// meaning that someone else modified the record in the meantime
+type VersionConflictError struct {
+	Resource        string
+	ID              uint
+	ExpectedVersion uint
+	CurrentVersion  uint
+}
+
+func (e *VersionConflictError) Error() string {
+	return e.Resource + " " + strconv.Itoa(int(e.ID)) + " was modified by someone else: version " +
+		strconv.Itoa(int(e.ExpectedVersion)) + " is outdated, current version is " + strconv.Itoa(int(e.CurrentVersion))
+}
+
 // WarehouseRepository is an interface used to define repositories used by the application
 type WarehouseRepository interface {
 
@@ -80,7 +112,8 @@ type WarehouseRepository interface {
 	// Returns a slice of Warehouse structs and an error if any issues occur during the query.
 	FindWarehousesByPosition(position string) ([]Warehouse, error)
 
-	// FindItemsByCategory retrieves a list of items that belong to the specified category from the repository.
+	// FindItemsByCategory retrieves a list of items that belong to the specified category or to one of its subcategories.
+	// The name of the category is matched regardless of its case.
 	FindItemsByCategory(category string) ([]Item, error)
 
 	// FindItemsInWarehouse retrieves a list of LoadedItemPack for a specific warehouse identified by warehouseID.
@@ -91,21 +124,26 @@ type WarehouseRepository interface {
 	FindWarehousesForItem(itemID uint) ([]LoadedItemPack, error)
 
 	// CreateItem creates a new item with the specified name, category, and description in the repository.
+	// Categories are matched regardless of their case and missing ones are created at the top level.
 	CreateItem(name string, category string, description string) error
 
 	// CreateWarehouse creates a new warehouse record with the specified name, position, and capacity.
 	CreateWarehouse(name string, position string, capacity int) error
 
 	// UpdateItem updates the details of an item identified by itemID, including its name, category, and description.
-	UpdateItem(itemID uint, name string, category string, description string) error
+	// The version must be the one the changes are based on, otherwise a *VersionConflictError is returned.
+	UpdateItem(itemID uint, name string, category string, description string, version uint) error
 
 	// UpdateWarehouse updates the warehouse information such as name, position, and capacity by its unique ID.
-	UpdateWarehouse(warehouseID uint, name string, position string, capacity int) error
+	// The version must be the one the changes are based on, otherwise a *VersionConflictError is returned.
+	UpdateWarehouse(warehouseID uint, name string, position string, capacity int, version uint) error
 
-	// DeleteItem removes an item from the repository using its unique identifier when it is empty. Returns an error if the operation fails.
+	// DeleteItem removes an item from the repository using its unique identifier when it is empty, together with its attachments.
+	// Returns an error if the operation fails.
 	DeleteItem(itemID uint) error
 
-	// DeleteWarehouse removes a warehouse record from the system using its unique identifier (warehouseID) when it is empty. It returns an error if the deletion fails.
+	// DeleteWarehouse removes a warehouse record from the system using its unique identifier (warehouseID) when it is empty,
+	// together with its attachments. It returns an error if the deletion fails.
 	DeleteWarehouse(warehouseID uint) error
 
 	// SupplyItems adds the specified quantity of an item to the inventory of a given warehouse.
@@ -118,6 +156,312 @@ type WarehouseRepository interface {
 	// TransferItems transfers a specified quantity of an item from one warehouse to another. Returns an error on failure.
 	TransferItems(itemID uint, sourceWarehouseID uint, quantity int, destinationWarehouseID uint) error
 
+	// ApplyStockBatch applies a list of supply, consume and transfer lines atomically: either every line succeeds or none is applied.
+	// Capacity and availability are validated against the state left by the previous lines of the batch.
+	ApplyStockBatch(lines []StockLine) error
+
+	// PreviewStockBatch validates a list of stock lines without applying them and returns the outcome of every line.
+	PreviewStockBatch(lines []StockLine) ([]StockLineResult, error)
+
+	// CheckConsistency scans the repository for item totals which don't match the association table, orphaned associations,
+	// negative quantities and over-capacity warehouses.
+	CheckConsistency() ([]Inconsistency, error)
+
+	// RepairConsistency fixes the repairable problems by recomputing them from the association table and returns the problems found before the repair.
+	RepairConsistency() ([]Inconsistency, error)
+
+	// UpdateWarehouseLocation sets the coordinates and the structured address of a warehouse. Coordinates are nil when unknown.
+	UpdateWarehouseLocation(warehouseID uint, location WarehouseLocation) error
+
+	// FindNearestStock retrieves the warehouses holding at least minQuantity of an item ordered by their haversine distance
+	// from the given point. Warehouses without coordinates are left out.
+	FindNearestStock(itemID uint, minQuantity int, latitude float64, longitude float64) ([]NearbyStock, error)
+
+	// ListCategories returns every category in depth-first order, children sorted by name, together with its item counts.
+	ListCategories() ([]CategoryNode, error)
+
+	// CreateCategory creates a new category under the given parent, or at the top level when parentID is nil.
+	CreateCategory(name string, parentID *uint) error
+
+	// RenameCategory changes the name of a category and of the items belonging to it.
+	RenameCategory(categoryID uint, name string) error
+
+	// MoveCategory moves a category, together with its subcategories, under a new parent or to the top level when parentID is nil.
+	MoveCategory(categoryID uint, parentID *uint) error
+
+	// MergeCategories moves the items and the subcategories of the source category into the target one and deletes the source.
+	MergeCategories(sourceID uint, targetID uint) error
+
+	// CreateAttributeDefinition defines a new attribute for the items of a category and of its subcategories.
+	// Options lists the values allowed by enum attributes and is ignored by the other types.
+	CreateAttributeDefinition(categoryID uint, name string, attributeType AttributeType, options []string) error
+
+	// DeleteAttributeDefinition removes an attribute definition together with its values.
+	DeleteAttributeDefinition(definitionID uint) error
+
+	// ListAttributeDefinitions returns the attributes defined for a category and for the categories containing it.
+	ListAttributeDefinitions(categoryID uint) ([]AttributeDefinition, error)
+
+	// FindItemAttributes returns every attribute applying to the category of an item together with its value.
+	FindItemAttributes(itemID uint) ([]ItemAttribute, error)
+
+	// SetItemAttributes validates and stores the values of the attributes of an item, given by definition ID.
+	// Empty values remove the attribute from the item. Either every value is stored or none is.
+	SetItemAttributes(itemID uint, values map[uint]string) error
+
+	// FindItemsByAttributes retrieves the items satisfying every filter.
+	FindItemsByAttributes(filters []AttributeFilter) ([]Item, error)
+
+	// AddAttachment stores a file attached to an item or a warehouse. Files bigger than MaxAttachmentSize or whose content
+	// isn't an image, a PDF or plain text are rejected. A thumbnail is generated for images.
+	AddAttachment(ownerType AttachmentOwner, ownerID uint, fileName string, content []byte) (Attachment, error)
+
+	// ListAttachments returns the attachments of an item or a warehouse in upload order.
+	ListAttachments(ownerType AttachmentOwner, ownerID uint) ([]Attachment, error)
+
+	// OpenAttachment returns an attachment together with its content, or with the content of its thumbnail.
+	OpenAttachment(attachmentID uint, thumbnail bool) (Attachment, []byte, error)
+
+	// DeleteAttachment removes an attachment and its files.
+	DeleteAttachment(attachmentID uint) error
+
+	// CreateProduct creates a product whose variants differ by the given dimensions, like size and colour.
+	CreateProduct(name string, description string, dimensions []string) error
+
+	// FindProductByID searches for a product with the specified ID.
+	FindProductByID(productID uint) (Product, error)
+
+	// ListProducts returns every product with the number of its variants and their total stock.
+	ListProducts() ([]ProductSummary, error)
+
+	// AddVariant makes an item a variant of a product, with a value for each dimension of the product.
+	// Two variants of the same product can't have the same values.
+	AddVariant(productID uint, itemID uint, values map[string]string) error
+
+	// RemoveVariant detaches an item from its product, leaving it as a standalone item.
+	RemoveVariant(itemID uint) error
+
+	// FindVariantMatrix returns the stock of every variant of a product in every warehouse storing any of them.
+	FindVariantMatrix(productID uint) (VariantMatrix, error)
+
+	// SetBOMLine sets the quantity of a component needed to assemble one kit. A quantity of 0 removes the component.
+	// Components can be kits themselves as long as they don't contain the kit.
+	SetBOMLine(kitID uint, componentID uint, quantity int) error
+
+	// FindBOM returns the bill of materials of a kit with the total stock of each component.
+	FindBOM(kitID uint) ([]BOMComponent, error)
+
+	// FindBuildableQuantity computes how many kits can be assembled from the components in stock, in each warehouse and overall.
+	FindBuildableQuantity(kitID uint) (BuildableStock, error)
+
+	// AssembleKits consumes the components of count kits from a warehouse and supplies the kits to the same warehouse atomically.
+	AssembleKits(kitID uint, warehouseID uint, count int) error
+
+	// DisassembleKits consumes count kits from a warehouse and supplies their components to the same warehouse atomically.
+	DisassembleKits(kitID uint, warehouseID uint, count int) error
+
+	// FindReplenishment forecasts the daily demand of every item from its consumption history and suggests the quantity
+	// to reorder for the items whose stock doesn't cover the lead time and the safety stock.
+	FindReplenishment(parameters ReplenishmentParameters) ([]ReplenishmentSuggestion, error)
+
+	// UpdateItemUnitCost sets the cost of a single unit of an item, used to value its consumption.
+	UpdateItemUnitCost(itemID uint, unitCost float64) error
+
+	// FindClassification classifies the items into A/B/C by consumption value or volume and into X/Y/Z by the
+	// variability of their weekly demand, without storing the classes.
+	FindClassification(parameters ClassificationParameters) (ClassificationReport, error)
+
+	// ClassifyItems classifies the items like FindClassification and stores the classes on the items.
+	ClassifyItems(parameters ClassificationParameters) (ClassificationReport, error)
+
+	// FindRecentMovements returns the last limit stock movements, most recent first.
+	FindRecentMovements(limit int) ([]MovementEntry, error)
+
+	// FindDashboard returns the utilization of the warehouses, the limit items most consumed in the last days,
+	// the stock value by category and the last limit stock movements.
+	FindDashboard(days int, limit int) (Dashboard, error)
+
+	// TakeInventorySnapshot stores the current content of every warehouse, speeding up the rebuilding of the
+	// quantities stored after it. Snapshots are also taken automatically as the movements accumulate.
+	TakeInventorySnapshot() (InventorySnapshot, error)
+
+	// ListInventorySnapshots returns every snapshot, most recent first.
+	ListInventorySnapshots() ([]InventorySnapshot, error)
+
+	// FindInventoryAsOf rebuilds the quantities of the items stored in the warehouse identified by warehouseID,
+	// or in every warehouse if warehouseID is 0, at the given time from the recorded movements.
+	FindInventoryAsOf(at time.Time, warehouseID uint) ([]InventoryLine, error)
+
+	// CompareInventory rebuilds the quantities stored at two times, like FindInventoryAsOf, and returns the
+	// items and warehouses whose quantity changed between them.
+	CompareInventory(from time.Time, to time.Time, warehouseID uint) ([]InventoryDifference, error)
+
+	// DispatchShipment removes quantity items from the source warehouse and puts them in transit to the destination
+	// warehouse, which must have room for them together with the stock already travelling to it.
+	DispatchShipment(itemID uint, sourceWarehouseID uint, quantity int, destinationWarehouseID uint) (Shipment, error)
+
+	// ReceiveShipment adds the received quantity of a shipment in transit to its destination warehouse. The received
+	// quantity may differ from the dispatched one, the difference is kept as the discrepancy of the shipment.
+	ReceiveShipment(shipmentID uint, receivedQuantity int) error
+
+	// ListShipments returns the shipments with the given status, most recent first.
+	ListShipments(status ShipmentStatus) ([]ShipmentEntry, error)
+
+	// FindIncomingQuantity returns the number of items in transit to the warehouse identified by warehouseID.
+	FindIncomingQuantity(warehouseID uint) (int, error)
+
+	// SetStockLevel sets the minimum and maximum quantities of an item a warehouse should hold, a maximum of 0 meaning
+	// no maximum. Setting both to 0 removes the levels.
+	SetStockLevel(itemID uint, warehouseID uint, min int, max int) error
+
+	// ListStockLevels returns every stock level together with the quantity currently stored.
+	ListStockLevels() ([]StockLevelEntry, error)
+
+	// PlanRebalancing proposes the transfers bringing the warehouses within their stock levels, using few moves,
+	// preferring near warehouses and respecting their capacity. Nothing is moved.
+	PlanRebalancing() ([]RebalancingMove, error)
+
+	// UpdateWarehousePutawayPriority sets the priority of a warehouse when a supply is split by the putaway.
+	UpdateWarehousePutawayPriority(warehouseID uint, priority int) error
+
+	// PlanPutaway proposes how to split the supply of quantity items across the warehouses with free capacity,
+	// ordering them according to the strategy. Nothing is supplied.
+	PlanPutaway(itemID uint, quantity int, strategy PutawayStrategy) ([]PutawayAllocation, error)
+
+	// ApplyPutaway plans the putaway like PlanPutaway and supplies the allocated quantities atomically.
+	ApplyPutaway(itemID uint, quantity int, strategy PutawayStrategy) ([]PutawayAllocation, error)
+
+	// SupplyItemsWithStatus supplies items like SupplyItems placing them in the given status, for example quarantined
+	// until they pass the quality control. The note is recorded with the movement.
+	SupplyItemsWithStatus(itemID uint, warehouseID uint, quantity int, status StockStatus, note MovementNote) error
+
+	// ConsumeItemsFromStatus consumes items which are in the given status. ConsumeItems and TransferItems only use
+	// available items. The note is recorded with the movement, can't use a write-off reason and may charge an open
+	// project.
+	ConsumeItemsFromStatus(itemID uint, warehouseID uint, quantity int, status StockStatus, note MovementNote) error
+
+	// ChangeStockStatus moves quantity items stored in a warehouse from a status to another.
+	ChangeStockStatus(itemID uint, warehouseID uint, quantity int, from StockStatus, to StockStatus) error
+
+	// FindStockStatuses returns the breakdown by status of the stock of the item identified by itemID in the warehouse
+	// identified by warehouseID. Either ID may be 0 to select every item or every warehouse.
+	FindStockStatuses(itemID uint, warehouseID uint) ([]StockStatusLine, error)
+
+	// AuthorizeReturn creates a return authorisation for quantity items coming back from a customer or going back to
+	// a supplier. orderReference and movementID link the return to the original order or stock movement, if known.
+	AuthorizeReturn(kind ReturnKind, itemID uint, quantity int, reason string, orderReference string, movementID *uint) (ReturnAuthorization, error)
+
+	// ReceiveReturn receives up to the authorized quantity of a customer return into the warehouse identified by
+	// warehouseID, then restocks, quarantines or scraps the goods according to disposition.
+	ReceiveReturn(returnID uint, warehouseID uint, quantity int, disposition ReturnDisposition) error
+
+	// ShipSupplierReturn sends the goods of a supplier return back, consuming them from the given status of the
+	// warehouse identified by warehouseID.
+	ShipSupplierReturn(returnID uint, warehouseID uint, status StockStatus) error
+
+	// ListReturns returns the return authorisations with the given status, most recent first.
+	ListReturns(status ReturnStatus) ([]ReturnEntry, error)
+
+	// TransferItemsWithNote transfers items like TransferItems recording the note with both movements.
+	TransferItemsWithNote(itemID uint, sourceWarehouseID uint, quantity int, destinationWarehouseID uint, note MovementNote) error
+
+	// ListReasonCodes returns the reason codes, the ordinary ones before the write-off ones.
+	ListReasonCodes() ([]ReasonCode, error)
+
+	// CreateReasonCode adds a reason code, usable only by write-offs when writeOff is true.
+	CreateReasonCode(code string, description string, writeOff bool) error
+
+	// DeleteReasonCode removes the reason code identified by reasonID. The movements keep their reason.
+	DeleteReasonCode(reasonID uint) error
+
+	// WriteOffItems removes stock which was lost, stolen, expired or scrapped from the given status of the warehouse
+	// identified by warehouseID. The note must carry a write-off reason code.
+	WriteOffItems(itemID uint, warehouseID uint, quantity int, status StockStatus, note MovementNote) error
+
+	// FindShrinkage returns the stock written off or scrapped between from and to grouped by reason and warehouse.
+	FindShrinkage(from time.Time, to time.Time) (ShrinkageReport, error)
+
+	// SetItemArchived archives or restores the item identified by itemID. Archived items are left out of ListAllItems
+	// and of the searches and can't be supplied, while their stock can still be consumed and transferred.
+	SetItemArchived(itemID uint, archived bool) error
+
+	// ListArchivedItems returns the archived items ordered by name.
+	ListArchivedItems() ([]Item, error)
+
+	// MergeItems folds the item identified by duplicateID into the one identified by canonicalID, moving its stock,
+	// history, shipments, returns and attachments, and then deletes the duplicate.
+	MergeItems(duplicateID uint, canonicalID uint) error
+
+	// CreateSupplier adds a supplier the items can be purchased from.
+	CreateSupplier(name string, contact string) error
+
+	// ListSuppliers returns the suppliers ordered by name.
+	ListSuppliers() ([]Supplier, error)
+
+	// DeleteSupplier removes the supplier identified by supplierID together with its catalog.
+	DeleteSupplier(supplierID uint) error
+
+	// SetSupplierItem adds or replaces an entry of the catalog of a supplier. A preferred entry makes the other
+	// suppliers of the item not preferred.
+	SetSupplierItem(entry SupplierItem) error
+
+	// RemoveSupplierItem removes an item from the catalog of a supplier.
+	RemoveSupplierItem(supplierID uint, itemID uint) error
+
+	// ListSupplierItems returns the catalog entries of the item identified by itemID from the supplier identified by
+	// supplierID, the preferred ones first. Either ID may be 0 to select every item or every supplier.
+	ListSupplierItems(itemID uint, supplierID uint) ([]SupplierItemEntry, error)
+
+	// FindItemsBySupplierPartNumber returns the items whose supplier part number contains partNumber.
+	FindItemsBySupplierPartNumber(partNumber string) ([]Item, error)
+
+	// CheckOutItems lends quantity items of the warehouse identified by warehouseID to the borrower until dueAt. A
+	// serialized unit is lent alone. The loaned items stay in the warehouse but aren't available.
+	CheckOutItems(itemID uint, warehouseID uint, quantity int, borrower string, serialNumber string, dueAt time.Time) (Loan, error)
+
+	// CheckInLoan closes the loan identified by loanID, returning its items to the warehouse identified by
+	// warehouseID, which may differ from the one they were checked out from.
+	CheckInLoan(loanID uint, warehouseID uint) error
+
+	// ListLoans returns the open loans by due date when open is true, otherwise the closed loans most recent first.
+	ListLoans(open bool) ([]LoanEntry, error)
+
+	// FindOverdueLoans returns the open loans whose due date is before now.
+	FindOverdueLoans(now time.Time) ([]LoanEntry, error)
+
+	// CreateProject adds a project or cost centre which consumptions can be charged to.
+	CreateProject(code string, name string) error
+
+	// ListProjects returns every project, the open ones first.
+	ListProjects() ([]Project, error)
+
+	// SetProjectClosed closes the project identified by projectID, so that it can't be charged anymore, or reopens it.
+	SetProjectClosed(projectID uint, closed bool) error
+
+	// FindProjectConsumption returns the quantity and value of the items consumed by each project between from and to.
+	FindProjectConsumption(from time.Time, to time.Time) (ProjectReport, error)
+
+	// SetItemApprovalThreshold sets the quantity above which consumptions and transfers of the item identified by
+	// itemID need an approval, 0 to never require one.
+	SetItemApprovalThreshold(itemID uint, threshold int) error
+
+	// RequestApproval stores a consumption or a transfer which waits for an approval instead of being executed.
+	RequestApproval(request ApprovalRequest) (ApprovalRequest, error)
+
+	// ApproveRequest executes the pending request identified by requestID on behalf of the approver. A request which
+	// can't be executed stays pending.
+	ApproveRequest(requestID uint, approver string) error
+
+	// RejectRequest closes the pending request identified by requestID without executing it.
+	RejectRequest(requestID uint, approver string, comment string) error
+
+	// ListApprovalRequests returns the approval requests with the given status, most recent first.
+	ListApprovalRequests(status ApprovalStatus) ([]ApprovalEntry, error)
+
+	// FindApprovalEvents returns the audit trail of the request identified by requestID, or of every request when it
+	// is 0, most recent first.
+	FindApprovalEvents(requestID uint) ([]ApprovalEvent, error)
+
 	// Close closes the repository connection, releasing any allocated resources. Returns an error if the operation fails.
 	Close() error
 }
@@ -125,6 +469,10 @@ type WarehouseRepository interface {
 // GORMSQLiteWarehouseRepository offers an implementation of WarehouseRepository using GORM and SQLite
 type GORMSQLiteWarehouseRepository struct {
 	DB *gorm.DB
+	// AttachmentDir is the directory storing the files attached to items and warehouses
+	AttachmentDir string
+	// note is attached to the movements recorded by the repository, see withNote
+	note MovementNote
 }
 
 func NewGORMSQLiteWarehouseRepository(DBName string) (*GORMSQLiteWarehouseRepository, error) {
@@ -132,11 +480,27 @@ func NewGORMSQLiteWarehouseRepository(DBName string) (*GORMSQLiteWarehouseReposi
 	if err1 != nil {
 		return nil, err1
 	}
-	err2 := database.AutoMigrate(&Warehouse{}, &Item{}, &WarehouseItem{})
+	seedReasons := !database.Migrator().HasTable(&ReasonCode{})
+	err2 := database.AutoMigrate(&Warehouse{}, &Item{}, &WarehouseItem{}, &Category{}, &AttributeDefinition{}, &AttributeValue{}, &Attachment{}, &Product{}, &VariantOption{}, &BOMLine{}, &StockMovement{}, &InventorySnapshot{}, &SnapshotLine{}, &Shipment{}, &StockLevel{}, &StockStatusBucket{}, &ReturnAuthorization{}, &ReasonCode{}, &Supplier{}, &SupplierItem{}, &Loan{}, &Project{}, &ApprovalRequest{}, &ApprovalEvent{})
 	if err2 != nil {
 		return nil, err2
 	}
-	return &GORMSQLiteWarehouseRepository{DB: database}, nil
+	repository := &GORMSQLiteWarehouseRepository{DB: database, AttachmentDir: attachmentDirectory(DBName)}
+	err3 := repository.migrateCategories()
+	if err3 != nil {
+		return nil, err3
+	}
+	err4 := repository.migrateSnapshots()
+	if err4 != nil {
+		return nil, err4
+	}
+	if seedReasons {
+		err5 := repository.seedReasonCodes()
+		if err5 != nil {
+			return nil, err5
+		}
+	}
+	return repository, nil
 }
 
 func (r *GORMSQLiteWarehouseRepository) Close() error {
@@ -147,6 +511,13 @@ func (r *GORMSQLiteWarehouseRepository) Close() error {
 	return db.Close()
 }
 
+// withTransaction returns a copy of the repository whose operations run inside the given transaction
+func (r *GORMSQLiteWarehouseRepository) withTransaction(tx *gorm.DB) *GORMSQLiteWarehouseRepository {
+	txRepository := *r
+	txRepository.DB = tx
+	return &txRepository
+}
+
 func (r *GORMSQLiteWarehouseRepository) ListAllWarehouses() ([]Warehouse, error) {
 	var warehouses []Warehouse
 	err := r.DB.Find(&warehouses).Error
@@ -155,44 +526,81 @@ func (r *GORMSQLiteWarehouseRepository) ListAllWarehouses() ([]Warehouse, error)
 
 func (r *GORMSQLiteWarehouseRepository) ListAllItems() ([]Item, error) {
 	var items []Item
-	err := r.DB.Find(&items).Error
+	err := r.DB.Where("archived = ?", false).Find(&items).Error
 	return items, err
 }
 
 func (r *GORMSQLiteWarehouseRepository) CreateItem(name string, category string, description string) error {
-	return r.DB.Create(&Item{Name: name, Category: category, Description: description}).Error
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		categoryID, categoryName, err := r.withTransaction(tx).resolveCategory(category)
+		if err != nil {
+			return err
+		}
+		return tx.Create(&Item{Name: name, Category: categoryName, CategoryID: categoryID, Description: description}).Error
+	})
 }
 
 func (r *GORMSQLiteWarehouseRepository) CreateWarehouse(name string, position string, capacity int) error {
 	return r.DB.Create(&Warehouse{Name: name, Position: position, Capacity: capacity}).Error
 }
 
-func (r *GORMSQLiteWarehouseRepository) UpdateItem(itemID uint, name string, category string, description string) error {
-	var item Item
-	err := r.DB.First(&item, itemID).Error
-	if err != nil {
-		return err
-	}
-	item.Name = name
-	item.Description = description
-	item.Category = category
-	return r.DB.Save(&item).Error
+func (r *GORMSQLiteWarehouseRepository) UpdateItem(itemID uint, name string, category string, description string, version uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		txRepository := r.withTransaction(tx)
+		var item Item
+		err1 := tx.First(&item, itemID).Error
+		if err1 != nil {
+			return err1
+		}
+		categoryID, categoryName, err2 := txRepository.resolveCategory(category)
+		if err2 != nil {
+			return err2
+		}
+		return txRepository.updateVersioned(&item, "item", itemID, item.Version, version, map[string]interface{}{
+			"name":        name,
+			"description": description,
+			"category":    categoryName,
+			"category_id": categoryID,
+		})
+	})
 }
 
-func (r *GORMSQLiteWarehouseRepository) UpdateWarehouse(warehouseID uint, name string, position string, capacity int) error {
+func (r *GORMSQLiteWarehouseRepository) UpdateWarehouse(warehouseID uint, name string, position string, capacity int, version uint) error {
 	var warehouse Warehouse
 	err := r.DB.First(&warehouse, warehouseID).Error
 	if err != nil {
 		return err
 	}
-	if warehouse.Capacity <= capacity {
-		warehouse.Name = name
-		warehouse.Capacity = capacity
-		warehouse.Position = position
-	} else {
+	if warehouse.Capacity > capacity {
 		return errors.New("cannot downgrade the capacity of a warehouse")
 	}
-	return r.DB.Save(&warehouse).Error
+	return r.updateVersioned(&warehouse, "warehouse", warehouseID, warehouse.Version, version, map[string]interface{}{
+		"name":     name,
+		"position": position,
+		"capacity": capacity,
+	})
+}
+
+// updateVersioned writes the given columns only if the row is still at the expected version, incrementing it.
+// The condition is part of the UPDATE statement so that concurrent edits can't both succeed.
+func (r *GORMSQLiteWarehouseRepository) updateVersioned(record interface{}, resource string, id uint, currentVersion uint, expectedVersion uint, columns map[string]interface{}) error {
+	if currentVersion != expectedVersion {
+		return &VersionConflictError{Resource: resource, ID: id, ExpectedVersion: expectedVersion, CurrentVersion: currentVersion}
+	}
+	columns["version"] = gorm.Expr("version + 1")
+	result := r.DB.Model(record).Where("version = ?", expectedVersion).Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var latestVersion uint
+		err := r.DB.Model(record).Select("version").Where("id = ?", id).Scan(&latestVersion).Error
+		if err != nil {
+			return err
+		}
+		return &VersionConflictError{Resource: resource, ID: id, ExpectedVersion: expectedVersion, CurrentVersion: latestVersion}
+	}
+	return nil
 }
 
 func (r *GORMSQLiteWarehouseRepository) FindItemByID(itemID uint) (Item, error) {
@@ -209,7 +617,7 @@ func (r *GORMSQLiteWarehouseRepository) FindWarehouseByID(warehouseID uint) (War
 
 func (r *GORMSQLiteWarehouseRepository) FindItemsByKeyword(keyword string) ([]Item, error) {
 	var items []Item
-	err := r.DB.Where("description LIKE ?", "%"+keyword+"%").Find(&items).Error
+	err := r.DB.Where("description LIKE ? AND archived = ?", "%"+keyword+"%", false).Find(&items).Error
 	return items, err
 }
 
@@ -233,53 +641,45 @@ func (r *GORMSQLiteWarehouseRepository) FindWarehousesByPosition(position string
 
 func (r *GORMSQLiteWarehouseRepository) FindItemsByCategory(category string) ([]Item, error) {
 	var items []Item
-	err := r.DB.Where("category = ?", category).Find(&items).Error
-	return items, err
+	if strings.EqualFold(strings.TrimSpace(category), DefaultCategory) {
+		err1 := r.DB.Where("category_id IS NULL AND archived = ?", false).Find(&items).Error
+		return items, err1
+	}
+	found, err2 := r.findCategoryByName(category)
+	if errors.Is(err2, gorm.ErrRecordNotFound) {
+		return items, nil
+	}
+	if err2 != nil {
+		return nil, err2
+	}
+	err3 := r.DB.Where("category_id IN (?) AND archived = ?", r.DB.Raw(categoryDescendants, found.ID), false).Find(&items).Error
+	return items, err3
 }
 
 func (r *GORMSQLiteWarehouseRepository) FindItemsInWarehouse(warehouseID uint) ([]LoadedItemPack, error) {
-	var correspondence []WarehouseItem
-	err1 := r.DB.Model(&WarehouseItem{}).Where("warehouse_id = ?", warehouseID).Find(&correspondence).Error
-	if err1 != nil {
-		return nil, err1
-	}
-	return r.findItemPacks(correspondence)
+	var res []LoadedItemPack
+	err := r.loadedItemPacks().Where("warehouse_items.warehouse_id = ?", warehouseID).
+		Order("warehouse_items.item_id").Scan(&res).Error
+	return res, err
 }
 
 func (r *GORMSQLiteWarehouseRepository) FindWarehousesForItem(itemID uint) ([]LoadedItemPack, error) {
-	var correspondence []WarehouseItem
-	err1 := r.DB.Model(&WarehouseItem{}).Where("item_id = ?", itemID).Find(&correspondence).Error
-	if err1 != nil {
-		return nil, err1
-	}
-	return r.findItemPacks(correspondence)
+	var res []LoadedItemPack
+	err := r.loadedItemPacks().Where("warehouse_items.item_id = ?", itemID).
+		Order("warehouse_items.warehouse_id").Scan(&res).Error
+	return res, err
 }
 
-func (r *GORMSQLiteWarehouseRepository) findItemPacks(correspondence []WarehouseItem) ([]LoadedItemPack, error) {
-	var res []LoadedItemPack
-	for _, v := range correspondence {
-		temp1, err2 := r.FindWarehouseByID(v.WarehouseID)
-		temp2, err3 := r.FindItemByID(v.ItemID)
-		if err2 != nil {
-			return nil, err2
-		}
-		if err3 != nil {
-			return nil, err3
-		}
-		res = append(res, LoadedItemPack{
-			ItemID:            v.ItemID,
-			ItemName:          temp2.Name,
-			ItemDescription:   temp2.Description,
-			ItemCategory:      temp2.Category,
-			ItemQuantity:      v.Quantity,
-			WarehouseID:       v.WarehouseID,
-			WarehouseName:     temp1.Name,
-			WarehousePosition: temp1.Position,
-			WarehouseCapacity: temp1.Capacity,
-		})
-	}
-	return res, nil
+// loadedItemPacks builds the query joining every association with its item and warehouse in a single statement
+func (r *GORMSQLiteWarehouseRepository) loadedItemPacks() *gorm.DB {
+	return r.DB.Table("warehouse_items").
+		Select("warehouse_items.item_id, items.name AS item_name, items.description AS item_description, " +
+			"items.category AS item_category, warehouse_items.quantity AS item_quantity, " +
+			"warehouse_items.warehouse_id, warehouses.name AS warehouse_name, " +
+			"warehouses.position AS warehouse_position, warehouses.capacity AS warehouse_capacity").
+		Joins(validAssociations)
 }
+
 func (r *GORMSQLiteWarehouseRepository) DeleteItem(itemID uint) error {
 	var item Item
 	err := r.DB.First(&item, itemID).Error
@@ -288,9 +688,12 @@ func (r *GORMSQLiteWarehouseRepository) DeleteItem(itemID uint) error {
 	}
 	if item.Quantity > 0 {
 		return errors.New("item is not empty")
-	} else {
-		return r.DB.Delete(&item).Error
 	}
+	err2 := r.DB.Delete(&item).Error
+	if err2 != nil {
+		return err2
+	}
+	return r.deleteAttachmentsOf(ItemAttachment, itemID)
 }
 
 func (r *GORMSQLiteWarehouseRepository) DeleteWarehouse(warehouseID uint) error {
@@ -306,12 +709,29 @@ func (r *GORMSQLiteWarehouseRepository) DeleteWarehouse(warehouseID uint) error
 	}
 	if len(correspondence) != 0 {
 		return errors.New("warehouse is not empty")
-	} else {
-		return r.DB.Delete(&warehouse).Error
 	}
+	incoming, err3 := r.FindIncomingQuantity(warehouseID)
+	if err3 != nil {
+		return err3
+	}
+	if incoming > 0 {
+		return errors.New("warehouse has shipments in transit to it")
+	}
+	err4 := r.DB.Delete(&warehouse).Error
+	if err4 != nil {
+		return err4
+	}
+	return r.deleteAttachmentsOf(WarehouseAttachment, warehouseID)
 }
 
 func (r *GORMSQLiteWarehouseRepository) SupplyItems(itemID uint, warehouseID uint, quantity int) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		return r.withTransaction(tx).supplyItems(itemID, warehouseID, quantity, SupplyMovement)
+	})
+}
+
+// supplyItems adds items to a warehouse recording the movement with the given kind
+func (r *GORMSQLiteWarehouseRepository) supplyItems(itemID uint, warehouseID uint, quantity int, kind MovementKind) error {
 	if quantity <= 0 {
 		return errors.New("quantity must be greater than 0")
 	}
@@ -324,6 +744,10 @@ func (r *GORMSQLiteWarehouseRepository) SupplyItems(itemID uint, warehouseID uin
 	if err1 != nil {
 		return err1
 	}
+	// stock already owned, like shipments and returns, can still come back to an archived item
+	if item.Archived && kind == SupplyMovement {
+		return errors.New("item \"" + item.Name + "\" is archived")
+	}
 	err2 := r.DB.First(&warehouse, warehouseID).Error
 	if err2 != nil {
 		return err2
@@ -336,12 +760,16 @@ func (r *GORMSQLiteWarehouseRepository) SupplyItems(itemID uint, warehouseID uin
 	if err3 != nil {
 		return err3
 	}
-	return r.supplyUpdateItems(item, quantity)
+	err4 := r.supplyUpdateItems(item, quantity)
+	if err4 != nil {
+		return err4
+	}
+	return r.recordMovement(kind, itemID, warehouseID, quantity)
 }
 
 func (r *GORMSQLiteWarehouseRepository) supplyUpdateItems(item Item, quantity int) error {
-	item.Quantity += quantity
-	err8 := r.DB.Save(&item).Error
+	// only the quantity is written so that concurrent edits of the item's details aren't overwritten
+	err8 := r.DB.Model(&item).Update("quantity", gorm.Expr("quantity + ?", quantity)).Error
 	if err8 != nil {
 		return err8
 	}
@@ -382,6 +810,12 @@ func (r *GORMSQLiteWarehouseRepository) checkIfEnoughCapacity(warehouseID uint,
 	} else {
 		nItems = 0
 	}
+	// the stock travelling to the warehouse needs room as well
+	incoming, err5 := r.FindIncomingQuantity(warehouseID)
+	if err5 != nil {
+		return err5
+	}
+	nItems += incoming
 	if nItems+quantity > warehouse.Capacity {
 		return errors.New("warehouse is full: " + strconv.Itoa(nItems+quantity) + " > " + strconv.Itoa(warehouse.Capacity))
 	}
@@ -389,6 +823,13 @@ func (r *GORMSQLiteWarehouseRepository) checkIfEnoughCapacity(warehouseID uint,
 }
 
 func (r *GORMSQLiteWarehouseRepository) ConsumeItems(itemID uint, warehouseID uint, quantity int) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		return r.withTransaction(tx).consumeItems(itemID, warehouseID, quantity, ConsumeMovement)
+	})
+}
+
+// consumeItems removes items from a warehouse recording the movement with the given kind
+func (r *GORMSQLiteWarehouseRepository) consumeItems(itemID uint, warehouseID uint, quantity int, kind MovementKind) error {
 	if quantity <= 0 {
 		return errors.New("quantity must be greater than 0")
 	}
@@ -411,12 +852,23 @@ func (r *GORMSQLiteWarehouseRepository) ConsumeItems(itemID uint, warehouseID ui
 	if err4 != nil {
 		return err4
 	}
-	return r.consumeUpdateItems(item, quantity)
+	// the stock which isn't available stays in the warehouse
+	available, err6 := r.availableQuantity(itemID, warehouseID)
+	if err6 != nil {
+		return err6
+	}
+	if available < 0 {
+		return errors.New("not enough available items in specified warehouse: " + strconv.Itoa(available+quantity) + " < " + strconv.Itoa(quantity))
+	}
+	err5 := r.consumeUpdateItems(item, quantity)
+	if err5 != nil {
+		return err5
+	}
+	return r.recordMovement(kind, itemID, warehouseID, -quantity)
 }
 
 func (r *GORMSQLiteWarehouseRepository) consumeUpdateItems(item Item, quantity int) error {
-	item.Quantity -= quantity
-	err5 := r.DB.Save(&item).Error
+	err5 := r.DB.Model(&item).Update("quantity", gorm.Expr("quantity - ?", quantity)).Error
 	if err5 != nil {
 		return err5
 	}
@@ -450,16 +902,23 @@ func (r *GORMSQLiteWarehouseRepository) checkIfEnoughItems(item Item, quantity i
 }
 
 func (r *GORMSQLiteWarehouseRepository) TransferItems(itemID uint, sourceWarehouseID uint, quantity int, destinationWarehouseID uint) error {
+	return r.TransferItemsWithNote(itemID, sourceWarehouseID, quantity, destinationWarehouseID, MovementNote{})
+}
+
+func (r *GORMSQLiteWarehouseRepository) TransferItemsWithNote(itemID uint, sourceWarehouseID uint, quantity int, destinationWarehouseID uint, note MovementNote) error {
 	if quantity <= 0 {
 		return errors.New("quantity must be greater than 0")
 	}
-	err1 := r.ConsumeItems(itemID, sourceWarehouseID, quantity)
-	if err1 != nil {
-		return err1
-	}
-	err2 := r.SupplyItems(itemID, destinationWarehouseID, quantity)
-	if err2 != nil {
-		return err2
-	}
-	return nil
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := r.withTransaction(tx).checkReasonCode(note.Reason, false)
+		if err != nil {
+			return err
+		}
+		txRepository := r.withTransaction(tx).withNote(note)
+		err1 := txRepository.consumeItems(itemID, sourceWarehouseID, quantity, TransferMovement)
+		if err1 != nil {
+			return err1
+		}
+		return txRepository.supplyItems(itemID, destinationWarehouseID, quantity, TransferMovement)
+	})
 }
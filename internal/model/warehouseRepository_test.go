@@ -1,6 +1,7 @@
 package model
 
 import (
+	"errors"
 	"os"
 	"strconv"
 	"testing"
@@ -221,7 +222,7 @@ func TestGORMRepository(t *testing.T) {
 		}
 	})
 	t.Run("UpdateItem", func(t *testing.T) {
-		err2 := rep.UpdateItem(1, "potatoes", "vegetables", "agata potatoes")
+		err2 := rep.UpdateItem(1, "potatoes", "vegetables", "agata potatoes", 1)
 		if err2 != nil {
 			t.Fatalf("Reported error: %v", err2)
 		}
@@ -235,7 +236,7 @@ func TestGORMRepository(t *testing.T) {
 		}
 	})
 	t.Run("UpdateWarehouse", func(t *testing.T) {
-		err2 := rep.UpdateWarehouse(2, "Big warehouse", "Florence", 1500)
+		err2 := rep.UpdateWarehouse(2, "Big warehouse", "Florence", 1500, 1)
 		if err2 != nil {
 			t.Fatalf("Reported error: %v", err2)
 		}
@@ -248,6 +249,25 @@ func TestGORMRepository(t *testing.T) {
 			t.Errorf("Warehouse wasn't updated correctly\nexpected values-\nname: \"Big warehouse\", position: \"Florence\", capacity: 1500\nactual values-\nname: \"%s\", position: \"%s\", capacity: %d", temp.Name, temp.Position, temp.Capacity)
 		}
 	})
+	t.Run("UpdateConflict", func(t *testing.T) {
+		err2 := rep.UpdateItem(1, "potatoes", "vegetables", "old potatoes", 1)
+		var conflict *VersionConflictError
+		if !errors.As(err2, &conflict) {
+			t.Fatalf("No version conflict reported when updating an outdated item, error: %v", err2)
+		}
+		if conflict.CurrentVersion != 2 || conflict.ExpectedVersion != 1 {
+			t.Errorf("Incorrect versions reported\nexpected versions: 1, 2\nactual versions: %d, %d", conflict.ExpectedVersion, conflict.CurrentVersion)
+		}
+		var temp Item
+		_ = rep.DB.First(&temp, 1).Error
+		if temp.Description != "agata potatoes" {
+			t.Errorf("Outdated update overwrote the item\nexpected description: agata potatoes\nactual description: %s", temp.Description)
+		}
+		err3 := rep.UpdateWarehouse(2, "Big warehouse", "Florence", 1600, 1)
+		if !errors.As(err3, &conflict) {
+			t.Errorf("No version conflict reported when updating an outdated warehouse, error: %v", err3)
+		}
+	})
 	t.Run("DeleteItem", func(t *testing.T) {
 		err2 := rep.DeleteItem(4)
 		if err2 != nil {
@@ -457,3 +477,92 @@ func TestGORMRepository(t *testing.T) {
 		}
 	})
 }
+
+// setUpBenchmarkRepository generates a repository where every one of nWarehouses warehouses stores nItems different items
+func setUpBenchmarkRepository(b *testing.B, name string, nItems int, nWarehouses int) *GORMSQLiteWarehouseRepository {
+	_ = os.Remove(name)
+	rep, err := NewGORMSQLiteWarehouseRepository(name)
+	if err != nil {
+		b.Fatalf("Reported error: %v", err)
+	}
+	b.Cleanup(func() {
+		_ = rep.Close()
+		_ = os.Remove(name)
+	})
+	warehouses := make([]Warehouse, 0, nWarehouses)
+	for i := 0; i < nWarehouses; i++ {
+		warehouses = append(warehouses, Warehouse{Name: "warehouse " + strconv.Itoa(i), Position: "Florence", Capacity: 10 * nItems})
+	}
+	items := make([]Item, 0, nItems)
+	for i := 0; i < nItems; i++ {
+		items = append(items, Item{Name: "item " + strconv.Itoa(i), Category: "generated", Description: "generated item", Quantity: 5 * nWarehouses})
+	}
+	correspondence := make([]WarehouseItem, 0, nItems*nWarehouses)
+	for i := 1; i <= nWarehouses; i++ {
+		for j := 1; j <= nItems; j++ {
+			correspondence = append(correspondence, WarehouseItem{ItemID: uint(j), WarehouseID: uint(i), Quantity: 5})
+		}
+	}
+	err1 := rep.DB.CreateInBatches(warehouses, 500).Error
+	err2 := rep.DB.CreateInBatches(items, 500).Error
+	err3 := rep.DB.CreateInBatches(correspondence, 500).Error
+	if err1 != nil || err2 != nil || err3 != nil {
+		b.Fatalf("Reported errors: %v %v %v", err1, err2, err3)
+	}
+	return rep
+}
+
+// findItemPacksPerRow reproduces the previous lookup, which loaded the item and the warehouse of every association separately
+func findItemPacksPerRow(rep *GORMSQLiteWarehouseRepository, warehouseID uint) ([]LoadedItemPack, error) {
+	var correspondence []WarehouseItem
+	err1 := rep.DB.Where("warehouse_id = ?", warehouseID).Find(&correspondence).Error
+	if err1 != nil {
+		return nil, err1
+	}
+	res := make([]LoadedItemPack, 0, len(correspondence))
+	for _, v := range correspondence {
+		warehouse, err2 := rep.FindWarehouseByID(v.WarehouseID)
+		if err2 != nil {
+			return nil, err2
+		}
+		item, err3 := rep.FindItemByID(v.ItemID)
+		if err3 != nil {
+			return nil, err3
+		}
+		res = append(res, LoadedItemPack{ItemID: v.ItemID, ItemName: item.Name, ItemQuantity: v.Quantity, WarehouseID: v.WarehouseID, WarehouseName: warehouse.Name})
+	}
+	return res, nil
+}
+
+func BenchmarkFindItemsInWarehouse(b *testing.B) {
+	for _, nItems := range []int{100, 2000} {
+		rep := setUpBenchmarkRepository(b, "bench_items.db", nItems, 1)
+		b.Run("joined "+strconv.Itoa(nItems)+" items", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				packs, err := rep.FindItemsInWarehouse(1)
+				if err != nil || len(packs) != nItems {
+					b.Fatalf("Unexpected result: %d packs, error: %v", len(packs), err)
+				}
+			}
+		})
+		b.Run("per row "+strconv.Itoa(nItems)+" items", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				packs, err := findItemPacksPerRow(rep, 1)
+				if err != nil || len(packs) != nItems {
+					b.Fatalf("Unexpected result: %d packs, error: %v", len(packs), err)
+				}
+			}
+		})
+		_ = rep.Close()
+	}
+}
+
+func BenchmarkFindWarehousesForItem(b *testing.B) {
+	rep := setUpBenchmarkRepository(b, "bench_warehouses.db", 50, 200)
+	for i := 0; i < b.N; i++ {
+		packs, err := rep.FindWarehousesForItem(1)
+		if err != nil || len(packs) != 200 {
+			b.Fatalf("Unexpected result: %d packs, error: %v", len(packs), err)
+		}
+	}
+}
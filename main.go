@@ -1,7 +1,69 @@
 package main
 
-import "WarehouseManager/internal/handlers"
+import (
+	"WarehouseManager/internal/handlers"
+	"WarehouseManager/internal/model"
+	"flag"
+	"fmt"
+	"os"
+)
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(checkConsistency(os.Args[2:]))
+	}
 	handlers.RunAPP("internal/handlers/templates/")
 }
+
+// checkConsistency implements the "check" command, which reports the inconsistencies of a user database
+// and optionally repairs them. It returns the exit code of the program.
+func checkConsistency(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	database := flags.String("db", "", "path of the user database to check, e.g. data/usr0.db")
+	repair := flags.Bool("repair", false, "repair the problems by recomputing them from the warehouse contents")
+	err1 := flags.Parse(args)
+	if err1 != nil {
+		return 2
+	}
+	if *database == "" {
+		fmt.Fprintln(os.Stderr, "usage: WarehouseManager check -db <database> [-repair]")
+		return 2
+	}
+	_, err2 := os.Stat(*database)
+	if err2 != nil {
+		fmt.Fprintln(os.Stderr, err2)
+		return 1
+	}
+	repository, err3 := model.NewGORMSQLiteWarehouseRepository(*database)
+	if err3 != nil {
+		fmt.Fprintln(os.Stderr, err3)
+		return 1
+	}
+	defer repository.Close()
+	var inconsistencies []model.Inconsistency
+	var err4 error
+	if *repair {
+		inconsistencies, err4 = repository.RepairConsistency()
+	} else {
+		inconsistencies, err4 = repository.CheckConsistency()
+	}
+	if err4 != nil {
+		fmt.Fprintln(os.Stderr, err4)
+		return 1
+	}
+	if len(inconsistencies) == 0 {
+		fmt.Println("no problems found")
+		return 0
+	}
+	for _, v := range inconsistencies {
+		repaired := ""
+		if *repair && v.Repairable {
+			repaired = " (repaired)"
+		}
+		fmt.Printf("%s: %s%s\n", v.Kind, v.Description, repaired)
+	}
+	if *repair {
+		return 0
+	}
+	return 1
+}